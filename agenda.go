@@ -0,0 +1,194 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"io"
+	"regexp"
+	"strings"
+	"time"
+)
+
+const (
+	orgDate     = "2006-01-02 Mon"
+	orgDateTime = "2006-01-02 Mon 15:04"
+)
+
+var markdownEscaper = strings.NewReplacer(`|`, `\|`, "\n", " ", `\`, `\\`)
+
+// writeMarkdown writes events as a Markdown table for status mails and
+// wikis.
+func writeMarkdown(w io.Writer, events []Event) error {
+	bw := bufio.NewWriter(w)
+	fmt.Fprintln(bw, "| Event | Target | Remaining | Tags |")
+	fmt.Fprintln(bw, "| --- | --- | --- | --- |")
+	for _, e := range events {
+		t := time.Unix(e.Time, 0)
+		tags := make([]string, len(e.Tags))
+		for i, tag := range e.Tags {
+			tags[i] = "`" + strings.ReplaceAll(tag, "`", "") + "`"
+		}
+		fmt.Fprintf(bw, "| %s | %s | %s | %s |\n", markdownEscaper.Replace(e.Name),
+			t.Format("Mon 2006-01-02 15:04 MST"), shortCountdown(time.Until(t)), strings.Join(tags, " "))
+	}
+	return bw.Flush()
+}
+
+// orgTag makes a tag valid in org-mode, which only allows letters, digits
+// and _@#%.
+func orgTag(tag string) string {
+	return strings.Map(func(r rune) rune {
+		if r == '_' || r == '@' || r == '#' || r == '%' || r >= '0' && r <= '9' ||
+			r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z' || r > 0x7f && !strings.ContainsRune(" \t:", r) {
+			return r
+		}
+		return '_'
+	}, tag)
+}
+
+// orgTimestamp formats an active org timestamp, leaving out the time of day
+// for events at midnight.
+func orgTimestamp(t time.Time) string {
+	if t.Hour() == 0 && t.Minute() == 0 {
+		return "<" + t.Format(orgDate) + ">"
+	}
+	return "<" + t.Format(orgDateTime) + ">"
+}
+
+// writeOrg writes events as org-mode headlines with DEADLINE timestamps,
+// which parseOrg reads back.
+func writeOrg(w io.Writer, events []Event) error {
+	bw := bufio.NewWriter(w)
+	for _, e := range events {
+		headline := "* " + strings.ReplaceAll(e.Name, "\n", " ")
+		if len(e.Tags) > 0 {
+			tags := make([]string, len(e.Tags))
+			for i, tag := range e.Tags {
+				tags[i] = orgTag(tag)
+			}
+			headline += " :" + strings.Join(tags, ":") + ":"
+		}
+		fmt.Fprintln(bw, headline)
+		fmt.Fprintln(bw, "  DEADLINE: "+orgTimestamp(time.Unix(e.Time, 0)))
+		fmt.Fprintln(bw, "  :PROPERTIES:")
+		fmt.Fprintln(bw, "  :ID: "+e.key())
+		fmt.Fprintln(bw, "  :END:")
+		for line := range strings.Lines(e.Notes) {
+			fmt.Fprintln(bw, "  "+strings.TrimRight(line, "\n"))
+		}
+	}
+	return bw.Flush()
+}
+
+var (
+	orgHeadline = regexp.MustCompile(`^(\*+)\s+(.*?)(?:\s+(:[^\s:]+(?::[^\s:]+)*:))?\s*$`)
+	orgKeyword  = regexp.MustCompile(`^(TODO|NEXT|STARTED|WAITING|HOLD|DONE|CANCELED|CANCELLED)\s+`)
+	orgPriority = regexp.MustCompile(`^\[#[A-Za-z0-9]\]\s+`)
+	orgPlanning = regexp.MustCompile(`(DEADLINE|SCHEDULED):\s*<(\d{4}-\d{2}-\d{2})(?:\s+[^\s\d>]+)?(?:\s+(\d{1,2}:\d{2}))?[^>]*>`)
+	// orgPlanningLine matches the line after a headline that holds its
+	// timestamps.
+	orgPlanningLine = regexp.MustCompile(`^(DEADLINE|SCHEDULED|CLOSED):`)
+	orgProperty     = regexp.MustCompile(`^:([^:\s]+):\s*(.*)$`)
+)
+
+// looksLikeOrg reports whether data has org headlines with planning
+// timestamps.
+func looksLikeOrg(data []byte) bool {
+	return (bytes.HasPrefix(data, []byte("* ")) || bytes.Contains(data, []byte("\n* "))) &&
+		orgPlanning.Match(data)
+}
+
+// orgEntry is a headline and the lines under it up to the next headline.
+type orgEntry struct {
+	line  int
+	title string
+	tags  []string
+	body  []string
+}
+
+// event returns the entry's event, dated by its DEADLINE or else its
+// SCHEDULED timestamp. ok is false for headlines with neither.
+func (o orgEntry) event(loc *time.Location) (event Event, ok bool, err error) {
+	event = Event{Name: o.title, Tags: o.tags}
+	stamps := make(map[string][]string)
+	var notes []string
+	inDrawer := false
+	for _, line := range o.body {
+		trimmed := strings.TrimSpace(line)
+		switch {
+		case trimmed == ":PROPERTIES:" || trimmed == ":LOGBOOK:":
+			inDrawer = true
+		case inDrawer && trimmed == ":END:":
+			inDrawer = false
+		case inDrawer:
+			if m := orgProperty.FindStringSubmatch(trimmed); m != nil && strings.EqualFold(m[1], "ID") {
+				event.ID = m[2]
+			}
+		case orgPlanningLine.MatchString(trimmed):
+			for _, m := range orgPlanning.FindAllStringSubmatch(trimmed, -1) {
+				stamps[m[1]] = m[2:]
+			}
+		default:
+			notes = append(notes, trimmed)
+		}
+	}
+	stamp, ok := stamps["DEADLINE"]
+	if !ok {
+		if stamp, ok = stamps["SCHEDULED"]; !ok {
+			return Event{}, false, nil
+		}
+	}
+	value, layout := stamp[0], "2006-01-02"
+	if stamp[1] != "" {
+		value, layout = value+" "+stamp[1], "2006-01-02 15:04"
+	}
+	t, err := time.ParseInLocation(layout, value, loc)
+	if err != nil {
+		return Event{}, false, err
+	}
+	event.Time = t.Unix()
+	event.Notes = strings.TrimSpace(strings.Join(notes, "\n"))
+	return event, true, nil
+}
+
+// parseOrg reads events from the DEADLINE or SCHEDULED timestamps of an
+// org-mode file's headlines. Headlines without either are skipped, as are
+// TODO keywords and priorities in titles.
+func parseOrg(data []byte, opts importOptions) ([]Event, error) {
+	loc := opts.zone
+	if loc == nil {
+		loc = time.Local
+	}
+	var entries []orgEntry
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	for n := 1; scanner.Scan(); n++ {
+		line := scanner.Text()
+		if m := orgHeadline.FindStringSubmatch(line); m != nil {
+			title := orgPriority.ReplaceAllString(orgKeyword.ReplaceAllString(m[2], ""), "")
+			entry := orgEntry{line: n, title: strings.TrimSpace(title)}
+			if m[3] != "" {
+				entry.tags = strings.Split(strings.Trim(m[3], ":"), ":")
+			}
+			entries = append(entries, entry)
+			continue
+		}
+		if len(entries) > 0 {
+			entries[len(entries)-1].body = append(entries[len(entries)-1].body, line)
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	var events []Event
+	for _, entry := range entries {
+		event, ok, err := entry.event(loc)
+		if err != nil {
+			return nil, fmt.Errorf("line %d: %w", entry.line, err)
+		}
+		if ok {
+			events = append(events, event)
+		}
+	}
+	return events, nil
+}
@@ -0,0 +1,78 @@
+package main
+
+import (
+	"bytes"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestWriteMarkdown(t *testing.T) {
+	events := []Event{{Name: "Freeze | v2", Time: time.Now().Add(12*24*time.Hour + time.Hour).Unix(), Tags: []string{"release", "q4"}}}
+	var buf bytes.Buffer
+	if err := writeMarkdown(&buf, events); err != nil {
+		t.Fatal(err)
+	}
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	if len(lines) != 3 || lines[0] != "| Event | Target | Remaining | Tags |" {
+		t.Fatalf("Unexpected table:\n%s", buf.String())
+	}
+	for _, want := range []string{`Freeze \| v2`, "| 12 days |", "`release` `q4`"} {
+		if !strings.Contains(lines[2], want) {
+			t.Errorf("Expected the row to contain %q, got %q", want, lines[2])
+		}
+	}
+}
+
+func TestParseOrg(t *testing.T) {
+	org := `#+TITLE: Plans
+* Projects
+** TODO [#A] Ship v2 :release:work:
+   DEADLINE: <2026-11-02 Mon 17:30> SCHEDULED: <2026-10-20 Tue>
+   :PROPERTIES:
+   :ID:       ship-v2
+   :END:
+   Branch cut the week before.
+** Book venue
+   SCHEDULED: <2026-12-01 Tue +1y>
+** Someday
+   Just an idea.
+`
+	got, err := parseOrg([]byte(org), importOptions{zone: time.UTC})
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []Event{
+		{ID: "ship-v2", Name: "Ship v2", Time: time.Date(2026, 11, 2, 17, 30, 0, 0, time.UTC).Unix(),
+			Tags: []string{"release", "work"}, Notes: "Branch cut the week before."},
+		{Name: "Book venue", Time: time.Date(2026, 12, 1, 0, 0, 0, 0, time.UTC).Unix()},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("parseOrg() = %+v, want %+v", got, want)
+	}
+	if !looksLikeOrg([]byte(org)) || looksLikeOrg([]byte("name,date\n")) {
+		t.Error("looksLikeOrg() misdetected the format")
+	}
+}
+
+func TestOrgRoundTrip(t *testing.T) {
+	events := []Event{
+		{ID: "a", Name: "Launch", Time: time.Date(2027, 3, 4, 9, 15, 0, 0, time.Local).Unix(), Tags: []string{"release", "q1"}, Notes: "line 1\nline 2"},
+		{ID: "b", Name: "All day", Time: time.Date(2027, 5, 6, 0, 0, 0, 0, time.Local).Unix()},
+	}
+	var buf bytes.Buffer
+	if err := writeOrg(&buf, events); err != nil {
+		t.Fatal(err)
+	}
+	got, err := parseOrg(buf.Bytes(), importOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(got, events) {
+		t.Errorf("Round trip = %+v, want %+v\n%s", got, events, buf.String())
+	}
+	if orgTag("team sync-up") != "team_sync_up" {
+		t.Errorf("orgTag() = %q", orgTag("team sync-up"))
+	}
+}
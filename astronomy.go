@@ -0,0 +1,219 @@
+package main
+
+import (
+	"cmp"
+	"math"
+	"slices"
+	"time"
+)
+
+// The calculations below follow Jean Meeus, Astronomical Algorithms (2nd
+// ed., 1998), chapters 27 (equinoxes and solstices) and 49 (phases of the
+// Moon). Both give times within a minute or two for the years around
+// 2000, which is plenty for a countdown.
+
+const (
+	// unixEpochJD is the Julian Day of 1970-01-01 00:00 UTC.
+	unixEpochJD = 2440587.5
+	// j2000 is the Julian Day of the standard epoch J2000.0.
+	j2000 = 2451545.0
+)
+
+// seasonNames are the equinoxes and solstices in calendar order. They are
+// named by month rather than season, which depends on the hemisphere.
+var seasonNames = [4]string{"March Equinox", "June Solstice", "September Equinox", "December Solstice"}
+
+// moonPhaseNames are the principal phases in the order they follow a new
+// moon.
+var moonPhaseNames = [4]string{"New Moon", "First Quarter Moon", "Full Moon", "Last Quarter Moon"}
+
+// seasonMean holds the coefficients of the mean equinox and solstice
+// polynomials for years 1000-3000 (Meeus table 27.B).
+var seasonMean = [4][5]float64{
+	{2451623.80984, 365242.37404, 0.05169, -0.00411, -0.00057},
+	{2451716.56767, 365241.62603, 0.00325, 0.00888, -0.00030},
+	{2451810.21715, 365242.01767, -0.11575, 0.00337, 0.00078},
+	{2451900.05952, 365242.74049, -0.06223, -0.00823, 0.00032},
+}
+
+// seasonTerms are the periodic terms A, B and C of Meeus table 27.C.
+var seasonTerms = [][3]float64{
+	{485, 324.96, 1934.136}, {203, 337.23, 32964.467}, {199, 342.08, 20.186},
+	{182, 27.85, 445267.112}, {156, 73.14, 45036.886}, {136, 171.52, 22518.443},
+	{77, 222.54, 65928.934}, {74, 296.72, 3034.906}, {70, 243.58, 9037.513},
+	{58, 119.81, 33718.147}, {52, 297.17, 150.678}, {50, 21.02, 2281.226},
+	{45, 247.54, 29929.562}, {44, 325.15, 31555.956}, {29, 60.93, 4443.417},
+	{18, 155.12, 67555.328}, {17, 288.79, 4562.452}, {16, 198.04, 62894.029},
+	{14, 199.76, 31436.921}, {12, 95.39, 14577.848}, {12, 287.11, 31931.756},
+	{12, 320.81, 34777.259}, {9, 227.73, 1222.114}, {8, 15.45, 16859.074},
+}
+
+func sinDeg(d float64) float64 { return math.Sin(d * math.Pi / 180) }
+func cosDeg(d float64) float64 { return math.Cos(d * math.Pi / 180) }
+
+// deltaT estimates the difference between Terrestrial Time, which the
+// formulas use, and UT in seconds (Espenak and Meeus, 2006).
+func deltaT(year float64) float64 {
+	if year >= 2005 && year < 2050 {
+		t := year - 2000
+		return 62.92 + 0.32217*t + 0.005589*t*t
+	}
+	u := (year - 1820) / 100
+	return -20 + 32*u*u
+}
+
+// jdeTime converts a Julian Ephemeris Day to the time in UTC.
+func jdeTime(jde float64) time.Time {
+	seconds := (jde - unixEpochJD) * 86400
+	year := 1970 + seconds/(365.2425*86400)
+	seconds -= deltaT(year)
+	return time.Unix(0, int64(seconds*1e9)).UTC()
+}
+
+// seasonTime returns the moment of an equinox or solstice: season 0 is the
+// March equinox, 1 the June solstice and so on.
+func seasonTime(year, season int) time.Time {
+	y := float64(year-2000) / 1000
+	c := seasonMean[season]
+	jde0 := c[0] + y*(c[1]+y*(c[2]+y*(c[3]+y*c[4])))
+	t := (jde0 - j2000) / 36525
+	w := 35999.373*t - 2.47
+	dl := 1 + 0.0334*cosDeg(w) + 0.0007*cosDeg(2*w)
+	s := 0.0
+	for _, term := range seasonTerms {
+		s += term[0] * cosDeg(term[1]+term[2]*t)
+	}
+	return jdeTime(jde0 + 0.00001*s/dl)
+}
+
+// moonPhaseTime returns the moment of a principal lunar phase. k counts
+// lunations from the new moon of 2000-01-06: whole numbers are new moons
+// and .25, .5 and .75 the first quarter, full moon and last quarter.
+func moonPhaseTime(k float64) time.Time {
+	t := k / 1236.85
+	jde := 2451550.09766 + 29.530588861*k + t*t*(0.00015437+t*(-0.000000150+t*0.00000000073))
+	e := 1 - t*(0.002516+t*0.0000074)
+	m := 2.5534 + 29.10535670*k - t*t*(0.0000014+t*0.00000011)
+	mp := 201.5643 + 385.81693528*k + t*t*(0.0107582+t*(0.00001238-t*0.000000058))
+	f := 160.7108 + 390.67050284*k - t*t*(0.0016118+t*(0.00000227-t*0.000000011))
+	om := 124.7746 - 1.56375588*k + t*t*(0.0020672+t*0.00000215)
+
+	var c float64
+	switch phase := k - math.Floor(k); {
+	case phase < 0.125 || phase > 0.875, phase > 0.375 && phase < 0.625:
+		// New and full moons share their terms, with slightly different
+		// coefficients.
+		full := phase > 0.375 && phase < 0.625
+		pick := func(newMoon, fullMoon float64) float64 {
+			if full {
+				return fullMoon
+			}
+			return newMoon
+		}
+		c = pick(-0.40720, -0.40614)*sinDeg(mp) +
+			pick(0.17241, 0.17302)*e*sinDeg(m) +
+			pick(0.01608, 0.01614)*sinDeg(2*mp) +
+			pick(0.01039, 0.01043)*sinDeg(2*f) +
+			pick(0.00739, 0.00734)*e*sinDeg(mp-m) -
+			pick(0.00514, 0.00515)*e*sinDeg(mp+m) +
+			pick(0.00208, 0.00209)*e*e*sinDeg(2*m) -
+			0.00111*sinDeg(mp-2*f) -
+			0.00057*sinDeg(mp+2*f) +
+			0.00056*e*sinDeg(2*mp+m) -
+			0.00042*sinDeg(3*mp) +
+			0.00042*e*sinDeg(m+2*f) +
+			0.00038*e*sinDeg(m-2*f) -
+			0.00024*e*sinDeg(2*mp-m) -
+			0.00017*sinDeg(om) -
+			0.00007*sinDeg(mp+2*m) +
+			0.00004*sinDeg(2*mp-2*f) +
+			0.00004*sinDeg(3*m) +
+			0.00003*sinDeg(mp+m-2*f) +
+			0.00003*sinDeg(2*mp+2*f) -
+			0.00003*sinDeg(mp+m+2*f) +
+			0.00003*sinDeg(mp-m+2*f) -
+			0.00002*sinDeg(mp-m-2*f) -
+			0.00002*sinDeg(3*mp+m) +
+			0.00002*sinDeg(4*mp)
+	default:
+		c = -0.62801*sinDeg(mp) +
+			0.17172*e*sinDeg(m) -
+			0.01183*e*sinDeg(mp+m) +
+			0.00862*sinDeg(2*mp) +
+			0.00804*sinDeg(2*f) +
+			0.00454*e*sinDeg(mp-m) +
+			0.00204*e*e*sinDeg(2*m) -
+			0.00180*sinDeg(mp-2*f) -
+			0.00070*sinDeg(mp+2*f) -
+			0.00040*sinDeg(3*mp) -
+			0.00034*e*sinDeg(2*mp-m) +
+			0.00032*e*sinDeg(m+2*f) +
+			0.00032*e*sinDeg(m-2*f) -
+			0.00028*e*e*sinDeg(mp+2*m) +
+			0.00027*e*sinDeg(2*mp+m) -
+			0.00017*sinDeg(om) -
+			0.00005*sinDeg(mp-m-2*f) +
+			0.00004*sinDeg(2*mp+2*f) -
+			0.00004*sinDeg(mp+m+2*f) +
+			0.00004*sinDeg(mp-2*m) +
+			0.00003*sinDeg(mp+m-2*f) +
+			0.00003*sinDeg(3*m) +
+			0.00002*sinDeg(2*mp-2*f) +
+			0.00002*sinDeg(mp-m+2*f) -
+			0.00002*sinDeg(3*mp+m)
+		w := 0.00306 - 0.00038*e*cosDeg(m) + 0.00026*cosDeg(mp) -
+			0.00002*cosDeg(mp-m) + 0.00002*cosDeg(mp+m) + 0.00002*cosDeg(2*f)
+		if phase < 0.5 {
+			c += w
+		} else {
+			c -= w
+		}
+	}
+
+	// Planetary arguments shared by all phases.
+	planetary := [][3]float64{
+		{0.000325, 299.77, 0.107408}, {0.000165, 251.88, 0.016321}, {0.000164, 251.83, 26.651886},
+		{0.000126, 349.42, 36.412478}, {0.000110, 84.66, 18.206239}, {0.000062, 141.74, 53.303771},
+		{0.000060, 207.14, 2.453732}, {0.000056, 154.84, 7.306860}, {0.000047, 34.52, 27.261239},
+		{0.000042, 207.19, 0.121824}, {0.000040, 291.34, 1.844379}, {0.000037, 161.72, 24.198154},
+		{0.000035, 239.56, 25.513099}, {0.000023, 331.55, 3.592518},
+	}
+	for i, a := range planetary {
+		arg := a[1] + a[2]*k
+		if i == 0 {
+			arg -= 0.009173 * t * t
+		}
+		c += a[0] * sinDeg(arg)
+	}
+	return jdeTime(jde + c)
+}
+
+// astronomyProvider yields equinoxes, solstices and the principal moon
+// phases.
+type astronomyProvider struct{}
+
+func (astronomyProvider) Events(from, to time.Time) ([]Event, error) {
+	var events []Event
+	add := func(name string, t time.Time) {
+		if !t.Before(from) && t.Before(to) {
+			events = append(events, Event{Name: name, Time: t.Unix(), Tags: []string{"astronomy"}})
+		}
+	}
+	for year := from.Year(); year <= to.Year(); year++ {
+		for season, name := range seasonNames {
+			add(name, seasonTime(year, season))
+		}
+	}
+	// Start a lunation early so no phase is missed.
+	years := float64(from.Unix())/(365.2425*86400) + 1970 - 2000
+	for k := math.Floor(years*12.3685) - 1; ; k++ {
+		if moonPhaseTime(k).After(to) {
+			break
+		}
+		for phase, name := range moonPhaseNames {
+			add(name, moonPhaseTime(k+float64(phase)/4))
+		}
+	}
+	slices.SortFunc(events, func(a, b Event) int { return cmp.Compare(a.Time, b.Time) })
+	return events, nil
+}
@@ -0,0 +1,115 @@
+package main
+
+import (
+	"testing"
+	"time"
+)
+
+// within reports whether got is within tolerance of want.
+func within(got, want time.Time, tolerance time.Duration) bool {
+	d := got.Sub(want)
+	return d >= -tolerance && d <= tolerance
+}
+
+func utc(s string) time.Time {
+	t, err := time.Parse("2006-01-02 15:04", s)
+	if err != nil {
+		panic(err)
+	}
+	return t
+}
+
+// The expected times are from the US Naval Observatory's tables, which
+// round to the minute.
+func TestSeasonTime(t *testing.T) {
+	tests := []struct {
+		year, season int
+		want         string
+	}{
+		{2024, 0, "2024-03-20 03:06"},
+		{2024, 1, "2024-06-20 20:51"},
+		{2024, 2, "2024-09-22 12:44"},
+		{2024, 3, "2024-12-21 09:21"},
+		{2025, 0, "2025-03-20 09:01"},
+		{2025, 1, "2025-06-21 02:42"},
+		{2025, 2, "2025-09-22 18:19"},
+		{2025, 3, "2025-12-21 15:03"},
+		{2026, 0, "2026-03-20 14:46"},
+		{2026, 1, "2026-06-21 08:24"},
+		{2026, 2, "2026-09-23 00:05"},
+		{2026, 3, "2026-12-21 20:50"},
+	}
+	for _, tt := range tests {
+		if got := seasonTime(tt.year, tt.season); !within(got, utc(tt.want), 2*time.Minute) {
+			t.Errorf("%d %s = %v, want %s UTC", tt.year, seasonNames[tt.season], got, tt.want)
+		}
+	}
+}
+
+func TestMoonPhaseTime(t *testing.T) {
+	// Meeus example 49.a: the new moon of February 1977, 03:37:42 TD.
+	if got, want := moonPhaseTime(-283), time.Date(1977, 2, 18, 3, 37, 42, 0, time.UTC); !within(got, want, 2*time.Minute) {
+		t.Errorf("moonPhaseTime(-283) = %v, want about %v", got, want)
+	}
+
+	tests := []struct {
+		phase int
+		want  string
+	}{
+		{3, "2024-04-02 03:15"},
+		{0, "2024-04-08 18:21"},
+		{1, "2024-04-15 19:13"},
+		{2, "2024-04-23 23:49"},
+		{2, "2024-09-18 02:34"},
+		{2, "2025-03-14 06:55"},
+		{0, "2025-03-29 10:58"},
+	}
+	for _, tt := range tests {
+		events, _ := astronomyProvider{}.Events(utc(tt.want).Add(-4*24*time.Hour), utc(tt.want).Add(4*24*time.Hour))
+		var got time.Time
+		for _, e := range events {
+			if e.Name == moonPhaseNames[tt.phase] {
+				got = time.Unix(e.Time, 0)
+			}
+		}
+		if !within(got, utc(tt.want), 2*time.Minute) {
+			t.Errorf("%s = %v, want %s UTC", moonPhaseNames[tt.phase], got.UTC(), tt.want)
+		}
+	}
+}
+
+func TestAstronomyProvider(t *testing.T) {
+	now := utc("2026-10-18 12:00")
+	from, to := now, now.AddDate(1, 0, 0)
+	all, err := astronomyProvider{}.Events(from, to)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// 4 seasons and 12 or 13 lunations.
+	if len(all) < 4+4*12 || len(all) > 4+4*13 {
+		t.Errorf("Expected a year of seasons and moon phases, got %d events", len(all))
+	}
+	for i := 1; i < len(all); i++ {
+		if all[i].Time < all[i-1].Time {
+			t.Fatalf("Events aren't sorted: %v before %v", all[i-1], all[i])
+		}
+	}
+
+	p := builtinProvider{name: "astronomy", horizon: oneYear, EventProvider: astronomyProvider{}}
+	events, err := p.upcoming(now)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(events) != len(seasonNames)+len(moonPhaseNames) {
+		t.Fatalf("Expected one event per season and phase, got %v", events)
+	}
+	for _, e := range events {
+		at := time.Unix(e.Time, 0)
+		if at.Before(from.Truncate(24*time.Hour)) || !at.Before(to) {
+			t.Errorf("%s at %v, want within the next year", e.Name, at)
+		}
+		if e.Name == "Full Moon" && at.After(now.Add(30*24*time.Hour)) {
+			t.Errorf("Full moon at %v, want within a lunation", at)
+		}
+	}
+}
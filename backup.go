@@ -0,0 +1,214 @@
+package main
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+)
+
+const (
+	backupsDirName         = "backups"
+	backupTimeFormat       = "20060102T150405.000000000Z"
+	defaultBackupRetention = 20
+)
+
+// corruptEventsError reports a profile events file that exists but can't
+// be parsed, so main can offer to restore a backup instead of giving up.
+type corruptEventsError struct {
+	Profile string
+	Path    string
+	Err     error
+}
+
+func (e *corruptEventsError) Error() string {
+	return fmt.Sprintf("%s is corrupt: %v", e.Path, e.Err)
+}
+
+func (e *corruptEventsError) Unwrap() error { return e.Err }
+
+// backupRetention returns how many backups to keep per profile. Zero means
+// the default and a negative value disables backups.
+func (c config) backupRetention() int {
+	if c.BackupRetention == 0 {
+		return defaultBackupRetention
+	}
+	return c.BackupRetention
+}
+
+// getBackupDir returns the directory holding a profile's backups.
+func getBackupDir(profile string) (string, error) {
+	if profile == "" {
+		profile = defaultProfile
+	}
+	eventsFile, err := getEventsFilePath()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(filepath.Dir(eventsFile), backupsDirName, profile), nil
+}
+
+// saveProfileEvents backs up a profile's events file, overwrites it with
+// events and commits the result if sync is enabled.
+func saveProfileEvents(profile string, events []Event) error {
+	cfg, err := loadConfig()
+	if err != nil {
+		return err
+	}
+	// Wait for a running sync, which may be rewriting the file.
+	repoMu.Lock()
+	defer repoMu.Unlock()
+	if err := backupEventsFile(profile, cfg.backupRetention()); err != nil {
+		return fmt.Errorf("failed to back up events: %w", err)
+	}
+	eventsFile, err := getProfileFilePath(profile)
+	if err != nil {
+		return fmt.Errorf("failed to get events file path: %w", err)
+	}
+	if err := writeEventsFile(eventsFile, events); err != nil {
+		return err
+	}
+	if err := commitSave(cfg.Sync, profile); err != nil {
+		return fmt.Errorf("failed to commit events: %w", err)
+	}
+	return nil
+}
+
+// backupEventsFile copies a profile's current events file into its backup
+// directory under a timestamped name, then prunes all but the newest keep
+// backups.
+func backupEventsFile(profile string, keep int) error {
+	if keep < 0 {
+		return nil
+	}
+	eventsFile, err := getProfileFilePath(profile)
+	if err != nil {
+		return err
+	}
+	data, err := os.ReadFile(eventsFile)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	dir, err := getBackupDir(profile)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return err
+	}
+	name := time.Now().UTC().Format(backupTimeFormat) + ".json"
+	if err := os.WriteFile(filepath.Join(dir, name), data, 0o644); err != nil {
+		return err
+	}
+	backups, err := listBackups(profile)
+	if err != nil {
+		return err
+	}
+	for _, old := range backups[min(keep, len(backups)):] {
+		if err := os.Remove(old); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// listBackups returns a profile's backup files, newest first.
+func listBackups(profile string) ([]string, error) {
+	dir, err := getBackupDir(profile)
+	if err != nil {
+		return nil, err
+	}
+	entries, err := os.ReadDir(dir)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	var backups []string
+	for _, entry := range entries {
+		if entry.IsDir() {
+			continue
+		}
+		if _, err := backupTime(entry.Name()); err != nil {
+			continue
+		}
+		backups = append(backups, filepath.Join(dir, entry.Name()))
+	}
+	// The timestamp format sorts lexically in time order.
+	sort.Sort(sort.Reverse(sort.StringSlice(backups)))
+	return backups, nil
+}
+
+// backupTime parses the time a backup was taken from its file name.
+func backupTime(name string) (time.Time, error) {
+	return time.Parse(backupTimeFormat, strings.TrimSuffix(filepath.Base(name), ".json"))
+}
+
+// latestValidBackup returns the newest backup of a profile that parses as
+// an events file, or "" if there is none.
+func latestValidBackup(profile string) (string, error) {
+	backups, err := listBackups(profile)
+	if err != nil {
+		return "", err
+	}
+	for _, path := range backups {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			continue
+		}
+		if _, err := decodeEvents(data); err == nil {
+			return path, nil
+		}
+	}
+	return "", nil
+}
+
+// restoreBackup replaces a profile's events file with a backup. The file
+// being replaced is kept alongside it with a .corrupt suffix.
+func restoreBackup(profile, backup string) error {
+	data, err := os.ReadFile(backup)
+	if err != nil {
+		return err
+	}
+	eventsFile, err := getProfileFilePath(profile)
+	if err != nil {
+		return err
+	}
+	if err := os.Rename(eventsFile, eventsFile+".corrupt"); err != nil && !errors.Is(err, os.ErrNotExist) {
+		return err
+	}
+	return os.WriteFile(eventsFile, data, 0o644)
+}
+
+// offerBackupRestore asks whether to replace a corrupt events file with its
+// most recent valid backup and does so if the answer is yes. It reports
+// whether a backup was restored.
+func offerBackupRestore(in io.Reader, out io.Writer, corrupt *corruptEventsError) (bool, error) {
+	backup, err := latestValidBackup(corrupt.Profile)
+	if err != nil || backup == "" {
+		return false, err
+	}
+	taken, err := backupTime(backup)
+	if err != nil {
+		return false, err
+	}
+	fmt.Fprintf(out, "%s: %v\nRestore the backup from %s? [y/N] ",
+		appName, corrupt, taken.Local().Format(inputTimeFormLong))
+	answer, err := bufio.NewReader(in).ReadString('\n')
+	if err != nil && !errors.Is(err, io.EOF) {
+		return false, err
+	}
+	if answer = strings.ToLower(strings.TrimSpace(answer)); answer != "y" && answer != "yes" {
+		return false, nil
+	}
+	return true, restoreBackup(corrupt.Profile, backup)
+}
@@ -0,0 +1,121 @@
+package main
+
+import (
+	"bytes"
+	"errors"
+	"os"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestBackupRetention(t *testing.T) {
+	newTestHelper(t)
+	events := []Event{{Name: "Launch", Time: time.Now().Add(time.Hour).Unix()}}
+
+	// The first save has nothing to back up yet.
+	if err := saveProfileEvents(defaultProfile, events); err != nil {
+		t.Fatalf("saveProfileEvents() failed: %v", err)
+	}
+	if backups, _ := listBackups(defaultProfile); len(backups) != 0 {
+		t.Errorf("Expected no backups before the file existed, got %d", len(backups))
+	}
+
+	for range 5 {
+		if err := backupEventsFile(defaultProfile, 3); err != nil {
+			t.Fatalf("backupEventsFile() failed: %v", err)
+		}
+	}
+	backups, err := listBackups(defaultProfile)
+	if err != nil {
+		t.Fatalf("listBackups() failed: %v", err)
+	}
+	if len(backups) != 3 {
+		t.Fatalf("Expected 3 backups to be kept, got %d", len(backups))
+	}
+	newest, _ := backupTime(backups[0])
+	oldest, _ := backupTime(backups[2])
+	if !newest.After(oldest) {
+		t.Errorf("Expected backups newest first, got %v", backups)
+	}
+
+	if err := backupEventsFile(defaultProfile, -1); err != nil {
+		t.Fatalf("backupEventsFile() with backups disabled failed: %v", err)
+	}
+	if backups, _ = listBackups(defaultProfile); len(backups) != 3 {
+		t.Errorf("Expected disabled backups to leave the count at 3, got %d", len(backups))
+	}
+}
+
+func TestCorruptEventsFile(t *testing.T) {
+	newTestHelper(t)
+	events := []Event{{Name: "Launch", Time: time.Now().Add(time.Hour).Unix()}}
+	if err := saveProfileEvents(defaultProfile, events); err != nil {
+		t.Fatal(err)
+	}
+	if err := saveProfileEvents(defaultProfile, events); err != nil {
+		t.Fatal(err)
+	}
+	eventsFile := mustProfilePath(t, defaultProfile)
+	if err := os.WriteFile(eventsFile, []byte(`[{"name":`), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	_, err := readEventsFile()
+	var corrupt *corruptEventsError
+	if !errors.As(err, &corrupt) {
+		t.Fatalf("Expected a corruptEventsError, got %v", err)
+	}
+	if corrupt.Profile != defaultProfile || corrupt.Path != eventsFile {
+		t.Errorf("Unexpected corrupt error fields: %+v", corrupt)
+	}
+
+	var out bytes.Buffer
+	restored, err := offerBackupRestore(strings.NewReader("n\n"), &out, corrupt)
+	if err != nil || restored {
+		t.Fatalf("Expected declining to leave the file alone, got restored=%v err=%v", restored, err)
+	}
+	if !strings.Contains(out.String(), "Restore the backup") {
+		t.Errorf("Expected a restore prompt, got %q", out.String())
+	}
+
+	restored, err = offerBackupRestore(strings.NewReader("y\n"), &out, corrupt)
+	if err != nil || !restored {
+		t.Fatalf("Expected the backup to be restored, got restored=%v err=%v", restored, err)
+	}
+	got, err := readEventsFile()
+	if err != nil {
+		t.Fatalf("readEventsFile() after restore failed: %v", err)
+	}
+	if len(got) != 1 || got[0].Name != "Launch" {
+		t.Errorf("Expected the backed up events, got %v", got)
+	}
+	if _, err := os.Stat(eventsFile + ".corrupt"); err != nil {
+		t.Errorf("Expected the corrupt file to be kept: %v", err)
+	}
+}
+
+func TestLatestValidBackupSkipsCorruptBackups(t *testing.T) {
+	newTestHelper(t)
+	if err := saveProfileEvents(defaultProfile, []Event{{Name: "Good", Time: 1}}); err != nil {
+		t.Fatal(err)
+	}
+	if err := backupEventsFile(defaultProfile, 10); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(mustProfilePath(t, defaultProfile), []byte(`nope`), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if err := backupEventsFile(defaultProfile, 10); err != nil {
+		t.Fatal(err)
+	}
+
+	backups, _ := listBackups(defaultProfile)
+	latest, err := latestValidBackup(defaultProfile)
+	if err != nil {
+		t.Fatalf("latestValidBackup() failed: %v", err)
+	}
+	if len(backups) != 2 || latest != backups[1] {
+		t.Errorf("Expected the older, valid backup %v, got %q", backups, latest)
+	}
+}
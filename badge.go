@@ -0,0 +1,171 @@
+package main
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+	"time"
+)
+
+const (
+	defaultBadgeWarn   = 7 * 24 * time.Hour
+	defaultBadgeUrgent = 24 * time.Hour
+)
+
+// badgeColors are the shields.io named colors, so the same names work in
+// both places.
+var badgeColors = map[string]string{
+	"brightgreen": "#4c1",
+	"green":       "#97ca00",
+	"yellowgreen": "#a4a61d",
+	"yellow":      "#dfb317",
+	"orange":      "#fe7d37",
+	"red":         "#e05d44",
+	"blue":        "#007ec6",
+	"grey":        "#555",
+	"lightgrey":   "#9f9f9f",
+}
+
+var hexColor = regexp.MustCompile(`^#?([0-9a-fA-F]{3}|[0-9a-fA-F]{6})$`)
+
+// badgeColor resolves a named or hex color.
+func badgeColor(s string) (string, error) {
+	if c, ok := badgeColors[strings.ToLower(s)]; ok {
+		return c, nil
+	}
+	if m := hexColor.FindStringSubmatch(s); m != nil {
+		return "#" + m[1], nil
+	}
+	return "", fmt.Errorf("invalid color %q, use a hex value or one of brightgreen, green, yellow, orange, red, blue, grey", s)
+}
+
+// badgeStyle holds the colors of a badge and the urgency thresholds that
+// pick between them.
+type badgeStyle struct {
+	label, ok, warn, urgent, expired string
+	warnWithin, urgentWithin         time.Duration
+}
+
+// urgency classifies the time left as "ok", "warn", "urgent" or
+// "expired".
+func urgency(left, warnWithin, urgentWithin time.Duration) string {
+	switch {
+	case left < 0:
+		return "expired"
+	case left < urgentWithin:
+		return "urgent"
+	case left < warnWithin:
+		return "warn"
+	default:
+		return "ok"
+	}
+}
+
+// color returns the value color for the time left.
+func (s badgeStyle) color(left time.Duration) string {
+	switch urgency(left, s.warnWithin, s.urgentWithin) {
+	case "expired":
+		return s.expired
+	case "urgent":
+		return s.urgent
+	case "warn":
+		return s.warn
+	default:
+		return s.ok
+	}
+}
+
+// textWidth estimates the width in pixels of text in 11px Verdana, the
+// font shields.io badges use.
+func textWidth(s string) int {
+	width := 0.0
+	for _, r := range s {
+		switch {
+		case strings.ContainsRune("iljI.,:;'|!", r):
+			width += 3.5
+		case strings.ContainsRune("ftr ()[]", r):
+			width += 4.5
+		case strings.ContainsRune("mwMW", r):
+			width += 10.5
+		case r >= 'A' && r <= 'Z':
+			width += 7.5
+		default:
+			width += 6.8
+		}
+	}
+	return int(width + 0.5)
+}
+
+// renderBadge draws a flat shields-style badge with label on the left and
+// value on the right.
+func renderBadge(label, value string, labelColor, valueColor string) string {
+	const padding = 10
+	lw, vw := textWidth(label)+padding, textWidth(value)+padding
+	label, value = xmlEscape(label), xmlEscape(value)
+	var b strings.Builder
+	fmt.Fprintf(&b, `<svg xmlns="http://www.w3.org/2000/svg" width="%d" height="20" role="img" aria-label="%s: %s">`+"\n", lw+vw, label, value)
+	fmt.Fprintf(&b, "<title>%s: %s</title>\n", label, value)
+	b.WriteString(`<linearGradient id="s" x2="0" y2="100%"><stop offset="0" stop-color="#bbb" stop-opacity=".1"/><stop offset="1" stop-opacity=".1"/></linearGradient>` + "\n")
+	fmt.Fprintf(&b, `<clipPath id="r"><rect width="%d" height="20" rx="3" fill="#fff"/></clipPath>`+"\n", lw+vw)
+	fmt.Fprintf(&b, `<g clip-path="url(#r)"><rect width="%d" height="20" fill="%s"/><rect x="%d" width="%d" height="20" fill="%s"/><rect width="%d" height="20" fill="url(#s)"/></g>`+"\n",
+		lw, labelColor, lw, vw, valueColor, lw+vw)
+	b.WriteString(`<g fill="#fff" text-anchor="middle" font-family="Verdana,Geneva,DejaVu Sans,sans-serif" font-size="11">` + "\n")
+	for _, part := range []struct {
+		x    int
+		text string
+	}{{lw / 2, label}, {lw + vw/2, value}} {
+		fmt.Fprintf(&b, `<text x="%d" y="15" fill="#010101" fill-opacity=".3">%s</text><text x="%d" y="14">%s</text>`+"\n",
+			part.x, part.text, part.x, part.text)
+	}
+	b.WriteString("</g>\n</svg>\n")
+	return b.String()
+}
+
+func runBadge(c *cli, args []string) error {
+	fs := c.newFlagSet("badge")
+	label := fs.String("label", "", "left-hand text (default: the event name)")
+	style := badgeStyle{warnWithin: defaultBadgeWarn, urgentWithin: defaultBadgeUrgent}
+	fs.Var((*spanFlag)(&style.warnWithin), "warn", "use the warn color when less than this is left, e.g. `7d`")
+	fs.Var((*spanFlag)(&style.urgentWithin), "urgent", "use the urgent color when less than this is left, e.g. `24h`")
+	colors := []struct {
+		dst       *string
+		name, def string
+		usage     string
+	}{
+		{&style.label, "label-color", "grey", "label background"},
+		{&style.ok, "color", "brightgreen", "value background before the warn window"},
+		{&style.warn, "warn-color", "yellow", "value background inside the warn window"},
+		{&style.urgent, "urgent-color", "orange", "value background inside the urgent window"},
+		{&style.expired, "expired-color", "red", "value background once expired"},
+	}
+	for _, col := range colors {
+		fs.StringVar(col.dst, col.name, col.def, col.usage+", a hex value or shields.io name")
+	}
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if fs.NArg() != 1 {
+		return errUsage
+	}
+	for _, col := range colors {
+		var err error
+		if *col.dst, err = badgeColor(*col.dst); err != nil {
+			return fmt.Errorf("-%s: %w", col.name, err)
+		}
+	}
+
+	events, err := loadProfileEvents(c.profile)
+	if err != nil {
+		return err
+	}
+	event, err := findEvent(events, fs.Arg(0))
+	if err != nil {
+		return err
+	}
+	if *label == "" {
+		*label = event.Name
+	}
+	fields := newStatusFields(event, time.Now())
+	_, err = fmt.Fprint(c.stdout, renderBadge(*label, fields.Short, style.label, style.color(fields.Left)))
+	return err
+}
@@ -0,0 +1,78 @@
+package main
+
+import (
+	"bytes"
+	"encoding/xml"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestBadgeStyleColor(t *testing.T) {
+	style := badgeStyle{ok: "ok", warn: "warn", urgent: "urgent", expired: "expired",
+		warnWithin: defaultBadgeWarn, urgentWithin: defaultBadgeUrgent}
+	tests := []struct {
+		left time.Duration
+		want string
+	}{
+		{30 * 24 * time.Hour, "ok"},
+		{6 * 24 * time.Hour, "warn"},
+		{2 * time.Hour, "urgent"},
+		{-time.Second, "expired"},
+	}
+	for _, tt := range tests {
+		if got := style.color(tt.left); got != tt.want {
+			t.Errorf("color(%v) = %q, want %q", tt.left, got, tt.want)
+		}
+	}
+}
+
+func TestBadgeColor(t *testing.T) {
+	for in, want := range map[string]string{"red": "#e05d44", "Blue": "#007ec6", "abc": "#abc", "#00FF00": "#00FF00"} {
+		if got, err := badgeColor(in); err != nil || got != want {
+			t.Errorf("badgeColor(%q) = %q, %v, want %q", in, got, err, want)
+		}
+	}
+	for _, bad := range []string{"", "#12", "url(#x)", "purple-ish"} {
+		if _, err := badgeColor(bad); err == nil {
+			t.Errorf("Expected badgeColor(%q) to fail", bad)
+		}
+	}
+}
+
+func TestRenderBadge(t *testing.T) {
+	svg := renderBadge("v2.0 <freeze>", "12 days", "#555", "#4c1")
+	if err := xml.Unmarshal([]byte(svg), new(struct{})); err != nil {
+		t.Fatalf("Badge is not well-formed XML: %v\n%s", err, svg)
+	}
+	for _, want := range []string{"v2.0 &lt;freeze&gt;", ">12 days</text>", `fill="#4c1"`, `fill="#555"`} {
+		if !strings.Contains(svg, want) {
+			t.Errorf("Expected the badge to contain %q:\n%s", want, svg)
+		}
+	}
+	if textWidth("WWWW") <= textWidth("iiii") {
+		t.Error("Expected wide letters to measure wider than narrow ones")
+	}
+}
+
+func TestBadgeCommand(t *testing.T) {
+	newTestHelper(t)
+	soon := time.Now().Add(3 * time.Hour).Unix()
+	if err := saveProfileEvents(defaultProfile, []Event{{ID: "f", Name: "Code freeze", Time: soon}}); err != nil {
+		t.Fatal(err)
+	}
+	var out bytes.Buffer
+	c := &cli{profile: defaultProfile, stdout: &out, stderr: &out}
+	if err := c.run([]string{"badge", "--urgent", "1d", "--urgent-color", "#123456", "code"}); err != nil {
+		t.Fatalf("badge failed: %v", err)
+	}
+	if !strings.Contains(out.String(), "Code freeze") || !strings.Contains(out.String(), `fill="#123456"`) {
+		t.Errorf("Expected an urgent badge for the event, got:\n%s", out.String())
+	}
+	if err := c.run([]string{"badge", "launch"}); err == nil {
+		t.Error("Expected an unknown event to fail")
+	}
+	if err := c.run([]string{"badge", "--color", "nope", "code"}); err == nil {
+		t.Error("Expected an invalid color to fail")
+	}
+}
@@ -0,0 +1,240 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+	"text/template"
+	"time"
+)
+
+const (
+	defaultBarFormat = "{{.Name}} {{.Long}}"
+	// barTooltipEvents is how many upcoming events the tooltip lists.
+	barTooltipEvents = 5
+)
+
+// barUpdate is what a status bar shows at one moment. Class is the
+// urgency of the event, "none" without one or "error" when the events
+// couldn't be read.
+type barUpdate struct {
+	Text, Tooltip, Class string
+	// Instance identifies the event to i3bar.
+	Instance string
+}
+
+// barProtocol writes updates in the format a status bar reads.
+type barProtocol struct {
+	name           string
+	header, footer string
+	write          func(w io.Writer, u barUpdate, first bool) error
+}
+
+// i3barColors are the text colors of each urgency in i3bar, which has no
+// classes to style.
+var i3barColors = map[string]string{
+	"warn":    badgeColors["yellow"],
+	"urgent":  badgeColors["orange"],
+	"expired": badgeColors["red"],
+	"error":   badgeColors["red"],
+}
+
+var barProtocols = []barProtocol{
+	{
+		// https://i3wm.org/docs/i3bar-protocol.html: a header, then an
+		// endless JSON array of status lines.
+		name:   "i3bar",
+		header: `{"version":1}` + "\n[\n",
+		footer: "]\n",
+		write: func(w io.Writer, u barUpdate, first bool) error {
+			block := struct {
+				Name     string `json:"name"`
+				Instance string `json:"instance,omitempty"`
+				FullText string `json:"full_text"`
+				Color    string `json:"color,omitempty"`
+				Urgent   bool   `json:"urgent,omitempty"`
+			}{"countdown", u.Instance, u.Text, i3barColors[u.Class], u.Class == "urgent" || u.Class == "expired"}
+			data, err := json.Marshal([]any{block})
+			if err != nil {
+				return err
+			}
+			if !first {
+				data = append([]byte{','}, data...)
+			}
+			_, err = fmt.Fprintf(w, "%s\n", data)
+			return err
+		},
+	},
+	{
+		// Waybar's custom module with "return-type": "json". Text and
+		// tooltip are Pango markup.
+		name: "waybar",
+		write: func(w io.Writer, u barUpdate, _ bool) error {
+			lines := strings.Split(u.Tooltip, "\n")
+			for i, line := range lines {
+				lines[i] = xmlEscape(line)
+			}
+			data, err := json.Marshal(map[string]string{
+				"text":    xmlEscape(u.Text),
+				"tooltip": strings.Join(lines, "\n"),
+				"class":   u.Class,
+				"alt":     u.Class,
+			})
+			if err != nil {
+				return err
+			}
+			_, err = fmt.Fprintf(w, "%s\n", data)
+			return err
+		},
+	},
+	{
+		// One line per update, for polybar's tail mode and the like.
+		name: "plain",
+		write: func(w io.Writer, u barUpdate, _ bool) error {
+			_, err := fmt.Fprintln(w, u.Text)
+			return err
+		},
+	},
+}
+
+func findBarProtocol(name string) (barProtocol, bool) {
+	for _, p := range barProtocols {
+		if p.name == name {
+			return p, true
+		}
+	}
+	return barProtocol{}, false
+}
+
+// barSource keeps a profile's events for a bar, rereading them only when
+// one of their files changes or a new day starts.
+type barSource struct {
+	profile string
+	events  []Event
+	inputs  map[string]int64
+	day     time.Time
+}
+
+func (s *barSource) load(now time.Time) error {
+	today := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, now.Location())
+	if s.inputs != nil && s.day.Equal(today) && unchanged(s.inputs) {
+		return nil
+	}
+	cfg, err := loadConfig()
+	if err != nil {
+		return err
+	}
+	inputs, err := snapshotInputs(cfg, s.profile)
+	if err != nil {
+		return err
+	}
+	events, err := loadProfileEvents(s.profile)
+	if err != nil {
+		return err
+	}
+	s.events, s.inputs, s.day = events, inputs, today
+	return nil
+}
+
+// barView turns events into bar updates.
+type barView struct {
+	// query picks an event with findEvent. Empty means the next one.
+	query                    string
+	format                   *template.Template
+	warnWithin, urgentWithin time.Duration
+}
+
+func (v barView) update(events []Event, now time.Time) (barUpdate, error) {
+	var tooltip []string
+	for _, e := range events {
+		if (e.Time >= now.Unix() || e.onItsDay(now)) && len(tooltip) < barTooltipEvents {
+			tooltip = append(tooltip, fmt.Sprintf("%s: %s", e.Name, newStatusFields(e, now).Long))
+		}
+	}
+	var event Event
+	if v.query != "" {
+		var err error
+		if event, err = findEvent(events, v.query); err != nil {
+			return barUpdate{}, err
+		}
+	} else if next, ok := nextEvent(events, now); ok {
+		event = next
+	} else {
+		return barUpdate{Class: "none"}, nil
+	}
+	fields := newStatusFields(event, now)
+	var b strings.Builder
+	if err := v.format.Execute(&b, fields); err != nil {
+		return barUpdate{}, err
+	}
+	instance := event.ID
+	if instance == "" {
+		instance = event.Name
+	}
+	return barUpdate{
+		Text:     strings.ReplaceAll(strings.TrimRight(b.String(), "\n"), "\n", " "),
+		Tooltip:  strings.Join(tooltip, "\n"),
+		Class:    urgency(fields.Left, v.warnWithin, v.urgentWithin),
+		Instance: instance,
+	}, nil
+}
+
+// runBar streams the countdown to a status bar until the bar closes its
+// end of the pipe.
+func runBar(c *cli, args []string) error {
+	fs := c.newFlagSet("bar")
+	protocolName := fs.String("protocol", "waybar", "output format: i3bar, waybar or plain")
+	format := fs.String("format", defaultBarFormat, "Go template for the text, with the fields of status --format")
+	view := barView{warnWithin: defaultBadgeWarn, urgentWithin: defaultBadgeUrgent}
+	fs.Var((*spanFlag)(&view.warnWithin), "warn", "use the warn class when less than this is left, e.g. `7d`")
+	fs.Var((*spanFlag)(&view.urgentWithin), "urgent", "use the urgent class when less than this is left, e.g. `24h`")
+	interval := time.Second
+	fs.Var((*spanFlag)(&interval), "interval", "time between updates")
+	once := fs.Bool("once", false, "print a single update and exit")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if fs.NArg() > 1 {
+		return errUsage
+	}
+	view.query = fs.Arg(0)
+	protocol, ok := findBarProtocol(*protocolName)
+	if !ok {
+		return fmt.Errorf("%w: unknown protocol %q", errUsage, *protocolName)
+	}
+	if interval <= 0 {
+		return fmt.Errorf("%w: -interval must be positive", errUsage)
+	}
+	var err error
+	if view.format, err = template.New("bar").Parse(*format); err != nil {
+		return fmt.Errorf("-format: %w", err)
+	}
+
+	if _, err := io.WriteString(c.stdout, protocol.header); err != nil {
+		return err
+	}
+	src := &barSource{profile: c.profile}
+	for first := true; ; first = false {
+		now := time.Now()
+		// Errors are shown in the bar, which keeps running: the events
+		// file may just be in the middle of being saved.
+		err := src.load(now)
+		var u barUpdate
+		if err == nil {
+			u, err = view.update(src.events, now)
+		}
+		if err != nil {
+			u = barUpdate{Text: appName + ": " + err.Error(), Class: "error"}
+		}
+		if err := protocol.write(c.stdout, u, first); err != nil {
+			return err
+		}
+		if *once {
+			_, err := io.WriteString(c.stdout, protocol.footer)
+			return err
+		}
+		// Tick on the second so the bar counts down evenly.
+		time.Sleep(time.Until(now.Truncate(interval).Add(interval)))
+	}
+}
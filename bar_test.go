@@ -0,0 +1,96 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"strings"
+	"testing"
+	"text/template"
+	"time"
+)
+
+func TestBarViewUpdate(t *testing.T) {
+	now := time.Date(2026, 10, 18, 12, 0, 0, 0, time.UTC)
+	events := []Event{
+		{Name: "Retro", Time: now.Add(-time.Hour).Unix()},
+		{ID: "f", Name: "Code freeze", Time: now.Add(2 * time.Hour).Unix()},
+		{Name: "Launch", Time: now.Add(10 * 24 * time.Hour).Unix()},
+	}
+	view := barView{format: template.Must(template.New("").Parse(defaultBarFormat)),
+		warnWithin: defaultBadgeWarn, urgentWithin: defaultBadgeUrgent}
+
+	u, err := view.update(events, now)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := barUpdate{Text: "Code freeze 2h 0m 0s", Tooltip: "Code freeze: 2h 0m 0s\nLaunch: 10d 0h 0m 0s", Class: "urgent", Instance: "f"}
+	if u != want {
+		t.Errorf("Expected %+v, got %+v", want, u)
+	}
+
+	for query, class := range map[string]string{"launch": "ok", "retro": "expired"} {
+		view.query = query
+		if u, err := view.update(events, now); err != nil || u.Class != class {
+			t.Errorf("Expected class %q for %q, got %+v (err %v)", class, query, u, err)
+		}
+	}
+	view.query = ""
+	if u, _ := view.update(events[:1], now); u.Class != "none" || u.Text != "" {
+		t.Errorf("Expected an empty bar without upcoming events, got %+v", u)
+	}
+}
+
+func TestBarCommand(t *testing.T) {
+	newTestHelper(t)
+	soon := time.Now().Add(3 * 24 * time.Hour).Unix()
+	if err := saveProfileEvents(defaultProfile, []Event{{Name: "Demo <v2>", Time: soon}}); err != nil {
+		t.Fatal(err)
+	}
+	run := func(args ...string) string {
+		t.Helper()
+		var out bytes.Buffer
+		c := &cli{profile: defaultProfile, stdout: &out, stderr: &out}
+		if err := c.run(append([]string{"bar", "--once"}, args...)); err != nil {
+			t.Fatalf("bar %v failed: %v", args, err)
+		}
+		return out.String()
+	}
+
+	var waybar map[string]string
+	if err := json.Unmarshal([]byte(run()), &waybar); err != nil {
+		t.Fatalf("Expected a JSON object for Waybar: %v", err)
+	}
+	if waybar["class"] != "warn" || !strings.HasPrefix(waybar["text"], "Demo &lt;v2&gt; 2d 23h") {
+		t.Errorf("Expected an escaped warn update, got %v", waybar)
+	}
+
+	scanner := bufio.NewScanner(strings.NewReader(run("--protocol", "i3bar", "--format", "{{.Short}}")))
+	var lines []string
+	for scanner.Scan() {
+		lines = append(lines, scanner.Text())
+	}
+	if len(lines) != 4 || lines[0] != `{"version":1}` || lines[1] != "[" || lines[3] != "]" {
+		t.Fatalf("Expected an i3bar header, one status line and the end of the array, got %q", lines)
+	}
+	var blocks []map[string]any
+	if err := json.Unmarshal([]byte(lines[2]), &blocks); err != nil || len(blocks) != 1 {
+		t.Fatalf("Expected one block, got %s (err %v)", lines[2], err)
+	}
+	if blocks[0]["full_text"] != "2 days" || blocks[0]["color"] != badgeColors["yellow"] {
+		t.Errorf("Expected a yellow block, got %v", blocks[0])
+	}
+
+	if got := run("--protocol", "plain", "--format", "{{.Name}}"); got != "Demo <v2>\n" {
+		t.Errorf("Expected a plain line, got %q", got)
+	}
+	if got := run("--protocol", "plain", "launch"); !strings.HasPrefix(got, appName+`: no event "launch"`) {
+		t.Errorf("Expected the error in the bar, got %q", got)
+	}
+
+	var out bytes.Buffer
+	c := &cli{profile: defaultProfile, stdout: &out, stderr: &out}
+	if err := c.run([]string{"bar", "--protocol", "lemonbar"}); err == nil {
+		t.Error("Expected an unknown protocol to fail")
+	}
+}
@@ -0,0 +1,470 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+	"path"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"time"
+)
+
+const (
+	caldavStateFileName   = "caldav-state.json"
+	defaultCalDAVPassword = "COUNTDOWN_CALDAV_PASSWORD"
+)
+
+// caldavConfig points a profile at a CalDAV calendar collection that is
+// kept in step with it in both directions.
+type caldavConfig struct {
+	// URL is the calendar collection, e.g.
+	// https://dav.example.com/calendars/me/countdown/.
+	URL      string `json:"url"`
+	Username string `json:"username,omitempty"`
+	// PasswordEnv names the environment variable holding the password.
+	PasswordEnv string `json:"passwordEnv,omitempty"`
+	Profile     string `json:"profile,omitempty"`
+}
+
+func (c caldavConfig) enabled() bool { return c.URL != "" }
+
+func (c caldavConfig) profile() string {
+	if c.Profile == "" {
+		return defaultProfile
+	}
+	return c.Profile
+}
+
+// caldavItem is an event as it was when both sides last agreed on it.
+type caldavItem struct {
+	Href  string `json:"href"`
+	ETag  string `json:"etag"`
+	Event Event  `json:"event"`
+}
+
+// caldavState is what the last sync saw, kept next to events.json: the
+// server's sync token and every event by UID. It holds copies of events,
+// so it is sealed with secrets like events.json.
+type caldavState struct {
+	URL       string                `json:"url"`
+	Profile   string                `json:"profile"`
+	SyncToken string                `json:"syncToken,omitempty"`
+	Items     map[string]caldavItem `json:"items"`
+}
+
+func getCalDAVStatePath() (string, error) {
+	eventsFile, err := getEventsFilePath()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(filepath.Dir(eventsFile), caldavStateFileName), nil
+}
+
+// readCalDAVState returns the state of the last sync, or an empty state if
+// there was none or it was for another calendar or profile.
+func readCalDAVState(cfg caldavConfig) (caldavState, error) {
+	empty := caldavState{URL: cfg.URL, Profile: cfg.profile(), Items: map[string]caldavItem{}}
+	path, err := getCalDAVStatePath()
+	if err != nil {
+		return empty, err
+	}
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return empty, nil
+	}
+	if err != nil {
+		return empty, err
+	}
+	if data, err = secrets.open(data); err != nil {
+		return empty, err
+	}
+	var state caldavState
+	if err := json.Unmarshal(data, &state); err != nil || state.URL != cfg.URL || state.Profile != cfg.profile() {
+		return empty, nil
+	}
+	if state.Items == nil {
+		state.Items = map[string]caldavItem{}
+	}
+	return state, nil
+}
+
+func writeCalDAVState(state caldavState) error {
+	path, err := getCalDAVStatePath()
+	if err != nil {
+		return err
+	}
+	data, err := json.MarshalIndent(state, "", "  ")
+	if err != nil {
+		return err
+	}
+	if data, err = secrets.seal(data); err != nil {
+		return err
+	}
+	return os.WriteFile(path, data, 0o600)
+}
+
+// caldavClient speaks the small part of CalDAV (RFC 4791) and WebDAV sync
+// (RFC 6578) needed to mirror one calendar collection.
+type caldavClient struct {
+	base     *url.URL
+	username string
+	password string
+	http     *http.Client
+}
+
+func newCalDAVClient(cfg caldavConfig) (*caldavClient, error) {
+	base, err := url.Parse(cfg.URL)
+	if err != nil {
+		return nil, err
+	}
+	if !strings.HasSuffix(base.Path, "/") {
+		base.Path += "/"
+	}
+	env := cfg.PasswordEnv
+	if env == "" {
+		env = defaultCalDAVPassword
+	}
+	return &caldavClient{base: base, username: cfg.Username, password: os.Getenv(env), http: feedClient}, nil
+}
+
+// errPreconditionFailed means a resource changed on the server since it was
+// last read; the next sync will pick the change up.
+var errPreconditionFailed = errors.New("calendar changed on the server during sync, sync again")
+
+func (c *caldavClient) do(method, href string, body []byte, header map[string]string) (*http.Response, error) {
+	u, err := c.base.Parse(href)
+	if err != nil {
+		return nil, err
+	}
+	req, err := http.NewRequest(method, u.String(), bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	if c.username != "" {
+		req.SetBasicAuth(c.username, c.password)
+	}
+	for k, v := range header {
+		req.Header.Set(k, v)
+	}
+	resp, err := c.http.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode == http.StatusPreconditionFailed {
+		resp.Body.Close()
+		return nil, errPreconditionFailed
+	}
+	if resp.StatusCode >= 300 {
+		resp.Body.Close()
+		return nil, fmt.Errorf("%s %s: %s", method, u.Path, resp.Status)
+	}
+	return resp, nil
+}
+
+type davMultistatus struct {
+	Responses []davResponse `xml:"DAV: response"`
+	SyncToken string        `xml:"DAV: sync-token"`
+}
+
+type davResponse struct {
+	Href      string        `xml:"DAV: href"`
+	Status    string        `xml:"DAV: status"`
+	Propstats []davPropstat `xml:"DAV: propstat"`
+}
+
+type davPropstat struct {
+	Status string `xml:"DAV: status"`
+	ETag   string `xml:"DAV: prop>getetag"`
+}
+
+// etag returns the ETag reported in a successful propstat.
+func (r davResponse) etag() string {
+	for _, ps := range r.Propstats {
+		if strings.Contains(ps.Status, " 200 ") && ps.ETag != "" {
+			return ps.ETag
+		}
+	}
+	return ""
+}
+
+func (r davResponse) deleted() bool { return strings.Contains(r.Status, " 404 ") }
+
+func (c *caldavClient) multistatus(method string, depth string, body string) (davMultistatus, error) {
+	var ms davMultistatus
+	resp, err := c.do(method, "", []byte(body), map[string]string{
+		"Content-Type": `application/xml; charset="utf-8"`,
+		"Depth":        depth,
+	})
+	if err != nil {
+		return ms, err
+	}
+	defer resp.Body.Close()
+	err = xml.NewDecoder(resp.Body).Decode(&ms)
+	return ms, err
+}
+
+// remoteChanges lists what changed on the server since token: the ETag of
+// every resource added or modified and the hrefs of those deleted. When the
+// server can't do an incremental sync it falls back to a full listing,
+// reported as deletions of every known href missing from it.
+func (c *caldavClient) remoteChanges(token string, known map[string]string) (changed map[string]string, deleted []string, newToken string, err error) {
+	changed = map[string]string{}
+	ms, err := c.multistatus("REPORT", "1", `<?xml version="1.0" encoding="utf-8"?>
+<d:sync-collection xmlns:d="DAV:">
+  <d:sync-token>`+xmlEscape(token)+`</d:sync-token>
+  <d:sync-level>1</d:sync-level>
+  <d:prop><d:getetag/></d:prop>
+</d:sync-collection>`)
+	if err == nil {
+		for _, r := range ms.Responses {
+			if r.deleted() {
+				deleted = append(deleted, r.Href)
+			} else if etag := r.etag(); etag != "" && etag != known[r.Href] {
+				changed[r.Href] = etag
+			}
+		}
+		return changed, deleted, ms.SyncToken, nil
+	}
+	if errors.Is(err, errPreconditionFailed) {
+		return nil, nil, "", err
+	}
+
+	ms, err = c.multistatus("PROPFIND", "1", `<?xml version="1.0" encoding="utf-8"?>
+<d:propfind xmlns:d="DAV:"><d:prop><d:getetag/></d:prop></d:propfind>`)
+	if err != nil {
+		return nil, nil, "", err
+	}
+	seen := map[string]bool{}
+	for _, r := range ms.Responses {
+		etag := r.etag()
+		if path.Clean(r.Href) == path.Clean(c.base.Path) || etag == "" {
+			continue
+		}
+		seen[r.Href] = true
+		if etag != known[r.Href] {
+			changed[r.Href] = etag
+		}
+	}
+	for href := range known {
+		if !seen[href] {
+			deleted = append(deleted, href)
+		}
+	}
+	return changed, deleted, "", nil
+}
+
+// get fetches a calendar resource and returns its event and ETag.
+func (c *caldavClient) get(href string) (Event, string, error) {
+	resp, err := c.do(http.MethodGet, href, nil, nil)
+	if err != nil {
+		return Event{}, "", err
+	}
+	defer resp.Body.Close()
+	data, err := readFeedBody(resp.Body)
+	if err != nil {
+		return Event{}, "", err
+	}
+	events, err := parseICS(data)
+	if err != nil {
+		return Event{}, "", fmt.Errorf("%s: %w", href, err)
+	}
+	// Recurring events may carry overridden instances after the master;
+	// only the first VEVENT is used.
+	if len(events) == 0 {
+		return Event{}, "", fmt.Errorf("%s: no event", href)
+	}
+	return events[0], resp.Header.Get("ETag"), nil
+}
+
+// put stores an event. With create set it fails if the resource already
+// exists; otherwise an update with an etag only succeeds if the resource is
+// unchanged. It returns the new ETag, which servers may omit.
+func (c *caldavClient) put(href string, event Event, etag string, create bool) (string, error) {
+	header := map[string]string{"Content-Type": "text/calendar; charset=utf-8"}
+	if create {
+		header["If-None-Match"] = "*"
+	} else if etag != "" {
+		header["If-Match"] = etag
+	}
+	resp, err := c.do(http.MethodPut, href, formatICS([]Event{event}), header)
+	if err != nil {
+		return "", err
+	}
+	resp.Body.Close()
+	return resp.Header.Get("ETag"), nil
+}
+
+func (c *caldavClient) delete(href, etag string) error {
+	header := map[string]string{}
+	if etag != "" {
+		header["If-Match"] = etag
+	}
+	resp, err := c.do(http.MethodDelete, href, nil, header)
+	if err != nil {
+		return err
+	}
+	return resp.Body.Close()
+}
+
+// hrefFor returns the href a new event is stored under.
+func (c *caldavClient) hrefFor(uid string) string {
+	return c.base.Path + url.PathEscape(uid) + ".ics"
+}
+
+func xmlEscape(s string) string {
+	var b strings.Builder
+	_ = xml.EscapeText(&b, []byte(s))
+	return b.String()
+}
+
+// syncCalDAV mirrors the configured profile and calendar into each other.
+// Both sides are compared with the state of the last sync and merged by
+// UID with the same rules as git sync: a change beats no change, an edit
+// beats a removal, and if both sides edited an event the local edit wins.
+func syncCalDAV(cfg caldavConfig) error {
+	client, err := newCalDAVClient(cfg)
+	if err != nil {
+		return err
+	}
+	state, err := readCalDAVState(cfg)
+	if err != nil {
+		return err
+	}
+	local, err := readProfileEvents(cfg.profile())
+	if err != nil {
+		return err
+	}
+	// Give legacy events their derived ID so they round-trip as UIDs.
+	for i := range local {
+		local[i].ID = local[i].key()
+	}
+
+	known := make(map[string]string, len(state.Items))
+	uidByHref := make(map[string]string, len(state.Items))
+	var base []Event
+	for uid, item := range state.Items {
+		known[item.Href] = item.ETag
+		uidByHref[item.Href] = uid
+		base = append(base, item.Event)
+	}
+	changed, deleted, token, err := client.remoteChanges(state.SyncToken, known)
+	if err != nil {
+		return err
+	}
+
+	// Build the server's view from the last state plus its changes.
+	remote := make(map[string]caldavItem, len(state.Items))
+	for uid, item := range state.Items {
+		remote[uid] = item
+	}
+	for _, href := range deleted {
+		delete(remote, uidByHref[href])
+	}
+	for href, etag := range changed {
+		event, getETag, err := client.get(href)
+		if err != nil {
+			return err
+		}
+		if getETag != "" {
+			etag = getETag
+		}
+		if uid, ok := uidByHref[href]; ok && uid != event.key() {
+			delete(remote, uid)
+		}
+		remote[event.key()] = caldavItem{Href: href, ETag: etag, Event: event}
+	}
+	var theirs []Event
+	for _, item := range remote {
+		theirs = append(theirs, item.Event)
+	}
+
+	merged := mergeEvents(base, local, theirs)
+	next := caldavState{URL: cfg.URL, Profile: cfg.profile(), SyncToken: token, Items: map[string]caldavItem{}}
+	inMerged := map[string]bool{}
+	for _, event := range merged {
+		uid := event.key()
+		inMerged[uid] = true
+		item, onServer := remote[uid]
+		if !onServer {
+			item.Href = client.hrefFor(uid)
+		}
+		if !onServer || !reflect.DeepEqual(item.Event, event) {
+			etag, err := client.put(item.Href, event, item.ETag, !onServer)
+			if err != nil {
+				return err
+			}
+			item.ETag = etag
+		}
+		item.Event = event
+		next.Items[uid] = item
+	}
+	for uid, item := range remote {
+		if !inMerged[uid] {
+			if err := client.delete(item.Href, item.ETag); err != nil {
+				return err
+			}
+		}
+	}
+
+	if !reflect.DeepEqual(merged, local) {
+		if err := saveProfileEvents(cfg.profile(), merged); err != nil {
+			return err
+		}
+	}
+	return writeCalDAVState(next)
+}
+
+// formatICS renders events as an iCalendar document with one VEVENT each.
+func formatICS(events []Event) []byte {
+	var b bytes.Buffer
+	writeICSLine(&b, "BEGIN:VCALENDAR")
+	writeICSLine(&b, "VERSION:2.0")
+	writeICSLine(&b, "PRODID:-//aldernero//"+appName+"//EN")
+	stamp := time.Now().UTC().Format(icsDateTimeUTC)
+	for _, e := range events {
+		writeICSLine(&b, "BEGIN:VEVENT")
+		writeICSLine(&b, "UID:"+e.key())
+		writeICSLine(&b, "DTSTAMP:"+stamp)
+		writeICSLine(&b, "DTSTART:"+time.Unix(e.Time, 0).UTC().Format(icsDateTimeUTC))
+		writeICSLine(&b, "SUMMARY:"+icsTextEscaper.Replace(e.Name))
+		if len(e.Tags) > 0 {
+			tags := make([]string, len(e.Tags))
+			for i, tag := range e.Tags {
+				tags[i] = icsTextEscaper.Replace(tag)
+			}
+			writeICSLine(&b, "CATEGORIES:"+strings.Join(tags, ","))
+		}
+		if e.Notes != "" {
+			writeICSLine(&b, "DESCRIPTION:"+icsTextEscaper.Replace(e.Notes))
+		}
+		writeICSLine(&b, "END:VEVENT")
+	}
+	writeICSLine(&b, "END:VCALENDAR")
+	return b.Bytes()
+}
+
+var icsTextEscaper = strings.NewReplacer(`\`, `\\`, ";", `\;`, ",", `\,`, "\n", `\n`)
+
+// writeICSLine writes a content line folded at 75 octets without splitting
+// UTF-8 sequences (RFC 5545 section 3.1).
+func writeICSLine(b *bytes.Buffer, line string) {
+	const limit = 75
+	width := limit
+	for len(line) > width {
+		cut := width
+		for cut > 0 && line[cut]&0xC0 == 0x80 {
+			cut--
+		}
+		b.WriteString(line[:cut] + "\r\n ")
+		line = line[cut:]
+		width = limit - 1
+	}
+	b.WriteString(line + "\r\n")
+}
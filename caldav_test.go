@@ -0,0 +1,354 @@
+package main
+
+import (
+	"bytes"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"io/fs"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+)
+
+// davServer is an in-process stand-in for a CalDAV calendar collection at
+// /cal/. It supports sync-collection REPORTs unless noSync is set, in which
+// case clients must fall back to PROPFIND.
+type davServer struct {
+	*httptest.Server
+	mu        sync.Mutex
+	noSync    bool
+	version   int
+	resources map[string]davResource
+	// changes records the version at which each href last changed.
+	changes map[string]int
+	gets    int
+}
+
+type davResource struct {
+	body string
+	etag string
+}
+
+func newDAVServer(t *testing.T) *davServer {
+	t.Helper()
+	s := &davServer{resources: map[string]davResource{}, changes: map[string]int{}}
+	s.Server = httptest.NewServer(http.HandlerFunc(s.serve))
+	t.Cleanup(s.Close)
+	return s
+}
+
+func (s *davServer) url() string { return s.URL + "/cal/" }
+
+// set stores a resource as another client would.
+func (s *davServer) set(href, body string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.version++
+	s.resources[href] = davResource{body: body, etag: `"` + strconv.Itoa(s.version) + `"`}
+	s.changes[href] = s.version
+}
+
+func (s *davServer) remove(href string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.version++
+	delete(s.resources, href)
+	s.changes[href] = s.version
+}
+
+// events returns the events stored on the server, sorted by time.
+func (s *davServer) events(t *testing.T) []Event {
+	t.Helper()
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	var events []Event
+	for _, r := range s.resources {
+		parsed, err := parseICS([]byte(r.body))
+		if err != nil {
+			t.Fatal(err)
+		}
+		events = append(events, parsed...)
+	}
+	slices.SortFunc(events, func(a, b Event) int { return int(a.Time - b.Time) })
+	return events
+}
+
+func (s *davServer) serve(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	href := r.URL.Path
+	current, exists := s.resources[href]
+	switch r.Method {
+	case "REPORT":
+		if s.noSync {
+			w.WriteHeader(http.StatusNotImplemented)
+			return
+		}
+		var req struct {
+			Token string `xml:"DAV: sync-token"`
+		}
+		body, _ := io.ReadAll(r.Body)
+		if err := xml.Unmarshal(body, &req); err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		since, _ := strconv.Atoi(req.Token)
+		var responses strings.Builder
+		for h, v := range s.changes {
+			if v <= since {
+				continue
+			}
+			if res, ok := s.resources[h]; ok {
+				responses.WriteString(davPropResponse(h, res.etag))
+			} else {
+				fmt.Fprintf(&responses, "<d:response><d:href>%s</d:href><d:status>HTTP/1.1 404 Not Found</d:status></d:response>", h)
+			}
+		}
+		writeMultistatus(w, responses.String()+"<d:sync-token>"+strconv.Itoa(s.version)+"</d:sync-token>")
+	case "PROPFIND":
+		responses := davPropResponse("/cal/", `"collection"`)
+		for h, res := range s.resources {
+			responses += davPropResponse(h, res.etag)
+		}
+		writeMultistatus(w, responses)
+	case http.MethodGet:
+		if !exists {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		s.gets++
+		w.Header().Set("ETag", current.etag)
+		_, _ = io.WriteString(w, current.body)
+	case http.MethodPut:
+		if r.Header.Get("If-None-Match") == "*" && exists ||
+			r.Header.Get("If-Match") != "" && r.Header.Get("If-Match") != current.etag {
+			w.WriteHeader(http.StatusPreconditionFailed)
+			return
+		}
+		body, _ := io.ReadAll(r.Body)
+		s.version++
+		etag := `"` + strconv.Itoa(s.version) + `"`
+		s.resources[href] = davResource{body: string(body), etag: etag}
+		s.changes[href] = s.version
+		w.Header().Set("ETag", etag)
+		w.WriteHeader(http.StatusCreated)
+	case http.MethodDelete:
+		if r.Header.Get("If-Match") != "" && r.Header.Get("If-Match") != current.etag {
+			w.WriteHeader(http.StatusPreconditionFailed)
+			return
+		}
+		s.version++
+		delete(s.resources, href)
+		s.changes[href] = s.version
+		w.WriteHeader(http.StatusNoContent)
+	default:
+		w.WriteHeader(http.StatusMethodNotAllowed)
+	}
+}
+
+func davPropResponse(href, etag string) string {
+	return "<d:response><d:href>" + href + "</d:href><d:propstat><d:prop><d:getetag>" +
+		xmlEscape(etag) + "</d:getetag></d:prop><d:status>HTTP/1.1 200 OK</d:status></d:propstat></d:response>"
+}
+
+func writeMultistatus(w http.ResponseWriter, inner string) {
+	w.Header().Set("Content-Type", "application/xml")
+	w.WriteHeader(http.StatusMultiStatus)
+	_, _ = io.WriteString(w, `<?xml version="1.0"?><d:multistatus xmlns:d="DAV:">`+inner+`</d:multistatus>`)
+}
+
+// setCalDAV configures sync of the default profile with srv.
+func setCalDAV(t *testing.T, srv *davServer) {
+	t.Helper()
+	configFile, err := getConfigFilePath()
+	if err != nil {
+		t.Fatal(err)
+	}
+	writeJSON(t, configFile, config{CalDAV: caldavConfig{URL: srv.url()}})
+}
+
+func mustSync(t *testing.T) []Event {
+	t.Helper()
+	if err := syncEvents(); err != nil {
+		t.Fatalf("syncEvents() error = %v", err)
+	}
+	events, err := readProfileEvents(defaultProfile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return events
+}
+
+func names(events []Event) []string {
+	var out []string
+	for _, e := range events {
+		out = append(out, e.Name)
+	}
+	return out
+}
+
+func TestCalDAVSync(t *testing.T) {
+	newTestHelper(t)
+	srv := newDAVServer(t)
+	setCalDAV(t, srv)
+	if err := saveProfileEvents(defaultProfile, []Event{{ID: "local", Name: "Local", Time: 1_800_000_000}}); err != nil {
+		t.Fatal(err)
+	}
+
+	mustSync(t)
+	if got := names(srv.events(t)); !slices.Equal(got, []string{"Local"}) {
+		t.Fatalf("Expected the local event to be pushed, server has %v", got)
+	}
+
+	srv.set("/cal/remote.ics", string(formatICS([]Event{{ID: "remote", Name: "Remote", Time: 1_900_000_000}})))
+	srv.gets = 0
+	events := mustSync(t)
+	if got := names(events); !slices.Equal(got, []string{"Local", "Remote"}) {
+		t.Fatalf("Expected the remote event to be pulled, got %v", got)
+	}
+	if srv.gets != 1 {
+		t.Errorf("Expected the sync token to limit fetches to the new event, got %d GETs", srv.gets)
+	}
+
+	srv.set("/cal/remote.ics", string(formatICS([]Event{{ID: "remote", Name: "Remote, renamed", Time: 1_900_000_000}})))
+	events = mustSync(t)
+	if got := names(events); !slices.Equal(got, []string{"Local", "Remote, renamed"}) {
+		t.Fatalf("Expected the remote edit to be pulled, got %v", got)
+	}
+
+	srv.remove("/cal/remote.ics")
+	events = mustSync(t)
+	if got := names(events); !slices.Equal(got, []string{"Local"}) {
+		t.Fatalf("Expected the remote removal to be pulled, got %v", got)
+	}
+
+	if err := saveProfileEvents(defaultProfile, nil); err != nil {
+		t.Fatal(err)
+	}
+	mustSync(t)
+	if got := srv.events(t); len(got) != 0 {
+		t.Errorf("Expected the local removal to be pushed, server has %v", got)
+	}
+}
+
+func TestCalDAVConflictKeepsLocalEdit(t *testing.T) {
+	newTestHelper(t)
+	srv := newDAVServer(t)
+	setCalDAV(t, srv)
+	if err := saveProfileEvents(defaultProfile, []Event{{ID: "shared", Name: "Shared", Time: 1_800_000_000}}); err != nil {
+		t.Fatal(err)
+	}
+	mustSync(t)
+
+	srv.set("/cal/shared.ics", string(formatICS([]Event{{ID: "shared", Name: "Theirs", Time: 1_800_000_000}})))
+	if err := saveProfileEvents(defaultProfile, []Event{{ID: "shared", Name: "Ours", Time: 1_800_000_000}}); err != nil {
+		t.Fatal(err)
+	}
+	events := mustSync(t)
+	if got := names(events); !slices.Equal(got, []string{"Ours"}) {
+		t.Errorf("Expected the local edit to win, got %v", got)
+	}
+	if got := names(srv.events(t)); !slices.Equal(got, []string{"Ours"}) {
+		t.Errorf("Expected the local edit to be pushed, server has %v", got)
+	}
+}
+
+func TestCalDAVWithoutSyncCollection(t *testing.T) {
+	newTestHelper(t)
+	srv := newDAVServer(t)
+	srv.noSync = true
+	setCalDAV(t, srv)
+	if err := saveProfileEvents(defaultProfile, []Event{{ID: "local", Name: "Local", Time: 1_800_000_000}}); err != nil {
+		t.Fatal(err)
+	}
+	mustSync(t)
+
+	srv.set("/cal/remote.ics", string(formatICS([]Event{{ID: "remote", Name: "Remote", Time: 1_900_000_000}})))
+	srv.gets = 0
+	events := mustSync(t)
+	if got := names(events); !slices.Equal(got, []string{"Local", "Remote"}) {
+		t.Fatalf("Expected the remote event to be pulled, got %v", got)
+	}
+	if srv.gets != 1 {
+		t.Errorf("Expected ETags to limit fetches to the new event, got %d GETs", srv.gets)
+	}
+
+	srv.remove("/cal/local.ics")
+	events = mustSync(t)
+	if got := names(events); !slices.Equal(got, []string{"Remote"}) {
+		t.Errorf("Expected the remote removal to be pulled, got %v", got)
+	}
+}
+
+func TestCalDAVStateEncrypted(t *testing.T) {
+	newTestHelper(t)
+	srv := newDAVServer(t)
+	setCalDAV(t, srv)
+	secrets.encrypt = true
+	secrets.passphrase = "s3cret"
+	if err := saveProfileEvents(defaultProfile, []Event{{ID: "local", Name: "Acquisition", Time: 1_800_000_000}}); err != nil {
+		t.Fatal(err)
+	}
+	srv.set("/cal/remote.ics", string(formatICS([]Event{{ID: "remote", Name: "Merger", Time: 1_900_000_000}})))
+	mustSync(t)
+
+	eventsFile, err := getEventsFilePath()
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = filepath.WalkDir(filepath.Dir(eventsFile), func(path string, d fs.DirEntry, err error) error {
+		if err != nil || d.IsDir() {
+			return err
+		}
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return err
+		}
+		for _, name := range []string{"Acquisition", "Merger"} {
+			if bytes.Contains(data, []byte(name)) {
+				t.Errorf("%s contains %q in plaintext", filepath.Base(path), name)
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// The sealed state still drives the next sync.
+	srv.gets = 0
+	if got := names(mustSync(t)); !slices.Equal(got, []string{"Acquisition", "Merger"}) {
+		t.Errorf("Expected both events after another sync, got %v", got)
+	}
+	if srv.gets != 0 {
+		t.Errorf("Expected no fetches with nothing changed, got %d GETs", srv.gets)
+	}
+}
+
+func TestFormatICSRoundTrip(t *testing.T) {
+	events := []Event{
+		{ID: "a@example.com", Name: "Launch; phase 2, with a\\backslash", Time: 1_800_000_000,
+			Tags: []string{"release", "q3, maybe"}, Notes: "Line one\nLine two"},
+		{ID: "b", Name: strings.Repeat("Ünïcödé ", 20), Time: 1_900_000_000},
+	}
+	data := formatICS(events)
+	for line := range strings.Lines(string(data)) {
+		if len(strings.TrimSuffix(line, "\r\n")) > 75 {
+			t.Errorf("Line longer than 75 octets: %q", line)
+		}
+	}
+	got, err := parseICS(data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !equalEvents(got, events) {
+		t.Errorf("Round trip = %v, want %v", got, events)
+	}
+}
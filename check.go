@@ -0,0 +1,94 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+	"text/tabwriter"
+	"time"
+)
+
+const defaultCheckWithin = 24 * time.Hour
+
+// dueEvents returns the events that are expired or happen within the
+// window, optionally only those with a tag.
+func dueEvents(events []Event, now time.Time, within time.Duration, tag string) []Event {
+	var due []Event
+	for _, e := range events {
+		if tag != "" && !e.hasTag(tag) {
+			continue
+		}
+		if time.Unix(e.Time, 0).Sub(now) < within {
+			due = append(due, e)
+		}
+	}
+	return due
+}
+
+// runCheck is for CI pipelines: it lists the profile's own events that are
+// expired or inside the window and fails if there are any. Generated and
+// read-only events are only checked with --all.
+func runCheck(c *cli, args []string) error {
+	fs := c.newFlagSet("check")
+	within := defaultCheckWithin
+	fs.Var((*spanFlag)(&within), "within", "fail for events less than this away, e.g. `48h`")
+	tag := fs.String("tag", "", "only check events with this tag")
+	all := fs.Bool("all", false, "include events from sources, feeds, plugins and providers")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if fs.NArg() > 0 {
+		return errUsage
+	}
+
+	load := loadOwnEvents
+	if *all {
+		load = loadProfileEvents
+	}
+	events, err := load(c.profile)
+	if err != nil {
+		return err
+	}
+	now := time.Now()
+	due := dueEvents(events, now, within, *tag)
+	if len(due) == 0 {
+		_, err := fmt.Fprintf(c.stdout, "No events within %s\n", formatSpan(within))
+		return err
+	}
+	tw := tabwriter.NewWriter(c.stdout, 0, 0, 2, ' ', 0)
+	for _, e := range due {
+		t := time.Unix(e.Time, 0)
+		left := expiredText
+		switch {
+		case e.onItsDay(now):
+			left = todayText
+		case !t.Before(now):
+			left = "in " + formatCountdown(int(t.Sub(now).Seconds()))
+		}
+		fmt.Fprintf(tw, "%s\t%s\t%s\n", e.Name, t.Format("Mon 2006-01-02 15:04 MST"), left)
+	}
+	if err := tw.Flush(); err != nil {
+		return err
+	}
+	noun := "events are"
+	if len(due) == 1 {
+		noun = "event is"
+	}
+	return fmt.Errorf("%d %s expired or within %s", len(due), noun, formatSpan(within))
+}
+
+// formatSpan writes a duration the way parseSpan reads it, preferring
+// whole days, e.g. "2d" rather than "48h0m0s".
+func formatSpan(d time.Duration) string {
+	const day = 24 * time.Hour
+	if d >= day && d%day == 0 {
+		return fmt.Sprintf("%dd", d/day)
+	}
+	s := d.String()
+	if strings.HasSuffix(s, "m0s") {
+		s = strings.TrimSuffix(s, "0s")
+	}
+	if strings.HasSuffix(s, "h0m") {
+		s = strings.TrimSuffix(s, "0m")
+	}
+	return s
+}
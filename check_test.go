@@ -0,0 +1,107 @@
+package main
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestDueEvents(t *testing.T) {
+	now := time.Date(2026, 10, 18, 12, 0, 0, 0, time.UTC)
+	events := []Event{
+		{Name: "Retro", Time: now.Add(-time.Hour).Unix()},
+		{Name: "Code freeze", Time: now.Add(30 * time.Hour).Unix(), Tags: []string{"Freeze"}},
+		{Name: "Launch", Time: now.Add(72 * time.Hour).Unix(), Tags: []string{"freeze"}},
+	}
+	names := func(events []Event) string {
+		var names []string
+		for _, e := range events {
+			names = append(names, e.Name)
+		}
+		return strings.Join(names, ",")
+	}
+	tests := []struct {
+		within time.Duration
+		tag    string
+		want   string
+	}{
+		{24 * time.Hour, "", "Retro"},
+		{48 * time.Hour, "", "Retro,Code freeze"},
+		{48 * time.Hour, "freeze", "Code freeze"},
+		{7 * 24 * time.Hour, "freeze", "Code freeze,Launch"},
+		{7 * 24 * time.Hour, "release", ""},
+	}
+	for _, tt := range tests {
+		if got := names(dueEvents(events, now, tt.within, tt.tag)); got != tt.want {
+			t.Errorf("dueEvents(%v, %q) = %q, want %q", tt.within, tt.tag, got, tt.want)
+		}
+	}
+}
+
+func TestFormatSpan(t *testing.T) {
+	for d, want := range map[time.Duration]string{
+		48 * time.Hour:             "2d",
+		36 * time.Hour:             "36h",
+		90 * time.Minute:           "1h30m",
+		30 * time.Second:           "30s",
+		time.Hour + 30*time.Second: "1h0m30s",
+	} {
+		if got := formatSpan(d); got != want {
+			t.Errorf("formatSpan(%v) = %q, want %q", d, got, want)
+		}
+	}
+}
+
+func TestCheckCommand(t *testing.T) {
+	newTestHelper(t)
+	now := time.Now()
+	events := []Event{
+		{Name: "Code freeze", Time: now.Add(30 * time.Hour).Unix(), Tags: []string{"freeze"}},
+		{Name: "Standup", Time: now.Add(2 * time.Hour).Unix()},
+	}
+	if err := saveProfileEvents(defaultProfile, events); err != nil {
+		t.Fatal(err)
+	}
+	var out bytes.Buffer
+	c := &cli{profile: defaultProfile, stdout: &out, stderr: &out}
+
+	if err := c.run([]string{"check", "--within", "1d", "--tag", "freeze"}); err != nil {
+		t.Errorf("Expected no freeze within a day, got %v", err)
+	}
+	if got := out.String(); got != "No events within 1d\n" {
+		t.Errorf("Expected a summary, got %q", got)
+	}
+
+	out.Reset()
+	err := c.run([]string{"check", "--within", "48h", "--tag", "freeze"})
+	if err == nil || err.Error() != "1 event is expired or within 2d" {
+		t.Errorf("Expected the check to fail, got %v", err)
+	}
+	if got := out.String(); !strings.HasPrefix(got, "Code freeze  ") || !strings.Contains(got, "in 1d 5h") {
+		t.Errorf("Expected the freeze in the summary, got %q", got)
+	}
+
+	if err := c.run([]string{"check", "--within", "soon"}); err == nil {
+		t.Error("Expected an invalid window to fail")
+	}
+}
+
+func TestCheckOwnEvents(t *testing.T) {
+	th := newTestHelper(t)
+	th.enableProviders(t, "astronomy")
+	writeJSON(t, systemEventsFile, []Event{{Name: "Old company holiday", Time: time.Now().AddDate(-1, 0, 0).Unix()}})
+	if err := saveProfileEvents(defaultProfile, []Event{{Name: "Launch", Time: time.Now().AddDate(0, 1, 0).Unix()}}); err != nil {
+		t.Fatal(err)
+	}
+	var out bytes.Buffer
+	c := &cli{profile: defaultProfile, stdout: &out, stderr: &out}
+
+	if err := c.run([]string{"check", "--within", "7d"}); err != nil {
+		t.Errorf("Expected generated and read-only events to be left out, got %v:\n%s", err, out.String())
+	}
+	out.Reset()
+	if err := c.run([]string{"check", "--within", "7d", "--all"}); err == nil || !strings.Contains(out.String(), "Old company holiday") {
+		t.Errorf("Expected --all to check read-only events too, got %v:\n%s", err, out.String())
+	}
+}
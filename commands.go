@@ -0,0 +1,289 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+	"time"
+)
+
+// cli carries the global options and I/O streams shared by subcommands.
+type cli struct {
+	profile string
+	stdin   io.Reader
+	stdout  io.Writer
+	stderr  io.Writer
+	// flagSet is the last flag set newFlagSet made, which shell
+	// completion inspects.
+	flagSet *flag.FlagSet
+}
+
+// command is a subcommand run instead of the TUI, e.g. "countdown history".
+type command struct {
+	name    string
+	usage   string
+	summary string
+	run     func(c *cli, args []string) error
+	// eventArgs marks commands whose arguments name events, for shell
+	// completion.
+	eventArgs bool
+	// quiet marks commands run from prompts, bars and completion, which
+	// never ask for a passphrase.
+	quiet bool
+}
+
+var commands = []command{
+	{
+		name:    "history",
+		usage:   "history [restore N]",
+		summary: "show the change log or revert entry N",
+		run:     runHistory,
+	},
+	{
+		name:    "sync",
+		usage:   "sync",
+		summary: "commit, pull and push events with the configured git remote",
+		run:     runSync,
+	},
+	{
+		name:    "feeds",
+		usage:   "feeds",
+		summary: "fetch every configured feed and run every plugin now",
+		run:     runFeeds,
+	},
+	{
+		name:    "import",
+		usage:   "import [--csv|--ics|--json|--org|--taskwarrior|--todotxt|--vcard] [--on-duplicate skip|overwrite|keep] [--dry-run] FILE|-",
+		summary: "add the events in a file or stdin to the profile",
+		run:     runImport,
+	},
+	{
+		name:    "edit",
+		usage:   "edit --all",
+		summary: "edit every event of the profile as TOML in $VISUAL or $EDITOR",
+		run:     runEdit,
+	},
+	{
+		name:    "export",
+		usage:   "export --csv|--html|--markdown|--org [--all] [FILE]",
+		summary: "write the profile's events to FILE or stdout",
+		run:     runExport,
+	},
+	{
+		name:      "badge",
+		usage:     "badge [--label TEXT] [--warn 7d] [--urgent 24h] EVENT",
+		summary:   "print an SVG badge with the time left until EVENT",
+		run:       runBadge,
+		eventArgs: true,
+	},
+	{
+		name:    "status",
+		usage:   "status [--format TEMPLATE] [--max-width N] [--cache]",
+		summary: "print one line about the next event for shell prompts",
+		run:     runStatus,
+		quiet:   true,
+	},
+	{
+		name:      "bar",
+		usage:     "bar [--protocol i3bar|waybar|plain] [--format TEMPLATE] [--warn 7d] [--urgent 24h] [--once] [EVENT]",
+		summary:   "stream the next event or EVENT to a status bar every second",
+		run:       runBar,
+		eventArgs: true,
+		quiet:     true,
+	},
+	{
+		name:    "check",
+		usage:   "check [--within 24h] [--tag TAG] [--all]",
+		summary: "fail if an event is expired or within the window, for CI",
+		run:     runCheck,
+	},
+	{
+		name:    "render",
+		usage:   "render [--width 100] [--height 30] [--now TIME] [--keys KEYS] [--light] [--ansi]",
+		summary: "print one frame of the TUI, for screenshots and visual tests",
+		run:     runRender,
+	},
+	{
+		name:    "encrypt",
+		usage:   "encrypt",
+		summary: "encrypt events, backups and history with a passphrase",
+		run:     func(c *cli, args []string) error { return runSetEncryption(c, args, true) },
+	},
+	{
+		name:    "decrypt",
+		usage:   "decrypt",
+		summary: "store events, backups and history as plain JSON again",
+		run:     func(c *cli, args []string) error { return runSetEncryption(c, args, false) },
+	},
+	{
+		name:    "completion",
+		usage:   "completion bash|zsh|fish",
+		summary: "print a shell completion script",
+		run:     runCompletion,
+		quiet:   true,
+	},
+}
+
+// errUsage is returned by commands when their positional arguments are
+// wrong; main exits with status 2 for it.
+var errUsage = errors.New("invalid usage")
+
+func findCommand(name string) (command, bool) {
+	for _, cmd := range commands {
+		if cmd.name == name {
+			return cmd, true
+		}
+	}
+	return command{}, false
+}
+
+func (c *cli) run(args []string) error {
+	// Completion isn't listed in commands, since it reads the list.
+	if args[0] == completeCommandName {
+		return runComplete(c, args[1:])
+	}
+	cmd, ok := findCommand(args[0])
+	if !ok {
+		return fmt.Errorf("%w: unknown command %q", errUsage, args[0])
+	}
+	err := cmd.run(c, args[1:])
+	if errors.Is(err, errUsage) {
+		return fmt.Errorf("%w, usage: %s %s", err, appName, cmd.usage)
+	}
+	return err
+}
+
+// findEvent returns the event a command line argument refers to: one with
+// that ID, else one with that name, else the only one whose name starts
+// with it, ignoring case. Among events with the same name the next one to
+// come is preferred.
+func findEvent(events []Event, query string) (Event, error) {
+	for _, e := range events {
+		if e.ID != "" && e.ID == query {
+			return e, nil
+		}
+	}
+	now := time.Now().Unix()
+	var found []Event
+	for _, match := range []func(name string) bool{
+		func(name string) bool { return strings.EqualFold(name, query) },
+		func(name string) bool { return strings.HasPrefix(strings.ToLower(name), strings.ToLower(query)) },
+	} {
+		for _, e := range events {
+			if match(e.Name) {
+				found = append(found, e)
+			}
+		}
+		if len(found) > 0 {
+			break
+		}
+	}
+	names := make(map[string]bool)
+	for _, e := range found {
+		names[strings.ToLower(e.Name)] = true
+	}
+	switch {
+	case len(found) == 0:
+		return Event{}, fmt.Errorf("no event %q", query)
+	case len(names) > 1:
+		return Event{}, fmt.Errorf("%q matches %d events, be more specific", query, len(names))
+	}
+	best := found[0]
+	for _, e := range found[1:] {
+		// Events are sorted by time: take the first one still to come.
+		if best.Time < now && e.Time >= now {
+			best = e
+		}
+	}
+	return best, nil
+}
+
+// parseSpan parses a Go duration, also accepting whole days ("7d") and
+// weeks ("2w").
+func parseSpan(s string) (time.Duration, error) {
+	for suffix, unit := range map[string]time.Duration{"d": 24 * time.Hour, "w": 7 * 24 * time.Hour} {
+		if n, ok := strings.CutSuffix(s, suffix); ok {
+			if v, err := strconv.ParseFloat(n, 64); err == nil {
+				return time.Duration(v * float64(unit)), nil
+			}
+		}
+	}
+	d, err := time.ParseDuration(s)
+	if err != nil {
+		return 0, fmt.Errorf("invalid duration %q, e.g. 48h, 7d or 2w", s)
+	}
+	return d, nil
+}
+
+// spanFlag is a flag.Value for durations given with parseSpan.
+type spanFlag time.Duration
+
+func (f *spanFlag) String() string { return time.Duration(*f).String() }
+
+func (f *spanFlag) Set(s string) error {
+	d, err := parseSpan(s)
+	*f = spanFlag(d)
+	return err
+}
+
+// newFlagSet returns a flag set for a subcommand that reports errors
+// instead of exiting and writes its usage to the cli's stderr.
+func (c *cli) newFlagSet(cmd string) *flag.FlagSet {
+	fs := flag.NewFlagSet(appName+" "+cmd, flag.ContinueOnError)
+	fs.SetOutput(c.stderr)
+	c.flagSet = fs
+	return fs
+}
+
+// printUsage writes the top-level usage, including the subcommands.
+func printUsage(w io.Writer, fs *flag.FlagSet) {
+	fmt.Fprintf(w, "Usage: %s [flags] [command]\n\nFlags:\n", appName)
+	fs.SetOutput(w)
+	fs.PrintDefaults()
+	fmt.Fprintln(w, "\nCommands:")
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	for _, cmd := range commands {
+		fmt.Fprintf(tw, "  %s\t%s\n", cmd.usage, cmd.summary)
+	}
+	tw.Flush()
+}
+
+func runHistory(c *cli, args []string) error {
+	fs := c.newFlagSet("history")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	entries, err := readHistory()
+	if err != nil {
+		return err
+	}
+	switch fs.NArg() {
+	case 0:
+		tw := tabwriter.NewWriter(c.stdout, 0, 0, 2, ' ', 0)
+		for i, entry := range entries {
+			fmt.Fprintf(tw, "%d\t%s\t%s\t%s\n", i+1,
+				time.Unix(entry.Time, 0).Format(inputTimeFormLong), entry.Profile, entry.change)
+		}
+		return tw.Flush()
+	case 2:
+		if fs.Arg(0) != "restore" {
+			return errUsage
+		}
+		n, err := strconv.Atoi(fs.Arg(1))
+		if err != nil || n < 1 || n > len(entries) {
+			return fmt.Errorf("no history entry %q", fs.Arg(1))
+		}
+		entry := entries[n-1]
+		if err := restoreHistoryEntry(entry); err != nil {
+			return err
+		}
+		fmt.Fprintf(c.stdout, "Reverted: %s\n", entry.change)
+		return nil
+	default:
+		return errUsage
+	}
+}
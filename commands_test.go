@@ -0,0 +1,57 @@
+package main
+
+import (
+	"testing"
+	"time"
+)
+
+func TestFindEvent(t *testing.T) {
+	now := time.Now().Unix()
+	events := []Event{
+		{ID: "r1", Name: "Retro", Time: now - 1000},
+		{ID: "r2", Name: "Retro", Time: now + 1000},
+		{ID: "l", Name: "Launch", Time: now + 2000},
+		{ID: "lp", Name: "Launch party", Time: now + 3000},
+	}
+	tests := []struct {
+		query, want string
+	}{
+		{"l", "l"},
+		{"lp", "lp"},
+		{"retro", "r2"},
+		{"LAUNCH", "l"},
+		{"launch p", "lp"},
+		{"re", "r2"},
+	}
+	for _, tt := range tests {
+		got, err := findEvent(events, tt.query)
+		if err != nil || got.ID != tt.want {
+			t.Errorf("findEvent(%q) = %q, %v, want %q", tt.query, got.ID, err, tt.want)
+		}
+	}
+	for _, bad := range []string{"x", "la"} {
+		if _, err := findEvent(events, bad); err == nil {
+			t.Errorf("Expected findEvent(%q) to fail", bad)
+		}
+	}
+}
+
+func TestParseSpan(t *testing.T) {
+	tests := map[string]time.Duration{
+		"48h":  48 * time.Hour,
+		"7d":   7 * 24 * time.Hour,
+		"1.5d": 36 * time.Hour,
+		"2w":   14 * 24 * time.Hour,
+		"90m":  90 * time.Minute,
+	}
+	for in, want := range tests {
+		if got, err := parseSpan(in); err != nil || got != want {
+			t.Errorf("parseSpan(%q) = %v, %v, want %v", in, got, err, want)
+		}
+	}
+	for _, bad := range []string{"", "soon", "7days"} {
+		if _, err := parseSpan(bad); err == nil {
+			t.Errorf("Expected parseSpan(%q) to fail", bad)
+		}
+	}
+}
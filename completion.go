@@ -0,0 +1,243 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"slices"
+	"sort"
+	"strings"
+)
+
+// completeCommandName is the hidden command the completion scripts call
+// with the words typed so far. It prints one candidate per line; no
+// candidates makes the shell complete file names instead.
+const completeCommandName = "__complete"
+
+// completionScripts are printed by `countdown completion SHELL`. They
+// leave all the work to __complete, so they never go out of date. Stdin
+// is closed so an encrypted profile can't prompt for its passphrase.
+var completionScripts = map[string]string{
+	"bash": `# bash completion for countdown
+_countdown() {
+    local cur=${COMP_WORDS[COMP_CWORD]} IFS=$'\n' candidate
+    local -a candidates
+    candidates=($("${COMP_WORDS[0]}" __complete "${COMP_WORDS[@]:1:COMP_CWORD}" </dev/null 2>/dev/null))
+    if (( ${#candidates[@]} == 0 )); then
+        compopt -o filenames 2>/dev/null
+        COMPREPLY=($(compgen -f -- "$cur"))
+        return
+    fi
+    COMPREPLY=()
+    for candidate in "${candidates[@]}"; do
+        COMPREPLY+=("$(printf '%q' "$candidate")")
+    done
+}
+complete -F _countdown countdown
+`,
+	"zsh": `#compdef countdown
+compdef _countdown countdown
+
+_countdown() {
+    local -a candidates
+    candidates=(${(f)"$(${words[1]} __complete "${(@)words[2,CURRENT]}" </dev/null 2>/dev/null)"})
+    if (( ${#candidates} == 0 )); then
+        _files
+        return
+    fi
+    compadd -- "${candidates[@]}"
+}
+
+# Only complete right away when autoloaded, not when sourced.
+if [ "$funcstack[1]" = "_countdown" ]; then
+    _countdown "$@"
+fi
+`,
+	"fish": `# fish completion for countdown
+function __countdown_complete
+    set -l args (commandline -opc)[2..-1] (commandline -ct)
+    set -l candidates (countdown __complete $args </dev/null 2>/dev/null)
+    if test (count $candidates) -eq 0
+        __fish_complete_path (commandline -ct)
+        return
+    end
+    printf '%s\n' $candidates
+end
+complete -c countdown -f -a '(__countdown_complete)'
+`,
+}
+
+func runCompletion(c *cli, args []string) error {
+	fs := c.newFlagSet("completion")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if fs.NArg() != 1 {
+		return errUsage
+	}
+	script, ok := completionScripts[fs.Arg(0)]
+	if !ok {
+		return fmt.Errorf("%w: unknown shell %q", errUsage, fs.Arg(0))
+	}
+	_, err := io.WriteString(c.stdout, script)
+	return err
+}
+
+func runComplete(c *cli, args []string) error {
+	for _, candidate := range c.completions(args) {
+		fmt.Fprintln(c.stdout, candidate)
+	}
+	return nil
+}
+
+// flagCompletions complete the values of flags by name, whichever command
+// they belong to.
+var flagCompletions = map[string]func(profile string) []string{
+	"profile": func(string) []string {
+		profiles, _ := listProfiles()
+		return profiles
+	},
+	"tag": eventTags,
+	"protocol": func(string) []string {
+		names := make([]string, len(barProtocols))
+		for i, p := range barProtocols {
+			names[i] = p.name
+		}
+		return names
+	},
+	"on-duplicate": func(string) []string {
+		return []string{string(policySkip), string(policyOverwrite), string(policyKeep)}
+	},
+}
+
+// completions returns the candidates for the last of words, the command
+// line after the program name.
+func (c *cli) completions(words []string) []string {
+	if len(words) == 0 {
+		words = []string{""}
+	}
+	cur, words := words[len(words)-1], words[:len(words)-1]
+
+	// Global flags come before the command.
+	profile, i := c.profile, 0
+	for i < len(words) && strings.HasPrefix(words[i], "-") {
+		name, value, hasValue := strings.Cut(strings.TrimLeft(words[i], "-"), "=")
+		i++
+		if name != "profile" {
+			continue
+		}
+		if !hasValue {
+			if i == len(words) {
+				return matching(flagCompletions["profile"](profile), cur)
+			}
+			value = words[i]
+			i++
+		}
+		profile = value
+	}
+	if i == len(words) {
+		if strings.HasPrefix(cur, "-") {
+			return matching([]string{"--profile"}, cur)
+		}
+		var names []string
+		for _, cmd := range commands {
+			names = append(names, cmd.name)
+		}
+		return matching(names, cur)
+	}
+
+	cmd, ok := findCommand(words[i])
+	if !ok {
+		return nil
+	}
+	fs := c.commandFlags(cmd)
+	if args := words[i+1:]; len(args) > 0 {
+		// The word before is a flag waiting for its value.
+		if last := args[len(args)-1]; strings.HasPrefix(last, "-") && !strings.Contains(last, "=") {
+			if f := fs.Lookup(strings.TrimLeft(last, "-")); f != nil && !isBoolFlag(f) {
+				if values, ok := flagCompletions[f.Name]; ok {
+					return matching(values(profile), cur)
+				}
+				return nil
+			}
+		}
+	}
+	if strings.HasPrefix(cur, "-") {
+		var names []string
+		fs.VisitAll(func(f *flag.Flag) { names = append(names, "--"+f.Name) })
+		return matching(names, cur)
+	}
+	if cmd.eventArgs {
+		return eventCandidates(profile, cur)
+	}
+	return nil
+}
+
+// commandFlags returns the flags a command defines. Every command parses
+// its flags before doing anything else, so running it with -h defines
+// them and stops there.
+func (c *cli) commandFlags(cmd command) *flag.FlagSet {
+	probe := &cli{profile: c.profile, stdin: strings.NewReader(""), stdout: io.Discard, stderr: io.Discard}
+	_ = cmd.run(probe, []string{"-h"})
+	if probe.flagSet == nil {
+		return flag.NewFlagSet(cmd.name, flag.ContinueOnError)
+	}
+	return probe.flagSet
+}
+
+func isBoolFlag(f *flag.Flag) bool {
+	b, ok := f.Value.(interface{ IsBoolFlag() bool })
+	return ok && b.IsBoolFlag()
+}
+
+// matching returns the candidates that start with prefix.
+func matching(candidates []string, prefix string) []string {
+	var matches []string
+	for _, c := range candidates {
+		if strings.HasPrefix(c, prefix) {
+			matches = append(matches, c)
+		}
+	}
+	return matches
+}
+
+// eventCandidates returns the names of a profile's events that start with
+// prefix, and the IDs that do once some of one has been typed.
+func eventCandidates(profile, prefix string) []string {
+	events, err := loadProfileEvents(profile)
+	if err != nil {
+		return nil
+	}
+	var candidates []string
+	for _, e := range events {
+		if strings.HasPrefix(e.Name, prefix) && !slices.Contains(candidates, e.Name) {
+			candidates = append(candidates, e.Name)
+		}
+	}
+	if prefix != "" {
+		for _, e := range events {
+			if e.ID != "" && strings.HasPrefix(e.ID, prefix) {
+				candidates = append(candidates, e.ID)
+			}
+		}
+	}
+	return candidates
+}
+
+// eventTags returns every tag used in a profile, sorted.
+func eventTags(profile string) []string {
+	events, err := loadProfileEvents(profile)
+	if err != nil {
+		return nil
+	}
+	var tags []string
+	for _, e := range events {
+		for _, tag := range e.Tags {
+			if !slices.Contains(tags, tag) {
+				tags = append(tags, tag)
+			}
+		}
+	}
+	sort.Strings(tags)
+	return tags
+}
@@ -0,0 +1,104 @@
+package main
+
+import (
+	"bytes"
+	"errors"
+	"flag"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+)
+
+func TestCommandsParseFlagsFirst(t *testing.T) {
+	newTestHelper(t)
+	// Completion runs commands with -h to see their flags, which is only
+	// safe if that stops them before they do anything.
+	for _, cmd := range commands {
+		probe := &cli{profile: defaultProfile, stdin: strings.NewReader(""), stdout: io.Discard, stderr: io.Discard}
+		if err := cmd.run(probe, []string{"-h"}); !errors.Is(err, flag.ErrHelp) || probe.flagSet == nil {
+			t.Errorf("Expected %s -h to stop at its flags, got %v", cmd.name, err)
+		}
+	}
+}
+
+func TestCompletions(t *testing.T) {
+	newTestHelper(t)
+	events := []Event{
+		{ID: "a1", Name: "Code freeze", Time: 1900000000, Tags: []string{"release", "freeze"}},
+		{ID: "b2", Name: "Conference", Time: 1900100000, Tags: []string{"travel"}},
+	}
+	if err := saveProfileEvents(defaultProfile, events); err != nil {
+		t.Fatal(err)
+	}
+	if err := saveProfileEvents("work", []Event{{Name: "Review", Time: 1900000000}}); err != nil {
+		t.Fatal(err)
+	}
+	c := &cli{profile: defaultProfile}
+	tests := []struct {
+		words []string
+		want  []string
+	}{
+		{[]string{"ba"}, []string{"badge", "bar"}},
+		{[]string{"-"}, []string{"--profile"}},
+		{[]string{"--profile", ""}, []string{defaultProfile, "work"}},
+		{[]string{"check", "--t"}, []string{"--tag"}},
+		{[]string{"check", "--tag", ""}, []string{"freeze", "release", "travel"}},
+		{[]string{"bar", "--protocol", "i"}, []string{"i3bar"}},
+		{[]string{"import", "--on-duplicate", ""}, []string{"skip", "overwrite", "keep"}},
+		{[]string{"badge", "Co"}, []string{"Code freeze", "Conference"}},
+		{[]string{"badge", "--warn", "7d", "Cod"}, []string{"Code freeze"}},
+		{[]string{"badge", "b"}, []string{"b2"}},
+		{[]string{"--profile", "work", "bar", ""}, []string{"Review"}},
+		{[]string{"--profile=work", "check", "--tag", ""}, nil},
+		{[]string{"export", ""}, nil},
+		{[]string{"nope", ""}, nil},
+	}
+	for _, tt := range tests {
+		if got := c.completions(tt.words); !slices.Equal(got, tt.want) {
+			t.Errorf("completions(%q) = %q, want %q", tt.words, got, tt.want)
+		}
+	}
+
+	var out bytes.Buffer
+	c.stdout = &out
+	if err := c.run([]string{completeCommandName, "sta"}); err != nil || out.String() != "status\n" {
+		t.Errorf("Expected the hidden command to print candidates, got %q (err %v)", out.String(), err)
+	}
+}
+
+func TestCompletionScripts(t *testing.T) {
+	for shell, script := range completionScripts {
+		var out bytes.Buffer
+		c := &cli{profile: defaultProfile, stdout: &out, stderr: io.Discard}
+		if err := c.run([]string{"completion", shell}); err != nil || out.String() != script {
+			t.Errorf("Expected the %s script, got %v", shell, err)
+		}
+		if !strings.Contains(script, completeCommandName) {
+			t.Errorf("Expected the %s script to call %s", shell, completeCommandName)
+		}
+		// Check the syntax where the shell is installed.
+		path, err := exec.LookPath(shell)
+		if err != nil {
+			continue
+		}
+		file := filepath.Join(t.TempDir(), "countdown."+shell)
+		if err := os.WriteFile(file, []byte(script), 0o644); err != nil {
+			t.Fatal(err)
+		}
+		check := []string{"-n", file}
+		if shell == "fish" {
+			check = []string{"--no-execute", file}
+		}
+		if out, err := exec.Command(path, check...).CombinedOutput(); err != nil {
+			t.Errorf("%s script has a syntax error: %v\n%s", shell, err, out)
+		}
+	}
+	c := &cli{profile: defaultProfile, stdout: io.Discard, stderr: io.Discard}
+	if err := c.run([]string{"completion", "tcsh"}); !errors.Is(err, errUsage) {
+		t.Errorf("Expected an unknown shell to fail, got %v", err)
+	}
+}
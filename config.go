@@ -0,0 +1,103 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+)
+
+const configFileName = "config.json"
+
+// config holds the optional settings read from config.json in the user's
+// config directory. A missing file is the same as an empty one.
+type config struct {
+	Sources []eventSource `json:"sources,omitempty"`
+	// Providers turns built-in event providers on or off by name.
+	Providers map[string]providerConfig `json:"providers,omitempty"`
+	// BackupRetention is the number of backups kept per profile. Zero
+	// keeps the default number and a negative value disables backups.
+	BackupRetention int `json:"backupRetention,omitempty"`
+	// Encrypt makes every events file, backup and history entry be
+	// written encrypted with the storage passphrase.
+	Encrypt bool `json:"encrypt,omitempty"`
+	// KeyFile holds the passphrase for non-interactive use. The
+	// COUNTDOWN_KEY_FILE environment variable takes precedence.
+	KeyFile string `json:"keyFile,omitempty"`
+	// Sync keeps the config directory in a git repository shared with
+	// other machines.
+	Sync syncConfig `json:"sync,omitzero"`
+	// CalDAV mirrors a profile into a calendar on a CalDAV server.
+	CalDAV caldavConfig `json:"caldav,omitzero"`
+}
+
+// getConfigFilePath returns the path to config.json next to the events file.
+func getConfigFilePath() (string, error) {
+	eventsFile, err := getEventsFilePath()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(filepath.Dir(eventsFile), configFileName), nil
+}
+
+func loadConfig() (config, error) {
+	var cfg config
+	path, err := getConfigFilePath()
+	if err != nil {
+		return cfg, err
+	}
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return cfg, nil
+	}
+	if err != nil {
+		return cfg, err
+	}
+	if err := json.Unmarshal(data, &cfg); err != nil {
+		return cfg, fmt.Errorf("failed to parse %s: %w", path, err)
+	}
+	return cfg, nil
+}
+
+func saveConfig(cfg config) error {
+	path, err := getConfigFilePath()
+	if err != nil {
+		return err
+	}
+	data, err := json.MarshalIndent(cfg, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(path, append(data, '\n'), 0o644)
+}
+
+// resolvePath expands a leading "~/" and makes relative paths relative to
+// the config directory, so config.json can be moved between machines.
+func resolvePath(path string) (string, error) {
+	if rest, ok := cutHomePrefix(path); ok {
+		home, err := os.UserHomeDir()
+		if err != nil {
+			return "", err
+		}
+		return filepath.Join(home, rest), nil
+	}
+	if filepath.IsAbs(path) {
+		return path, nil
+	}
+	configFile, err := getConfigFilePath()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(filepath.Dir(configFile), path), nil
+}
+
+func cutHomePrefix(path string) (string, bool) {
+	if path == "~" {
+		return "", true
+	}
+	if len(path) > 1 && path[0] == '~' && os.IsPathSeparator(path[1]) {
+		return path[2:], true
+	}
+	return "", false
+}
@@ -0,0 +1,69 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestLoadConfig(t *testing.T) {
+	newTestHelper(t)
+
+	cfg, err := loadConfig()
+	if err != nil {
+		t.Fatalf("loadConfig() without a file failed: %v", err)
+	}
+	if len(cfg.Sources) != 0 {
+		t.Errorf("Expected no sources, got %v", cfg.Sources)
+	}
+
+	path, err := getConfigFilePath()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(path, []byte(`{"sources":[{"name":"team","path":"team.json"}]}`), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	cfg, err = loadConfig()
+	if err != nil {
+		t.Fatalf("loadConfig() failed: %v", err)
+	}
+	if len(cfg.Sources) != 1 || cfg.Sources[0].Name != "team" {
+		t.Errorf("Expected the team source, got %v", cfg.Sources)
+	}
+
+	if err := os.WriteFile(path, []byte(`{`), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := loadConfig(); err == nil {
+		t.Error("Expected an error for a malformed config file")
+	}
+}
+
+func TestResolvePath(t *testing.T) {
+	th := newTestHelper(t)
+	home, err := os.UserHomeDir()
+	if err != nil {
+		t.Skip("no home directory")
+	}
+	abs := filepath.Join(th.testConfigDir, "abs.json")
+
+	tests := []struct {
+		path string
+		want string
+	}{
+		{path: abs, want: abs},
+		{path: "~/events.json", want: filepath.Join(home, "events.json")},
+		{path: "team.json", want: filepath.Join(th.testConfigDir, appName, "team.json")},
+	}
+	for _, tt := range tests {
+		got, err := resolvePath(tt.path)
+		if err != nil {
+			t.Errorf("resolvePath(%q) failed: %v", tt.path, err)
+			continue
+		}
+		if got != tt.want {
+			t.Errorf("resolvePath(%q) = %s, want %s", tt.path, got, tt.want)
+		}
+	}
+}
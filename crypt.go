@@ -0,0 +1,426 @@
+package main
+
+import (
+	"bytes"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/pbkdf2"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"sync"
+
+	"github.com/charmbracelet/x/term"
+)
+
+const (
+	passphraseEnv   = "COUNTDOWN_PASSPHRASE"
+	keyFileEnv      = "COUNTDOWN_KEY_FILE"
+	encryptedFormat = "countdown-encrypted"
+	kdfPBKDF2SHA256 = "pbkdf2-sha256"
+	saltSize        = 16
+	keySize         = 32
+)
+
+// pbkdf2Iterations is the work factor for new files. It is stored in each
+// file, so changing it doesn't affect reading older ones. Tests lower it.
+var pbkdf2Iterations = 600_000
+
+var (
+	errPassphraseRequired = fmt.Errorf("events are encrypted: set %s or %s", passphraseEnv, keyFileEnv)
+	errWrongPassphrase    = errors.New("wrong passphrase or damaged encrypted file")
+)
+
+// envelope is the on-disk form of an encrypted file: AES-256-GCM with a key
+// derived from the passphrase by PBKDF2. Byte slices are base64 in JSON.
+type envelope struct {
+	Format     string `json:"format"`
+	Version    int    `json:"version"`
+	KDF        string `json:"kdf"`
+	Iterations int    `json:"iterations"`
+	Salt       []byte `json:"salt"`
+	Nonce      []byte `json:"nonce"`
+	Data       []byte `json:"data"`
+}
+
+// keyring holds the storage passphrase and caches derived keys, since key
+// derivation is deliberately slow and every save would otherwise pay for it.
+type keyring struct {
+	mu sync.Mutex
+	// encrypt makes writes produce encrypted files. Reads detect the
+	// format on their own.
+	encrypt    bool
+	passphrase string
+	// lookup finds the passphrase the first time a key is needed, so
+	// commands that never touch encrypted data don't pay for it.
+	lookup func() (string, error)
+	// prompt asks for the passphrase when lookup finds none. It is nil
+	// when nothing may block on the terminal.
+	prompt func() (string, error)
+	salt   []byte
+	keys   map[string][]byte
+}
+
+// secrets is the keyring used by every read and write of events files and
+// the history log.
+var secrets = &keyring{}
+
+// isEncrypted reports whether data is an encrypted envelope rather than
+// plain JSON.
+func isEncrypted(data []byte) bool {
+	data = bytes.TrimSpace(data)
+	if len(data) == 0 || data[0] != '{' {
+		return false
+	}
+	var probe struct {
+		Format string `json:"format"`
+	}
+	return json.Unmarshal(data, &probe) == nil && probe.Format == encryptedFormat
+}
+
+// unlock finds the passphrase with lookup, then prompt. Either is tried
+// at most once.
+func (k *keyring) unlock() error {
+	lookup, prompt := k.lookup, k.prompt
+	k.lookup, k.prompt = nil, nil
+	var err error
+	if lookup != nil {
+		if k.passphrase, err = lookup(); err != nil {
+			return err
+		}
+	}
+	if k.passphrase == "" && prompt != nil {
+		if k.passphrase, err = prompt(); err != nil {
+			return err
+		}
+	}
+	if k.passphrase == "" {
+		return errPassphraseRequired
+	}
+	return nil
+}
+
+func (k *keyring) key(salt []byte, iterations int) ([]byte, error) {
+	if k.passphrase == "" {
+		if err := k.unlock(); err != nil {
+			return nil, err
+		}
+	}
+	if key, ok := k.keys[string(salt)]; ok {
+		return key, nil
+	}
+	key, err := pbkdf2.Key(sha256.New, k.passphrase, salt, iterations, keySize)
+	if err != nil {
+		return nil, err
+	}
+	if k.keys == nil {
+		k.keys = make(map[string][]byte)
+	}
+	k.keys[string(salt)] = key
+	return key, nil
+}
+
+func newGCM(key []byte) (cipher.AEAD, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
+
+// seal encrypts plain if encryption is on and returns it unchanged if not.
+func (k *keyring) seal(plain []byte) ([]byte, error) {
+	k.mu.Lock()
+	defer k.mu.Unlock()
+	if !k.encrypt {
+		return plain, nil
+	}
+	if k.salt == nil {
+		k.salt = make([]byte, saltSize)
+		if _, err := rand.Read(k.salt); err != nil {
+			return nil, err
+		}
+	}
+	key, err := k.key(k.salt, pbkdf2Iterations)
+	if err != nil {
+		return nil, err
+	}
+	gcm, err := newGCM(key)
+	if err != nil {
+		return nil, err
+	}
+	env := envelope{
+		Format:     encryptedFormat,
+		Version:    1,
+		KDF:        kdfPBKDF2SHA256,
+		Iterations: pbkdf2Iterations,
+		Salt:       k.salt,
+		Nonce:      make([]byte, gcm.NonceSize()),
+	}
+	if _, err := rand.Read(env.Nonce); err != nil {
+		return nil, err
+	}
+	env.Data = gcm.Seal(nil, env.Nonce, plain, []byte(encryptedFormat))
+	return json.Marshal(env)
+}
+
+// open decrypts data if it is an encrypted envelope and returns it
+// unchanged otherwise.
+func (k *keyring) open(data []byte) ([]byte, error) {
+	if !isEncrypted(data) {
+		return data, nil
+	}
+	var env envelope
+	if err := json.Unmarshal(data, &env); err != nil {
+		return nil, err
+	}
+	if env.Version != 1 || env.KDF != kdfPBKDF2SHA256 {
+		return nil, fmt.Errorf("unsupported encryption version %d (%s)", env.Version, env.KDF)
+	}
+	k.mu.Lock()
+	defer k.mu.Unlock()
+	key, err := k.key(env.Salt, env.Iterations)
+	if err != nil {
+		return nil, err
+	}
+	gcm, err := newGCM(key)
+	if err != nil {
+		return nil, err
+	}
+	if len(env.Nonce) != gcm.NonceSize() {
+		return nil, errWrongPassphrase
+	}
+	plain, err := gcm.Open(nil, env.Nonce, env.Data, []byte(encryptedFormat))
+	if err != nil {
+		return nil, errWrongPassphrase
+	}
+	// Reuse the salt for new writes so the cached key stays valid.
+	if k.salt == nil {
+		k.salt = env.Salt
+	}
+	return plain, nil
+}
+
+// encodeEvents returns the on-disk form of events.
+func encodeEvents(events []Event) ([]byte, error) {
+	data, err := json.MarshalIndent(events, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+	return secrets.seal(data)
+}
+
+// decodeEvents parses an events file in either format. Errors from a bad
+// passphrase are returned as is; JSON errors are left for callers to wrap.
+func decodeEvents(data []byte) ([]Event, error) {
+	plain, err := secrets.open(data)
+	if err != nil {
+		return nil, err
+	}
+	var events []Event
+	if err := json.Unmarshal(plain, &events); err != nil {
+		return nil, err
+	}
+	return events, nil
+}
+
+// lookupPassphrase returns the passphrase from the environment or a key
+// file, or "" if neither is set.
+func lookupPassphrase(cfg config) (string, error) {
+	if p := os.Getenv(passphraseEnv); p != "" {
+		return p, nil
+	}
+	keyFile := os.Getenv(keyFileEnv)
+	if keyFile == "" {
+		keyFile = cfg.KeyFile
+	}
+	if keyFile == "" {
+		return "", nil
+	}
+	path, err := resolvePath(keyFile)
+	if err != nil {
+		return "", err
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return "", fmt.Errorf("failed to read key file: %w", err)
+	}
+	return strings.TrimRight(string(data), "\r\n"), nil
+}
+
+// readPassphrase prompts for a passphrase on the terminal without echo.
+func readPassphrase(in *os.File, out io.Writer, prompt string) (string, error) {
+	fmt.Fprint(out, prompt)
+	p, err := term.ReadPassword(in.Fd())
+	fmt.Fprintln(out)
+	if err != nil {
+		return "", err
+	}
+	return string(p), nil
+}
+
+// configureStorage sets up the keyring from config.json. The passphrase
+// is only looked up, or asked for on the terminal if prompt is set, once an
+// encrypted file is read or written.
+func configureStorage(in *os.File, out io.Writer, prompt bool) error {
+	cfg, err := loadConfig()
+	if err != nil {
+		return err
+	}
+	secrets.encrypt = cfg.Encrypt
+	secrets.lookup = func() (string, error) { return lookupPassphrase(cfg) }
+	secrets.prompt = nil
+	if prompt && term.IsTerminal(in.Fd()) {
+		secrets.prompt = func() (string, error) { return readPassphrase(in, out, "Passphrase: ") }
+	}
+	return nil
+}
+
+// runSetEncryption turns storage encryption on or off and rewrites every
+// profile, backup and history entry in the new format.
+func runSetEncryption(c *cli, args []string, encrypt bool) error {
+	name := "decrypt"
+	if encrypt {
+		name = "encrypt"
+	}
+	fs := c.newFlagSet(name)
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if fs.NArg() > 0 {
+		return errUsage
+	}
+	if encrypt && secrets.passphrase == "" && secrets.lookup != nil {
+		p, err := secrets.lookup()
+		if err != nil {
+			return err
+		}
+		secrets.passphrase = p
+	}
+	if encrypt && secrets.passphrase == "" {
+		f, ok := c.stdin.(*os.File)
+		if !ok || !term.IsTerminal(f.Fd()) {
+			return errPassphraseRequired
+		}
+		p, err := readPassphrase(f, c.stderr, "New passphrase: ")
+		if err != nil {
+			return err
+		}
+		confirm, err := readPassphrase(f, c.stderr, "Confirm passphrase: ")
+		if err != nil {
+			return err
+		}
+		if p == "" || p != confirm {
+			return errors.New("passphrases are empty or don't match")
+		}
+		secrets.passphrase = p
+	}
+	if err := rewriteStorage(encrypt); err != nil {
+		return err
+	}
+	if encrypt {
+		fmt.Fprintln(c.stdout, "Events, backups and history are now encrypted.")
+	} else {
+		fmt.Fprintln(c.stdout, "Events, backups and history are now stored as plain JSON.")
+	}
+	return nil
+}
+
+// rewriteStorage reads every profile, backup, history entry and the CalDAV
+// sync state and writes them back with encryption set as requested.
+// Backups that can't be read are left as they are.
+func rewriteStorage(encrypt bool) error {
+	type eventsFile struct {
+		path   string
+		events []Event
+	}
+	var files []eventsFile
+	profiles, err := listProfiles()
+	if err != nil {
+		return err
+	}
+	for _, profile := range profiles {
+		path, err := getProfileFilePath(profile)
+		if err != nil {
+			return err
+		}
+		events, err := readProfileEvents(profile)
+		if err != nil {
+			return err
+		}
+		files = append(files, eventsFile{path, events})
+		backups, err := listBackups(profile)
+		if err != nil {
+			return err
+		}
+		for _, backup := range backups {
+			data, err := os.ReadFile(backup)
+			if err != nil {
+				continue
+			}
+			if events, err := decodeEvents(data); err == nil {
+				files = append(files, eventsFile{backup, events})
+			}
+		}
+	}
+	entries, err := readHistory()
+	if err != nil {
+		return err
+	}
+	statePath, err := getCalDAVStatePath()
+	if err != nil {
+		return err
+	}
+	state, err := os.ReadFile(statePath)
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
+		return err
+	}
+	if state != nil {
+		if state, err = secrets.open(state); err != nil {
+			return err
+		}
+	}
+
+	secrets.encrypt = encrypt
+	for _, f := range files {
+		if err := writeEventsFile(f.path, f.events); err != nil {
+			return err
+		}
+	}
+	if len(entries) > 0 {
+		var log bytes.Buffer
+		for _, entry := range entries {
+			line, err := encodeHistoryEntry(entry)
+			if err != nil {
+				return err
+			}
+			log.Write(line)
+		}
+		path, err := getHistoryFilePath()
+		if err != nil {
+			return err
+		}
+		if err := os.WriteFile(path, log.Bytes(), 0o644); err != nil {
+			return err
+		}
+	}
+	if state != nil {
+		if state, err = secrets.seal(state); err != nil {
+			return err
+		}
+		if err := os.WriteFile(statePath, state, 0o600); err != nil {
+			return err
+		}
+	}
+	cfg, err := loadConfig()
+	if err != nil {
+		return err
+	}
+	cfg.Encrypt = encrypt
+	return saveConfig(cfg)
+}
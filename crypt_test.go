@@ -0,0 +1,244 @@
+package main
+
+import (
+	"bytes"
+	"errors"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+func init() {
+	// Keep key derivation fast; the iteration count is stored per file.
+	pbkdf2Iterations = 1000
+}
+
+func TestKeyringRoundTrip(t *testing.T) {
+	k := &keyring{encrypt: true, passphrase: "correct horse"}
+	plain := []byte(`[{"name":"Embargo","ts":1}]`)
+
+	sealed, err := k.seal(plain)
+	if err != nil {
+		t.Fatalf("seal() failed: %v", err)
+	}
+	if !isEncrypted(sealed) {
+		t.Fatal("Expected sealed data to be detected as encrypted")
+	}
+	if bytes.Contains(sealed, []byte("Embargo")) {
+		t.Error("Sealed data contains the plaintext")
+	}
+
+	got, err := (&keyring{passphrase: "correct horse"}).open(sealed)
+	if err != nil {
+		t.Fatalf("open() failed: %v", err)
+	}
+	if !bytes.Equal(got, plain) {
+		t.Errorf("open() = %s, want %s", got, plain)
+	}
+
+	if _, err := (&keyring{passphrase: "wrong"}).open(sealed); !errors.Is(err, errWrongPassphrase) {
+		t.Errorf("Expected errWrongPassphrase, got %v", err)
+	}
+	if _, err := (&keyring{}).open(sealed); !errors.Is(err, errPassphraseRequired) {
+		t.Errorf("Expected errPassphraseRequired, got %v", err)
+	}
+
+	// Plain JSON passes through untouched in both directions.
+	if got, err := (&keyring{}).open(plain); err != nil || !bytes.Equal(got, plain) {
+		t.Errorf("open() of plain JSON = %s, %v", got, err)
+	}
+	if got, err := (&keyring{passphrase: "x"}).seal(plain); err != nil || !bytes.Equal(got, plain) {
+		t.Errorf("seal() with encryption off = %s, %v", got, err)
+	}
+}
+
+func TestEncryptedStorage(t *testing.T) {
+	newTestHelper(t)
+	secrets.encrypt = true
+	secrets.passphrase = "s3cret"
+	event := Event{Name: "Acquisition", Time: time.Now().Add(time.Hour).Unix()}
+
+	if err := saveProfileEvents(defaultProfile, []Event{event}); err != nil {
+		t.Fatalf("saveProfileEvents() failed: %v", err)
+	}
+	if err := appendHistory(defaultProfile, change{Action: actionAdd, After: &event}); err != nil {
+		t.Fatalf("appendHistory() failed: %v", err)
+	}
+	for _, path := range []string{mustProfilePath(t, defaultProfile), mustHistoryPath(t)} {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if bytes.Contains(data, []byte("Acquisition")) {
+			t.Errorf("%s contains the event name in plaintext", filepath.Base(path))
+		}
+	}
+
+	// A fresh keyring with the same passphrase, as on the next start.
+	secrets = &keyring{passphrase: "s3cret"}
+	events, err := readEventsFile()
+	if err != nil {
+		t.Fatalf("readEventsFile() failed: %v", err)
+	}
+	if len(events) != 1 || !reflect.DeepEqual(events[0], event) {
+		t.Errorf("Expected the encrypted event, got %v", events)
+	}
+	entries, err := readHistory()
+	if err != nil || len(entries) != 1 {
+		t.Errorf("Expected 1 history entry, got %v (err %v)", entries, err)
+	}
+
+	secrets = &keyring{}
+	if _, err := readEventsFile(); !errors.Is(err, errPassphraseRequired) {
+		t.Errorf("Expected errPassphraseRequired without a passphrase, got %v", err)
+	}
+}
+
+func TestKeyringUnlocksLazily(t *testing.T) {
+	newTestHelper(t)
+	var lookups, prompts int
+	lazy := func(passphrase string, prompt bool) *keyring {
+		k := &keyring{lookup: func() (string, error) { lookups++; return passphrase, nil }}
+		if prompt {
+			k.prompt = func() (string, error) { prompts++; return "s3cret", nil }
+		}
+		return k
+	}
+	event := Event{Name: "Acquisition", Time: time.Now().Add(time.Hour).Unix()}
+
+	// Plain files never need the passphrase.
+	secrets = lazy("", true)
+	if err := saveProfileEvents(defaultProfile, []Event{event}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := readEventsFile(); err != nil || lookups+prompts != 0 {
+		t.Fatalf("Expected no lookup for plain events, got %d lookups, %d prompts (err %v)", lookups, prompts, err)
+	}
+
+	secrets = lazy("s3cret", true)
+	secrets.encrypt = true
+	if err := saveProfileEvents(defaultProfile, []Event{event}); err != nil {
+		t.Fatal(err)
+	}
+	if lookups != 1 || prompts != 0 {
+		t.Errorf("Expected one lookup and no prompt, got %d lookups, %d prompts", lookups, prompts)
+	}
+
+	// Without a passphrase from the environment it is asked for once.
+	secrets = lazy("", true)
+	for range 2 {
+		if _, err := readEventsFile(); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if lookups != 2 || prompts != 1 {
+		t.Errorf("Expected the prompt once, got %d lookups, %d prompts", lookups, prompts)
+	}
+
+	// Quiet commands fail instead of prompting.
+	secrets = lazy("", false)
+	if _, err := readEventsFile(); !errors.Is(err, errPassphraseRequired) {
+		t.Errorf("Expected errPassphraseRequired without a prompt, got %v", err)
+	}
+	for _, name := range []string{"status", "bar", "completion"} {
+		if cmd, ok := findCommand(name); !ok || !cmd.quiet {
+			t.Errorf("Expected %s to never prompt", name)
+		}
+	}
+}
+
+func TestEncryptCommand(t *testing.T) {
+	newTestHelper(t)
+	event := Event{Name: "Launch", Time: time.Now().Add(time.Hour).Unix()}
+	if err := saveProfileEvents("work", []Event{event}); err != nil {
+		t.Fatal(err)
+	}
+	if err := saveProfileEvents("work", []Event{event}); err != nil {
+		t.Fatal(err)
+	}
+	if err := appendHistory("work", change{Action: actionAdd, After: &event}); err != nil {
+		t.Fatal(err)
+	}
+
+	var out bytes.Buffer
+	c := &cli{profile: defaultProfile, stdin: strings.NewReader(""), stdout: &out, stderr: &out}
+	if err := c.run([]string{"encrypt"}); !errors.Is(err, errPassphraseRequired) {
+		t.Fatalf("Expected encrypt without a passphrase to fail, got %v", err)
+	}
+
+	secrets.passphrase = "s3cret"
+	if err := c.run([]string{"encrypt"}); err != nil {
+		t.Fatalf("encrypt failed: %v", err)
+	}
+	backups, err := listBackups("work")
+	if err != nil || len(backups) != 1 {
+		t.Fatalf("Expected 1 backup, got %v (err %v)", backups, err)
+	}
+	for _, path := range []string{mustProfilePath(t, "work"), backups[0], mustHistoryPath(t)} {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if bytes.Contains(data, []byte("Launch")) {
+			t.Errorf("%s still contains plaintext after encrypt", path)
+		}
+	}
+	cfg, err := loadConfig()
+	if err != nil || !cfg.Encrypt {
+		t.Errorf("Expected encrypt to be recorded in config.json, got %+v (err %v)", cfg, err)
+	}
+
+	if err := c.run([]string{"decrypt"}); err != nil {
+		t.Fatalf("decrypt failed: %v", err)
+	}
+	data, err := os.ReadFile(mustProfilePath(t, "work"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Contains(data, []byte("Launch")) {
+		t.Error("Expected plain JSON after decrypt")
+	}
+	if cfg, _ = loadConfig(); cfg.Encrypt {
+		t.Error("Expected decrypt to turn encryption off in config.json")
+	}
+}
+
+func TestLookupPassphrase(t *testing.T) {
+	th := newTestHelper(t)
+	t.Setenv(passphraseEnv, "")
+	t.Setenv(keyFileEnv, "")
+
+	if p, err := lookupPassphrase(config{}); err != nil || p != "" {
+		t.Errorf("Expected no passphrase, got %q (err %v)", p, err)
+	}
+
+	keyFile := filepath.Join(th.testConfigDir, "key")
+	if err := os.WriteFile(keyFile, []byte("from-file\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	if p, err := lookupPassphrase(config{KeyFile: keyFile}); err != nil || p != "from-file" {
+		t.Errorf("Expected the key file passphrase, got %q (err %v)", p, err)
+	}
+
+	t.Setenv(keyFileEnv, filepath.Join(th.testConfigDir, "missing"))
+	if _, err := lookupPassphrase(config{KeyFile: keyFile}); err == nil {
+		t.Error("Expected the environment key file to take precedence and fail")
+	}
+
+	t.Setenv(passphraseEnv, "from-env")
+	if p, err := lookupPassphrase(config{KeyFile: keyFile}); err != nil || p != "from-env" {
+		t.Errorf("Expected the environment passphrase, got %q (err %v)", p, err)
+	}
+}
+
+func mustHistoryPath(t *testing.T) string {
+	t.Helper()
+	path, err := getHistoryFilePath()
+	if err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
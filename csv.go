@@ -0,0 +1,355 @@
+package main
+
+import (
+	"bytes"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// csvFields are the event fields a CSV column can be mapped to, in the
+// order export writes them.
+var csvFields = []string{"id", "name", "date", "time", "zone", "tags", "notes", "yearly"}
+
+// csvHeaders are the header names each field is recognised by when no
+// mapping is given, compared case-insensitively.
+var csvHeaders = map[string][]string{
+	"id":     {"id", "uid"},
+	"name":   {"name", "title", "summary", "event", "task", "milestone"},
+	"date":   {"date", "due", "due date", "deadline", "day", "start", "start date"},
+	"time":   {"time", "start time", "due time"},
+	"zone":   {"zone", "time zone", "timezone", "tz"},
+	"tags":   {"tags", "tag", "labels", "categories"},
+	"notes":  {"notes", "note", "description", "comments"},
+	"yearly": {"yearly"},
+}
+
+// csvDateLayouts are tried in order when detecting the date format of a
+// file. Month-first comes before day-first, so a file is only read as
+// day-first if some date can't be month-first, e.g. 31/01/2026.
+var csvDateLayouts = []string{
+	time.RFC3339,
+	"2006-01-02T15:04",
+	"2006-01-02 15:04:05",
+	"2006-01-02 15:04",
+	"2006-1-2",
+	"2006/1/2",
+	"20060102",
+	"1/2/2006",
+	"2/1/2006",
+	"1/2/06",
+	"2/1/06",
+	"2.1.2006",
+	"2.1.06",
+	"2-Jan-2006",
+	"2 Jan 2006",
+	"2 January 2006",
+	"Jan 2, 2006",
+	"January 2, 2006",
+	"Jan 2 2006",
+	"January 2 2006",
+}
+
+// csvTimeLayouts are tried in order for each time cell. Cells are
+// upper-cased first so "3pm" matches "3PM".
+var csvTimeLayouts = []string{
+	"15:04",
+	"15:04:05",
+	"3:04PM",
+	"3:04 PM",
+	"3:04:05PM",
+	"3:04:05 PM",
+	"3PM",
+	"3 PM",
+}
+
+var utcOffset = regexp.MustCompile(`^([+-])(\d{2}):?(\d{2})$`)
+
+// parseZone parses an IANA time zone name or a UTC offset such as +05:30.
+func parseZone(s string) (*time.Location, error) {
+	if m := utcOffset.FindStringSubmatch(s); m != nil {
+		hours, _ := strconv.Atoi(m[2])
+		minutes, _ := strconv.Atoi(m[3])
+		offset := hours*secondsPerHour + minutes*secondsPerMinute
+		if m[1] == "-" {
+			offset = -offset
+		}
+		return time.FixedZone(s, offset), nil
+	}
+	if s == "Z" {
+		return time.UTC, nil
+	}
+	loc, err := time.LoadLocation(s)
+	if err != nil {
+		return nil, fmt.Errorf("unknown time zone %q", s)
+	}
+	return loc, nil
+}
+
+// csvDelimiter guesses the delimiter from the header line, since
+// spreadsheets in many locales export with semicolons.
+func csvDelimiter(data []byte) rune {
+	header, _, _ := bytes.Cut(data, []byte("\n"))
+	best, count := ',', bytes.Count(header, []byte(","))
+	for _, d := range []rune{';', '\t'} {
+		if n := bytes.Count(header, []byte(string(d))); n > count {
+			best, count = d, n
+		}
+	}
+	return best
+}
+
+// looksLikeCSV reports whether data starts with a header row naming a
+// name and a date column.
+func looksLikeCSV(data []byte) bool {
+	data = bytes.TrimPrefix(data, []byte("\uFEFF"))
+	r := csv.NewReader(bytes.NewReader(data))
+	r.Comma = csvDelimiter(data)
+	r.FieldsPerRecord = -1
+	header, err := r.Read()
+	if err != nil {
+		return false
+	}
+	_, err = csvColumns(header, nil)
+	return err == nil
+}
+
+// csvColumns returns the index of the column for each field, or -1 for
+// fields the file doesn't have.
+func csvColumns(header []string, mapping map[string]string) (map[string]int, error) {
+	index := make(map[string]int, len(header))
+	for i, h := range header {
+		h = strings.ToLower(strings.TrimSpace(h))
+		if _, dup := index[h]; !dup {
+			index[h] = i
+		}
+	}
+	columns := make(map[string]int, len(csvFields))
+	for _, field := range csvFields {
+		columns[field] = -1
+		if column, ok := mapping[field]; ok {
+			if n, err := strconv.Atoi(column); err == nil && n >= 1 && n <= len(header) {
+				columns[field] = n - 1
+				continue
+			}
+			i, ok := index[strings.ToLower(column)]
+			if !ok {
+				return nil, fmt.Errorf("no column %q for %s", column, field)
+			}
+			columns[field] = i
+			continue
+		}
+		for _, h := range csvHeaders[field] {
+			if i, ok := index[h]; ok {
+				columns[field] = i
+				break
+			}
+		}
+	}
+	for _, field := range []string{"name", "date"} {
+		if columns[field] < 0 {
+			return nil, fmt.Errorf("no %s column, map one with --map %s=COLUMN", field, field)
+		}
+	}
+	return columns, nil
+}
+
+// detectDateLayout returns the first layout that parses every date.
+func detectDateLayout(dates []string) (string, error) {
+	for _, layout := range csvDateLayouts {
+		ok := true
+		for _, d := range dates {
+			if _, err := time.Parse(layout, d); err != nil {
+				ok = false
+				break
+			}
+		}
+		if ok {
+			return layout, nil
+		}
+	}
+	return "", errors.New("unrecognised date format, set one with --date-format")
+}
+
+func parseCSVTime(s string) (time.Time, error) {
+	s = strings.ToUpper(s)
+	for _, layout := range csvTimeLayouts {
+		if t, err := time.Parse(layout, s); err == nil {
+			return t, nil
+		}
+	}
+	return time.Time{}, fmt.Errorf("unrecognised time %q", s)
+}
+
+// splitTags splits a tags cell on semicolons, or on commas if it has no
+// semicolons.
+func splitTags(s string) []string {
+	sep := ","
+	if strings.Contains(s, ";") {
+		sep = ";"
+	}
+	var tags []string
+	for _, tag := range strings.Split(s, sep) {
+		if tag = strings.TrimSpace(tag); tag != "" {
+			tags = append(tags, tag)
+		}
+	}
+	return tags
+}
+
+// joinTags is the inverse of splitTags. A lone tag containing a comma gets
+// a trailing semicolon so it isn't split on the way back in.
+func joinTags(tags []string) string {
+	s := strings.Join(tags, ";")
+	if len(tags) == 1 && strings.Contains(s, ",") {
+		s += ";"
+	}
+	return s
+}
+
+// csvRow is a record with the line it started on, for error messages.
+type csvRow struct {
+	line   int
+	fields []string
+}
+
+func (r csvRow) cell(column int) string {
+	if column < 0 || column >= len(r.fields) {
+		return ""
+	}
+	return strings.TrimSpace(r.fields[column])
+}
+
+// parseCSV reads events from a CSV file with a header row. Columns are
+// found by header name or opts.columns; only name and date are required.
+func parseCSV(data []byte, opts importOptions) ([]Event, error) {
+	data = bytes.TrimPrefix(data, []byte("\uFEFF"))
+	r := csv.NewReader(bytes.NewReader(data))
+	r.Comma = csvDelimiter(data)
+	r.FieldsPerRecord = -1
+	header, err := r.Read()
+	if errors.Is(err, io.EOF) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	columns, err := csvColumns(header, opts.columns)
+	if err != nil {
+		return nil, err
+	}
+
+	var rows []csvRow
+	var dates []string
+	for {
+		fields, err := r.Read()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+		line, _ := r.FieldPos(0)
+		row := csvRow{line: line, fields: fields}
+		if strings.TrimSpace(strings.Join(fields, "")) == "" {
+			continue
+		}
+		rows = append(rows, row)
+		if d := row.cell(columns["date"]); d != "" {
+			dates = append(dates, d)
+		}
+	}
+	layout := opts.dateLayout
+	if layout == "" {
+		if layout, err = detectDateLayout(dates); err != nil {
+			return nil, err
+		}
+	}
+
+	events := make([]Event, 0, len(rows))
+	for _, row := range rows {
+		event, err := row.event(columns, layout, opts.zone)
+		if err != nil {
+			return nil, fmt.Errorf("line %d: %w", row.line, err)
+		}
+		events = append(events, event)
+	}
+	return events, nil
+}
+
+// event converts a row to an event. A date with its own offset, such as
+// an RFC 3339 timestamp, ignores the zone column.
+func (r csvRow) event(columns map[string]int, layout string, zone *time.Location) (Event, error) {
+	name := r.cell(columns["name"])
+	if name == "" {
+		return Event{}, errors.New("empty name")
+	}
+	loc := zone
+	if loc == nil {
+		loc = time.Local
+	}
+	if z := r.cell(columns["zone"]); z != "" {
+		var err error
+		if loc, err = parseZone(z); err != nil {
+			return Event{}, err
+		}
+	}
+	date, err := time.ParseInLocation(layout, r.cell(columns["date"]), loc)
+	if err != nil {
+		return Event{}, fmt.Errorf("unrecognised date %q", r.cell(columns["date"]))
+	}
+	if t := r.cell(columns["time"]); t != "" {
+		clock, err := parseCSVTime(t)
+		if err != nil {
+			return Event{}, err
+		}
+		date = time.Date(date.Year(), date.Month(), date.Day(),
+			clock.Hour(), clock.Minute(), clock.Second(), 0, date.Location())
+	}
+	yearly := r.cell(columns["yearly"])
+	if yearly != "" {
+		if _, err := parseYearly(yearly); err != nil {
+			return Event{}, err
+		}
+	}
+	return Event{
+		ID:     r.cell(columns["id"]),
+		Name:   name,
+		Time:   date.Unix(),
+		Yearly: yearly,
+		Tags:   splitTags(r.cell(columns["tags"])),
+		Notes:  r.cell(columns["notes"]),
+	}, nil
+}
+
+// writeCSV writes events in the layout parseCSV reads back: local dates and
+// times with the UTC offset in effect at each event.
+func writeCSV(w io.Writer, events []Event) error {
+	cw := csv.NewWriter(w)
+	if err := cw.Write(csvFields); err != nil {
+		return err
+	}
+	for _, e := range events {
+		t := time.Unix(e.Time, 0)
+		err := cw.Write([]string{
+			e.key(),
+			e.Name,
+			t.Format("2006-01-02"),
+			t.Format("15:04:05"),
+			t.Format("-07:00"),
+			joinTags(e.Tags),
+			e.Notes,
+			e.Yearly,
+		})
+		if err != nil {
+			return err
+		}
+	}
+	cw.Flush()
+	return cw.Error()
+}
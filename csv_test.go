@@ -0,0 +1,123 @@
+package main
+
+import (
+	"bytes"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestParseCSV(t *testing.T) {
+	berlin, err := time.LoadLocation("Europe/Berlin")
+	if err != nil {
+		t.Skip("time zone database unavailable")
+	}
+
+	tests := []struct {
+		name string
+		csv  string
+		opts importOptions
+		want []Event
+	}{
+		{
+			name: "Known headers",
+			csv: "Title,Due,Time,Time Zone,Labels,Description\n" +
+				"Freeze,2026-11-02,17:30,Europe/Berlin,release;q4,\"Branch cut,\nno merges\"\n",
+			want: []Event{{
+				Name:  "Freeze",
+				Time:  time.Date(2026, 11, 2, 17, 30, 0, 0, berlin).Unix(),
+				Tags:  []string{"release", "q4"},
+				Notes: "Branch cut,\nno merges",
+			}},
+		},
+		{
+			name: "Mapped columns by header and index",
+			csv:  "Milestone,Owner,When\nBeta,ann,2026-12-01\n",
+			opts: importOptions{columns: map[string]string{"name": "milestone", "date": "3"}, zone: time.UTC},
+			want: []Event{{Name: "Beta", Time: time.Date(2026, 12, 1, 0, 0, 0, 0, time.UTC).Unix()}},
+		},
+		{
+			name: "Day-first dates detected from an unambiguous row",
+			csv:  "name;date;time\nA;02/03/2027;3pm\nB;31/03/2027;\n",
+			opts: importOptions{zone: time.UTC},
+			want: []Event{
+				{Name: "A", Time: time.Date(2027, 3, 2, 15, 0, 0, 0, time.UTC).Unix()},
+				{Name: "B", Time: time.Date(2027, 3, 31, 0, 0, 0, 0, time.UTC).Unix()},
+			},
+		},
+		{
+			name: "Month-first dates when ambiguous",
+			csv:  "name,date\nA,02/03/2027\n",
+			opts: importOptions{zone: time.UTC},
+			want: []Event{{Name: "A", Time: time.Date(2027, 2, 3, 0, 0, 0, 0, time.UTC).Unix()}},
+		},
+		{
+			name: "Forced layout",
+			csv:  "name,date\nA,02/03/2027\n",
+			opts: importOptions{dateLayout: "02/01/2006", zone: time.UTC},
+			want: []Event{{Name: "A", Time: time.Date(2027, 3, 2, 0, 0, 0, 0, time.UTC).Unix()}},
+		},
+		{
+			name: "Timestamps with offsets ignore the zone column",
+			csv:  "name,date,zone\nA,2027-01-05T10:00:00+02:00,Asia/Tokyo\n",
+			want: []Event{{Name: "A", Time: time.Date(2027, 1, 5, 8, 0, 0, 0, time.UTC).Unix()}},
+		},
+		{
+			name: "UTC offset zone and long dates",
+			csv:  "name,date,time,zone\nA,\"March 4, 2027\",09:15,-05:00\n",
+			want: []Event{{Name: "A", Time: time.Date(2027, 3, 4, 14, 15, 0, 0, time.UTC).Unix()}},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := parseCSV([]byte(tt.csv), tt.opts)
+			if err != nil {
+				t.Fatalf("parseCSV() error = %v", err)
+			}
+			if !equalEvents(got, tt.want) {
+				t.Errorf("parseCSV() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseCSVErrors(t *testing.T) {
+	tests := []struct {
+		name, csv, want string
+		opts            importOptions
+	}{
+		{name: "No date column", csv: "name,owner\nA,ann\n", want: "no date column"},
+		{name: "Unknown mapped column", csv: "name,date\n", opts: importOptions{columns: map[string]string{"date": "Due"}}, want: `no column "Due"`},
+		{name: "Bad date", csv: "name,date\nA,2026-01-01\nB,soon\n", want: "unrecognised date format"},
+		{name: "Bad time reports its line", csv: "name,date,time\nA,2026-01-01,09:00\nB,2026-01-02,noonish\n", want: "line 3: unrecognised time"},
+		{name: "Bad zone", csv: "name,date,zone\nA,2026-01-01,Mars/Olympus\n", want: "unknown time zone"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := parseCSV([]byte(tt.csv), tt.opts)
+			if err == nil || !strings.Contains(err.Error(), tt.want) {
+				t.Errorf("parseCSV() error = %v, want it to contain %q", err, tt.want)
+			}
+		})
+	}
+}
+
+func TestCSVRoundTrip(t *testing.T) {
+	events := []Event{
+		{ID: "a", Name: "Launch, finally", Time: 1_800_000_000, Tags: []string{"release", "q3"}, Notes: "line 1\nline 2"},
+		{ID: "b", Name: "Retro", Time: 1_800_090_000, Tags: []string{"ceremony, team"}},
+		{ID: "c", Name: "Plain", Time: 1_900_000_000},
+	}
+	var buf bytes.Buffer
+	if err := writeCSV(&buf, events); err != nil {
+		t.Fatal(err)
+	}
+	got, err := parseCSV(buf.Bytes(), importOptions{})
+	if err != nil {
+		t.Fatalf("parseCSV() error = %v\n%s", err, buf.String())
+	}
+	if !reflect.DeepEqual(got, events) {
+		t.Errorf("Round trip = %+v, want %+v", got, events)
+	}
+}
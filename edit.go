@@ -0,0 +1,173 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"reflect"
+	"strings"
+	"time"
+)
+
+const editHeader = `# The events of profile %q. Change, add or delete [[event]] tables, then
+# save and quit to apply. Quit without saving, or delete every event, to
+# change nothing. Times are RFC 3339, e.g. 2026-11-10T09:00:00+01:00, and
+# new events need no id.
+
+`
+
+// editorCommand returns the user's editor and its arguments.
+func editorCommand() []string {
+	for _, name := range []string{"VISUAL", "EDITOR"} {
+		if fields := strings.Fields(os.Getenv(name)); len(fields) > 0 {
+			return fields
+		}
+	}
+	return []string{"vi"}
+}
+
+// planEdit works out the changes that turn a profile's events into the
+// edited ones, matching them by key. It returns the changes in the order
+// they are applied and the resulting events.
+func planEdit(current, edited []Event, now time.Time) ([]change, []Event, error) {
+	byKey := make(map[string]Event, len(current))
+	for _, e := range current {
+		byKey[e.key()] = e
+	}
+	var edits, adds []change
+	kept, ids := make(map[string]bool), make(map[string]bool)
+	for _, e := range edited {
+		if len(e.Tags) == 0 {
+			e.Tags = nil
+		}
+		if e.ID != "" && ids[e.ID] {
+			return nil, nil, fmt.Errorf("id %q is used by more than one event", e.ID)
+		}
+		ids[e.ID] = true
+		before, ok := byKey[e.ID]
+		if e.Yearly != "" && (!ok || e.Yearly != before.Yearly) {
+			// A new yearly date moves the event to its next occurrence.
+			e = e.occurrence(now)
+		}
+		if !ok {
+			if e.ID == "" {
+				e.ID = newEventID()
+			}
+			adds = append(adds, change{Action: actionAdd, After: &e})
+			continue
+		}
+		kept[e.ID] = true
+		e.ID = before.ID
+		if reflect.DeepEqual(e, before) {
+			continue
+		}
+		e.ID = before.key()
+		edits = append(edits, change{Action: actionEdit, Before: &before, After: &e})
+	}
+	var changes []change
+	for _, e := range current {
+		if !kept[e.key()] {
+			changes = append(changes, change{Action: actionRemove, Before: &e})
+		}
+	}
+	changes = append(append(changes, edits...), adds...)
+	events := current
+	for _, c := range changes {
+		var err error
+		if events, err = c.apply(events); err != nil {
+			return nil, nil, err
+		}
+	}
+	return changes, events, nil
+}
+
+// runEdit opens every event of the profile in the user's editor as TOML
+// and applies what was saved, offering to edit again while it has errors.
+func runEdit(c *cli, args []string) error {
+	fs := c.newFlagSet("edit")
+	all := fs.Bool("all", false, "edit every event of the profile at once")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if !*all || fs.NArg() > 0 {
+		return errUsage
+	}
+	current, err := readProfileEvents(c.profile)
+	if err != nil {
+		return err
+	}
+	var doc bytes.Buffer
+	fmt.Fprintf(&doc, editHeader, c.profile)
+	listed := make([]Event, len(current))
+	for i, e := range current {
+		e.ID = e.key()
+		listed[i] = e
+	}
+	if err := writeEventsTOML(&doc, listed); err != nil {
+		return err
+	}
+	f, err := os.CreateTemp("", appName+"-*.toml")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(f.Name())
+	_, err = f.Write(doc.Bytes())
+	if closeErr := f.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		return err
+	}
+
+	in := bufio.NewReader(c.stdin)
+	var changes []change
+	var events []Event
+	for {
+		editor := editorCommand()
+		cmd := exec.Command(editor[0], append(editor[1:], f.Name())...)
+		cmd.Stdin, cmd.Stdout, cmd.Stderr = c.stdin, c.stdout, c.stderr
+		if err := cmd.Run(); err != nil {
+			return fmt.Errorf("editor %s failed: %w", editor[0], err)
+		}
+		data, err := os.ReadFile(f.Name())
+		if err != nil {
+			return err
+		}
+		if bytes.Equal(data, doc.Bytes()) {
+			_, err := fmt.Fprintln(c.stdout, "No changes")
+			return err
+		}
+		edited, err := parseEventsTOML(data, time.Local)
+		if err == nil && len(edited) == 0 {
+			_, err := fmt.Fprintln(c.stdout, "No events left, nothing was changed")
+			return err
+		}
+		if err == nil {
+			changes, events, err = planEdit(current, edited, time.Now())
+		}
+		if err == nil {
+			break
+		}
+		fmt.Fprintf(c.stderr, "%s: %v\nEdit again? [Y/n] ", f.Name(), err)
+		answer, readErr := in.ReadString('\n')
+		if answer = strings.ToLower(strings.TrimSpace(answer)); readErr != nil && answer == "" || answer == "n" || answer == "no" {
+			return errors.New("nothing was changed")
+		}
+	}
+
+	if len(changes) == 0 {
+		_, err := fmt.Fprintln(c.stdout, "No changes")
+		return err
+	}
+	if err := (importPlan{changes: changes, events: events}).apply(c.profile); err != nil {
+		return err
+	}
+	for _, ch := range changes {
+		fmt.Fprintln(c.stdout, ch)
+	}
+	_, err = fmt.Fprintf(c.stdout, "%d changes saved to %s\n", len(changes), c.profile)
+	return err
+}
@@ -0,0 +1,130 @@
+package main
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestPlanEdit(t *testing.T) {
+	now := time.Date(2026, 10, 18, 12, 0, 0, 0, time.UTC)
+	legacy := Event{Name: "Old", Time: 1900000000}
+	current := []Event{
+		{ID: "a", Name: "Code freeze", Time: 1900100000},
+		{ID: "b", Name: "Launch", Time: 1900200000, Tags: []string{"release"}},
+		legacy,
+	}
+	edited := []Event{
+		{ID: legacy.key(), Name: "Old", Time: 1900000000, Tags: []string{}},
+		{ID: "b", Name: "Launch v2", Time: 1900200000, Tags: []string{"release"}},
+		{Name: "Ada's birthday", Yearly: "--03-01"},
+	}
+	changes, events, err := planEdit(current, edited, now)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var got []string
+	for _, c := range changes {
+		got = append(got, string(c.Action))
+	}
+	if strings.Join(got, ",") != "remove,edit,add" {
+		t.Fatalf("Expected a removal, an edit and an addition, got %v", changes)
+	}
+	if changes[0].Before.ID != "a" || changes[1].After.Name != "Launch v2" || changes[1].After.ID != "b" {
+		t.Errorf("Expected the freeze removed and the launch renamed, got %v", changes)
+	}
+	birthday := changes[2].After
+	if birthday.ID == "" || time.Unix(birthday.Time, 0).UTC() != time.Date(2027, 3, 1, 0, 0, 0, 0, time.UTC) {
+		t.Errorf("Expected the new yearly event on its next date with an ID, got %+v", birthday)
+	}
+	if len(events) != 3 || events[1].Name != "Old" || events[1].ID != "" {
+		t.Errorf("Expected the untouched legacy event to stay as it was, got %v", events)
+	}
+
+	// A copied table that kept its id can't be told apart from the event.
+	copied := []Event{current[1], {ID: "b", Name: "Launch (copy)", Time: 1900300000}}
+	if _, _, err := planEdit(current, copied, now); err == nil {
+		t.Error("Expected two events with the same id to fail")
+	}
+}
+
+func TestEditCommand(t *testing.T) {
+	newTestHelper(t)
+	if err := saveProfileEvents(defaultProfile, []Event{
+		{ID: "a", Name: "Code freeze", Time: 1900000000},
+		{ID: "b", Name: "Launch", Time: 1900100000},
+	}); err != nil {
+		t.Fatal(err)
+	}
+	// The editor renames the freeze, deletes the launch and adds a demo.
+	dir := t.TempDir()
+	script := filepath.Join(dir, "editor.sh")
+	edited := filepath.Join(dir, "edited.toml")
+	if err := os.WriteFile(script, []byte("#!/bin/sh\ncp \""+edited+"\" \"$1\"\n"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("VISUAL", "")
+	t.Setenv("EDITOR", script)
+	writeEdited := func(doc string) {
+		t.Helper()
+		if err := os.WriteFile(edited, []byte(doc), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// An invalid document is reported with line numbers and left alone
+	// when the user declines to edit again.
+	writeEdited("[[event]]\nid = \"a\"\nname = \"Code freeze\"\ntime = soon\n")
+	var out, errOut bytes.Buffer
+	c := &cli{profile: defaultProfile, stdin: strings.NewReader("n\n"), stdout: &out, stderr: &errOut}
+	if err := c.run([]string{"edit", "--all"}); err == nil {
+		t.Error("Expected a declined fix to fail")
+	}
+	if !strings.Contains(errOut.String(), "line 4: ") || !strings.Contains(errOut.String(), "Edit again?") {
+		t.Errorf("Expected a line-numbered error and a prompt, got %q", errOut.String())
+	}
+
+	// So is a table copied along with its id.
+	writeEdited("[[event]]\nid = \"a\"\nname = \"Code freeze\"\ntime = 2030-03-17T17:46:40Z\n\n" +
+		"[[event]]\nid = \"a\"\nname = \"Code freeze, again\"\ntime = 2030-03-18T17:46:40Z\n")
+	errOut.Reset()
+	c.stdin = strings.NewReader("n\n")
+	if err := c.run([]string{"edit", "--all"}); err == nil {
+		t.Error("Expected a copied id to fail")
+	}
+	if !strings.Contains(errOut.String(), `line 6: id "a" is already used on line 1`) {
+		t.Errorf("Expected the copied id to be reported by line, got %q", errOut.String())
+	}
+
+	writeEdited(`[[event]]
+id = "a"
+name = "Code freeze (extended)"
+time = 2030-03-17T17:46:40Z
+
+[[event]]
+name = "Demo"
+time = 2030-04-01T10:00:00Z
+`)
+	out.Reset()
+	c.stdin = strings.NewReader("")
+	if err := c.run([]string{"edit", "--all"}); err != nil {
+		t.Fatalf("edit --all failed: %v", err)
+	}
+	if !strings.Contains(out.String(), "3 changes saved") {
+		t.Errorf("Expected a summary of the changes, got %q", out.String())
+	}
+	events, err := readProfileEvents(defaultProfile)
+	if err != nil || len(events) != 2 || events[0].Name != "Code freeze (extended)" || events[1].Name != "Demo" {
+		t.Fatalf("Expected the edited events to be saved, got %v (err %v)", events, err)
+	}
+	if entries, _ := readHistory(); len(entries) != 3 {
+		t.Errorf("Expected each change in the history, got %v", entries)
+	}
+
+	if err := c.run([]string{"edit"}); err == nil {
+		t.Error("Expected edit without --all to fail")
+	}
+}
@@ -0,0 +1,71 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"os"
+)
+
+// exportFormat is a file format the events of a profile can be written in.
+type exportFormat struct {
+	name  string
+	usage string
+	write func(w io.Writer, events []Event) error
+}
+
+var exportFormats = []exportFormat{
+	{name: "csv", usage: "write CSV that import --csv reads back", write: writeCSV},
+	{name: "html", usage: "write a self-contained web page with live countdowns", write: writeHTML},
+	{name: "markdown", usage: "write a Markdown table of events, dates, time left and tags", write: writeMarkdown},
+	{name: "org", usage: "write org-mode headlines with DEADLINE timestamps", write: writeOrg},
+}
+
+// runExport writes a profile's own events to a file or stdout, or with
+// --all every event the profile shows, including read-only and generated
+// ones.
+func runExport(c *cli, args []string) error {
+	fs := c.newFlagSet("export")
+	formats := make([]*bool, len(exportFormats))
+	for i, f := range exportFormats {
+		formats[i] = fs.Bool(f.name, false, f.usage)
+	}
+	all := fs.Bool("all", false, "include events from sources, feeds, plugins and providers")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if fs.NArg() > 1 {
+		return errUsage
+	}
+	var format *exportFormat
+	for i, set := range formats {
+		if !*set {
+			continue
+		}
+		if format != nil {
+			return fmt.Errorf("%w: choose one format", errUsage)
+		}
+		format = &exportFormats[i]
+	}
+	if format == nil {
+		return fmt.Errorf("%w: choose a format", errUsage)
+	}
+
+	load := loadOwnEvents
+	if *all {
+		load = loadProfileEvents
+	}
+	events, err := load(c.profile)
+	if err != nil {
+		return err
+	}
+	var buf bytes.Buffer
+	if err := format.write(&buf, events); err != nil {
+		return err
+	}
+	if fs.NArg() == 0 {
+		_, err = c.stdout.Write(buf.Bytes())
+		return err
+	}
+	return os.WriteFile(fs.Arg(0), buf.Bytes(), 0o644)
+}
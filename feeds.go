@@ -0,0 +1,271 @@
+package main
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+const (
+	feedsCacheDirName   = "feeds"
+	defaultFeedInterval = time.Hour
+	// feedCheckInterval is how often the TUI looks for feeds that are due.
+	feedCheckInterval = time.Minute
+	maxFeedSize       = 10 << 20
+)
+
+// readFeedBody reads a response of at most maxFeedSize bytes. A larger
+// one is an error rather than cut short, since part of a calendar may
+// still parse and replace a good cached copy.
+func readFeedBody(r io.Reader) ([]byte, error) {
+	body, err := io.ReadAll(io.LimitReader(r, maxFeedSize+1))
+	if err != nil {
+		return nil, err
+	}
+	if len(body) > maxFeedSize {
+		return nil, fmt.Errorf("response is larger than %d bytes", maxFeedSize)
+	}
+	return body, nil
+}
+
+// feedClient fetches http(s) feeds.
+var feedClient = &http.Client{Timeout: 30 * time.Second}
+
+// feedCache is a feed's last successful response, kept so feeds keep
+// working offline and can be revalidated with an ETag.
+type feedCache struct {
+	URL          string `json:"url"`
+	ETag         string `json:"etag,omitempty"`
+	LastModified string `json:"lastModified,omitempty"`
+	Fetched      int64  `json:"fetched"`
+	Body         []byte `json:"body"`
+}
+
+// isFeed reports whether a source is fetched from a URL or produced by a
+// plugin rather than read from a local path.
+func (s eventSource) isFeed() bool { return s.URL != "" || s.isPlugin() }
+
+// interval returns how long the cached copy of a feed or plugin stays
+// fresh.
+func (s eventSource) interval() time.Duration {
+	if d, err := time.ParseDuration(s.Interval); err == nil && d > 0 {
+		return d
+	}
+	return defaultFeedInterval
+}
+
+// feeds returns every configured feed and plugin, whatever profile it
+// applies to.
+func (c config) feeds() []eventSource {
+	var feeds []eventSource
+	for _, src := range c.Sources {
+		if src.isFeed() {
+			feeds = append(feeds, src)
+		}
+	}
+	return feeds
+}
+
+// getFeedCachePath returns where a feed's cached copy is kept, in the
+// user's cache directory rather than the (possibly synced) config one.
+func getFeedCachePath(feedURL string) (string, error) {
+	cacheDir, err := os.UserCacheDir()
+	if err != nil {
+		return "", fmt.Errorf("failed to get user cache directory: %w", err)
+	}
+	dir := filepath.Join(cacheDir, appName, feedsCacheDirName)
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return "", fmt.Errorf("failed to create feed cache directory: %w", err)
+	}
+	sum := sha256.Sum256([]byte(feedURL))
+	return filepath.Join(dir, hex.EncodeToString(sum[:12])+".json"), nil
+}
+
+// readFeedCache returns a feed's cached copy, or an empty cache if it has
+// never been fetched.
+func readFeedCache(feedURL string) (feedCache, error) {
+	cache := feedCache{URL: feedURL}
+	path, err := getFeedCachePath(feedURL)
+	if err != nil {
+		return cache, err
+	}
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return cache, nil
+	}
+	if err != nil {
+		return cache, err
+	}
+	if err := json.Unmarshal(data, &cache); err != nil {
+		return feedCache{URL: feedURL}, nil
+	}
+	return cache, nil
+}
+
+func writeFeedCache(cache feedCache) error {
+	path, err := getFeedCachePath(cache.URL)
+	if err != nil {
+		return err
+	}
+	data, err := json.Marshal(cache)
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(path, data, 0o644)
+}
+
+// parseFeed decodes a feed body in either the events JSON format or
+// iCalendar.
+func parseFeed(data []byte) ([]Event, error) {
+	if isICS(data) {
+		return parseICS(data)
+	}
+	return decodeEvents(data)
+}
+
+// parse decodes the body of a feed or the output of a plugin.
+func (s eventSource) parse(data []byte) ([]Event, error) {
+	if s.isPlugin() {
+		return parsePluginOutput(data)
+	}
+	return parseFeed(data)
+}
+
+// readFeedEvents returns a feed's events from its cached copy. Feeds are
+// only fetched by refreshFeeds, so loading events never waits on the
+// network or a plugin.
+func readFeedEvents(src eventSource) ([]Event, error) {
+	cache, err := readFeedCache(src.cacheKey())
+	if err != nil || cache.Body == nil {
+		return nil, err
+	}
+	return src.parse(cache.Body)
+}
+
+// fetchFeed downloads a feed into its cache, revalidating the cached copy
+// with its ETag or Last-Modified date, or runs a plugin and caches its
+// output. It reports whether the cached body changed.
+func fetchFeed(src eventSource) (bool, error) {
+	cache, err := readFeedCache(src.cacheKey())
+	if err != nil {
+		return false, err
+	}
+	u, err := url.Parse(src.URL)
+	if err != nil {
+		return false, err
+	}
+	var body []byte
+	switch {
+	case src.isPlugin():
+		if body, err = runPlugin(src); err != nil {
+			return false, err
+		}
+	case u.Scheme == "file":
+		if body, err = os.ReadFile(u.Path); err != nil {
+			return false, err
+		}
+	case u.Scheme == "http" || u.Scheme == "https":
+		req, err := http.NewRequest(http.MethodGet, src.URL, nil)
+		if err != nil {
+			return false, err
+		}
+		if cache.Body != nil && cache.ETag != "" {
+			req.Header.Set("If-None-Match", cache.ETag)
+		}
+		if cache.Body != nil && cache.LastModified != "" {
+			req.Header.Set("If-Modified-Since", cache.LastModified)
+		}
+		resp, err := feedClient.Do(req)
+		if err != nil {
+			return false, err
+		}
+		defer resp.Body.Close()
+		switch resp.StatusCode {
+		case http.StatusNotModified:
+			cache.Fetched = time.Now().Unix()
+			return false, writeFeedCache(cache)
+		case http.StatusOK:
+		default:
+			return false, fmt.Errorf("unexpected status %s", resp.Status)
+		}
+		if body, err = readFeedBody(resp.Body); err != nil {
+			return false, err
+		}
+		cache.ETag = resp.Header.Get("ETag")
+		cache.LastModified = resp.Header.Get("Last-Modified")
+	default:
+		return false, fmt.Errorf("unsupported URL scheme %q", u.Scheme)
+	}
+	// Refuse to replace a good cached copy with something unreadable.
+	if _, err := src.parse(body); err != nil {
+		return false, err
+	}
+	changed := string(body) != string(cache.Body)
+	cache.Body = body
+	cache.Fetched = time.Now().Unix()
+	return changed, writeFeedCache(cache)
+}
+
+// refreshFeeds fetches every configured feed whose cached copy is older
+// than its interval, or all of them if force is set. It reports whether any
+// feed changed, and returns one error per feed that failed.
+func refreshFeeds(force bool) (bool, []error) {
+	cfg, err := loadConfig()
+	if err != nil {
+		return false, []error{err}
+	}
+	changed := false
+	var errs []error
+	for _, src := range cfg.feeds() {
+		if !force {
+			cache, err := readFeedCache(src.cacheKey())
+			if err == nil && time.Since(time.Unix(cache.Fetched, 0)) < src.interval() {
+				continue
+			}
+		}
+		feedChanged, err := fetchFeed(src)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("%s %s: %w", src.kind(), src.Name, err))
+		}
+		changed = changed || feedChanged
+	}
+	return changed, errs
+}
+
+func runFeeds(c *cli, args []string) error {
+	fs := c.newFlagSet("feeds")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if fs.NArg() > 0 {
+		return errUsage
+	}
+	cfg, err := loadConfig()
+	if err != nil {
+		return err
+	}
+	_, errs := refreshFeeds(true)
+	for _, err := range errs {
+		fmt.Fprintf(c.stderr, "%s: %v\n", appName, err)
+	}
+	for _, src := range cfg.feeds() {
+		events, err := readFeedEvents(src)
+		if err != nil {
+			fmt.Fprintf(c.stdout, "%s\t%s\terror: %v\n", src.Name, src.location(), err)
+			continue
+		}
+		fmt.Fprintf(c.stdout, "%s\t%s\t%d events\n", src.Name, src.location(), len(events))
+	}
+	if len(errs) > 0 {
+		return fmt.Errorf("%d of %d feeds failed to refresh", len(errs), len(cfg.feeds()))
+	}
+	return nil
+}
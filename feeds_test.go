@@ -0,0 +1,150 @@
+package main
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// newFeedServer serves body with an ETag and counts requests and 304s.
+func newFeedServer(t *testing.T, body string) (*httptest.Server, *atomic.Int32, *atomic.Int32) {
+	t.Helper()
+	var requests, notModified atomic.Int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests.Add(1)
+		w.Header().Set("ETag", `"v1"`)
+		if r.Header.Get("If-None-Match") == `"v1"` {
+			notModified.Add(1)
+			w.WriteHeader(http.StatusNotModified)
+			return
+		}
+		_, _ = w.Write([]byte(body))
+	}))
+	t.Cleanup(srv.Close)
+	return srv, &requests, &notModified
+}
+
+func setFeeds(t *testing.T, sources ...eventSource) {
+	t.Helper()
+	configFile, err := getConfigFilePath()
+	if err != nil {
+		t.Fatal(err)
+	}
+	writeJSON(t, configFile, config{Sources: sources})
+}
+
+func TestFetchFeedWithETag(t *testing.T) {
+	newTestHelper(t)
+	srv, requests, notModified := newFeedServer(t, testICS)
+	feed := eventSource{Name: "releases", URL: srv.URL + "/releases.ics"}
+
+	changed, err := fetchFeed(feed)
+	if err != nil || !changed {
+		t.Fatalf("first fetchFeed() = %v, %v", changed, err)
+	}
+	changed, err = fetchFeed(feed)
+	if err != nil || changed {
+		t.Fatalf("second fetchFeed() = %v, %v", changed, err)
+	}
+	if requests.Load() != 2 || notModified.Load() != 1 {
+		t.Errorf("Expected the second fetch to be revalidated, got %d requests and %d 304s",
+			requests.Load(), notModified.Load())
+	}
+
+	events, err := readFeedEvents(feed)
+	if err != nil || len(events) != 3 {
+		t.Errorf("Expected 3 cached events, got %v (err %v)", events, err)
+	}
+}
+
+func TestOversizedFeedKeepsCache(t *testing.T) {
+	newTestHelper(t)
+	var body atomic.Value
+	body.Store(testICS)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte(body.Load().(string)))
+	}))
+	t.Cleanup(srv.Close)
+	feed := eventSource{Name: "releases", URL: srv.URL}
+	if _, err := fetchFeed(feed); err != nil {
+		t.Fatal(err)
+	}
+
+	// Cut off at the limit, this would still parse as the first events.
+	body.Store(testICS + strings.Repeat("\r\n", maxFeedSize/2+1))
+	if _, err := fetchFeed(feed); err == nil || !strings.Contains(err.Error(), "larger than") {
+		t.Errorf("Expected an oversized feed to fail, got %v", err)
+	}
+	if events, err := readFeedEvents(feed); err != nil || len(events) != 3 {
+		t.Errorf("Expected the cached events to stay, got %v (err %v)", events, err)
+	}
+}
+
+func TestFeedsWorkOffline(t *testing.T) {
+	newTestHelper(t)
+	srv, _, _ := newFeedServer(t, `[{"id":"r","name":"Release","ts":1900000000}]`)
+	setFeeds(t, eventSource{Name: "releases", URL: srv.URL})
+
+	if changed, errs := refreshFeeds(false); !changed || len(errs) > 0 {
+		t.Fatalf("refreshFeeds() = %v, %v", changed, errs)
+	}
+	srv.Close()
+
+	// Not due yet, so nothing is fetched and nothing fails.
+	if _, errs := refreshFeeds(false); len(errs) > 0 {
+		t.Errorf("Expected no fetch before the interval, got %v", errs)
+	}
+	// Forcing a refresh fails, but the cached copy still loads.
+	if _, errs := refreshFeeds(true); len(errs) != 1 {
+		t.Errorf("Expected the offline refresh to fail, got %v", errs)
+	}
+	events, err := loadProfileEvents(defaultProfile)
+	if err != nil {
+		t.Fatalf("loadProfileEvents() failed: %v", err)
+	}
+	var release Event
+	for _, e := range events {
+		if e.ID == "r" {
+			release = e
+		}
+	}
+	if !release.ReadOnly() || release.Source != "releases" {
+		t.Fatalf("Expected the cached feed event as read-only, got %+v in %v", release, events)
+	}
+	if got := release.Title(); got != "Release [releases]" {
+		t.Errorf("Expected a source badge in the title, got %q", got)
+	}
+}
+
+func TestFileFeed(t *testing.T) {
+	th := newTestHelper(t)
+	path := filepath.Join(th.testConfigDir, "team.ics")
+	if err := os.WriteFile(path, []byte(testICS), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	feed := eventSource{Name: "team", URL: "file://" + filepath.ToSlash(path), Interval: "5m"}
+	if feed.interval() != 5*time.Minute {
+		t.Errorf("Expected a 5m interval, got %v", feed.interval())
+	}
+	if _, err := fetchFeed(feed); err != nil {
+		t.Fatalf("fetchFeed() failed: %v", err)
+	}
+	if events, err := readFeedEvents(feed); err != nil || len(events) != 3 {
+		t.Errorf("Expected 3 events from the file feed, got %v (err %v)", events, err)
+	}
+
+	if err := os.WriteFile(path, []byte("not a calendar"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := fetchFeed(feed); err == nil {
+		t.Error("Expected an unparseable feed to be rejected")
+	}
+	if events, _ := readFeedEvents(feed); len(events) != 3 {
+		t.Errorf("Expected the previous copy to be kept, got %v", events)
+	}
+}
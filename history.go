@@ -0,0 +1,210 @@
+package main
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"time"
+)
+
+const historyFileName = "history.jsonl"
+
+type changeAction string
+
+const (
+	actionAdd    changeAction = "add"
+	actionRemove changeAction = "remove"
+	actionEdit   changeAction = "edit"
+)
+
+// change is a single edit to a profile's own events. Before is unset for
+// additions and After is unset for removals.
+type change struct {
+	Action changeAction `json:"action"`
+	Before *Event       `json:"before,omitempty"`
+	After  *Event       `json:"after,omitempty"`
+}
+
+// inverse returns the change that undoes c.
+func (c change) inverse() change {
+	inv := change{Action: c.Action, Before: c.After, After: c.Before}
+	switch c.Action {
+	case actionAdd:
+		inv.Action = actionRemove
+	case actionRemove:
+		inv.Action = actionAdd
+	}
+	return inv
+}
+
+// occurrence returns c with yearly events moved to their next occurrence
+// as of now, so it applies to events loaded in a later year.
+func (c change) occurrence(now time.Time) change {
+	if c.Before != nil {
+		before := c.Before.occurrence(now)
+		c.Before = &before
+	}
+	if c.After != nil {
+		after := c.After.occurrence(now)
+		c.After = &after
+	}
+	return c
+}
+
+// apply returns events with c applied, keeping the result sorted by time.
+// The input slice is not modified.
+func (c change) apply(events []Event) ([]Event, error) {
+	events = slices.Clone(events)
+	if c.Before != nil {
+		i := indexOfEvent(events, *c.Before)
+		if i < 0 {
+			return nil, fmt.Errorf("event %q not found", c.Before.Name)
+		}
+		events = slices.Delete(events, i, i+1)
+	}
+	if c.After != nil {
+		events = insertSorted(events, *c.After)
+	}
+	return events, nil
+}
+
+func (c change) String() string {
+	switch c.Action {
+	case actionAdd:
+		return fmt.Sprintf("added %q (%s)", c.After.Name, c.After.ToBasicString())
+	case actionRemove:
+		return fmt.Sprintf("removed %q (%s)", c.Before.Name, c.Before.ToBasicString())
+	default:
+		return fmt.Sprintf("edited %q (%s) -> %q (%s)",
+			c.Before.Name, c.Before.ToBasicString(), c.After.Name, c.After.ToBasicString())
+	}
+}
+
+// indexOfEvent returns the index of the editable event with the key of e,
+// or -1. Matching on the key rather than the name and time keeps copies of
+// an event apart.
+func indexOfEvent(events []Event, e Event) int {
+	return slices.IndexFunc(events, func(x Event) bool {
+		return !x.ReadOnly() && x.key() == e.key()
+	})
+}
+
+// insertSorted inserts e after every event at or before its time.
+func insertSorted(events []Event, e Event) []Event {
+	index := 0
+	for _, x := range events {
+		if e.Time >= x.Time {
+			index++
+		}
+	}
+	return slices.Insert(events, index, e)
+}
+
+// historyEntry is one line of the append-only history log.
+type historyEntry struct {
+	Time    int64  `json:"time"`
+	Profile string `json:"profile"`
+	change
+}
+
+// getHistoryFilePath returns the path to the history log next to events.json.
+func getHistoryFilePath() (string, error) {
+	eventsFile, err := getEventsFilePath()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(filepath.Dir(eventsFile), historyFileName), nil
+}
+
+func appendHistory(profile string, c change) error {
+	path, err := getHistoryFilePath()
+	if err != nil {
+		return err
+	}
+	line, err := encodeHistoryEntry(historyEntry{Time: time.Now().Unix(), Profile: profile, change: c})
+	if err != nil {
+		return err
+	}
+	f, err := os.OpenFile(path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0o644)
+	if err != nil {
+		return err
+	}
+	if _, err := f.Write(line); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
+
+// encodeHistoryEntry returns an entry as one line of the log. With
+// encryption on, each line is sealed on its own so the log stays
+// append-only.
+func encodeHistoryEntry(entry historyEntry) ([]byte, error) {
+	data, err := json.Marshal(entry)
+	if err != nil {
+		return nil, err
+	}
+	if data, err = secrets.seal(data); err != nil {
+		return nil, err
+	}
+	return append(data, '\n'), nil
+}
+
+func readHistory() ([]historyEntry, error) {
+	path, err := getHistoryFilePath()
+	if err != nil {
+		return nil, err
+	}
+	f, err := os.Open(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	var entries []historyEntry
+	scanner := bufio.NewScanner(f)
+	for line := 1; scanner.Scan(); line++ {
+		if len(scanner.Bytes()) == 0 {
+			continue
+		}
+		data, err := secrets.open(scanner.Bytes())
+		if err != nil {
+			return nil, fmt.Errorf("%s:%d: %w", path, line, err)
+		}
+		var entry historyEntry
+		if err := json.Unmarshal(data, &entry); err != nil {
+			return nil, fmt.Errorf("%s:%d: %w", path, line, err)
+		}
+		entries = append(entries, entry)
+	}
+	return entries, scanner.Err()
+}
+
+// restoreHistoryEntry reverts a logged change in the profile it was made in
+// and logs the reversal as a new entry.
+func restoreHistoryEntry(entry historyEntry) error {
+	events, err := readProfileEvents(entry.Profile)
+	if err != nil {
+		return err
+	}
+	// Stored yearly events may still be on a past year's date.
+	now := time.Now()
+	for i := range events {
+		events[i] = events[i].occurrence(now)
+	}
+	revert := entry.inverse().occurrence(now)
+	if events, err = revert.apply(events); err != nil {
+		return err
+	}
+	if err := saveProfileEvents(entry.Profile, events); err != nil {
+		return err
+	}
+	return appendHistory(entry.Profile, revert)
+}
@@ -0,0 +1,329 @@
+package main
+
+import (
+	"bytes"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	tea "charm.land/bubbletea/v2"
+)
+
+func TestChangeApply(t *testing.T) {
+	a := Event{Name: "A", Time: 100}
+	b := Event{Name: "B", Time: 200}
+	c := Event{Name: "C", Time: 300}
+	shared := Event{Name: "B", Time: 200, Source: "team"}
+
+	tests := []struct {
+		name    string
+		events  []Event
+		change  change
+		want    []Event
+		wantErr bool
+	}{
+		{
+			name:   "Add keeps order",
+			events: []Event{a, c},
+			change: change{Action: actionAdd, After: &b},
+			want:   []Event{a, b, c},
+		},
+		{
+			name:   "Remove",
+			events: []Event{a, b, c},
+			change: change{Action: actionRemove, Before: &b},
+			want:   []Event{a, c},
+		},
+		{
+			name:   "Edit moves event",
+			events: []Event{a, b},
+			change: change{Action: actionEdit, Before: &a, After: &c},
+			want:   []Event{b, c},
+		},
+		{
+			name:    "Remove missing event",
+			events:  []Event{a},
+			change:  change{Action: actionRemove, Before: &b},
+			wantErr: true,
+		},
+		{
+			name:    "Read-only events are never matched",
+			events:  []Event{shared},
+			change:  change{Action: actionRemove, Before: &b},
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			original := append([]Event(nil), tt.events...)
+			got, err := tt.change.apply(tt.events)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("apply() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if tt.wantErr {
+				return
+			}
+			if len(got) != len(tt.want) {
+				t.Fatalf("apply() = %v, want %v", got, tt.want)
+			}
+			for i := range got {
+				if !reflect.DeepEqual(got[i], tt.want[i]) {
+					t.Errorf("apply()[%d] = %v, want %v", i, got[i], tt.want[i])
+				}
+			}
+			for i := range original {
+				if !reflect.DeepEqual(tt.events[i], original[i]) {
+					t.Error("apply() modified its input")
+				}
+			}
+		})
+	}
+}
+
+func TestChangeInverse(t *testing.T) {
+	x := Event{Name: "X", Time: 1}
+	a := Event{Name: "A", Time: 100}
+	b := Event{Name: "B", Time: 200}
+	tests := []struct {
+		change change
+		start  []Event
+	}{
+		{change: change{Action: actionAdd, After: &a}, start: []Event{x}},
+		{change: change{Action: actionRemove, Before: &a}, start: []Event{x, a}},
+		{change: change{Action: actionEdit, Before: &a, After: &b}, start: []Event{x, a}},
+	}
+
+	for _, tt := range tests {
+		events, err := tt.change.apply(tt.start)
+		if err != nil {
+			t.Fatalf("%s: apply() failed: %v", tt.change.Action, err)
+		}
+		events, err = tt.change.inverse().apply(events)
+		if err != nil {
+			t.Fatalf("%s: inverse().apply() failed: %v", tt.change.Action, err)
+		}
+		if len(events) != len(tt.start) {
+			t.Fatalf("%s: round trip gave %v, want %v", tt.change.Action, events, tt.start)
+		}
+		for i := range events {
+			if !reflect.DeepEqual(events[i], tt.start[i]) {
+				t.Errorf("%s: round trip gave %v, want %v", tt.change.Action, events, tt.start)
+			}
+		}
+	}
+}
+
+func TestHistoryLog(t *testing.T) {
+	newTestHelper(t)
+	event := Event{Name: "Launch", Time: time.Now().Add(time.Hour).Unix()}
+
+	entries, err := readHistory()
+	if err != nil || len(entries) != 0 {
+		t.Fatalf("Expected empty history, got %v (err %v)", entries, err)
+	}
+
+	if err := appendHistory("work", change{Action: actionAdd, After: &event}); err != nil {
+		t.Fatalf("appendHistory() failed: %v", err)
+	}
+	if err := appendHistory("work", change{Action: actionRemove, Before: &event}); err != nil {
+		t.Fatalf("appendHistory() failed: %v", err)
+	}
+
+	entries, err = readHistory()
+	if err != nil {
+		t.Fatalf("readHistory() failed: %v", err)
+	}
+	if len(entries) != 2 {
+		t.Fatalf("Expected 2 entries, got %d", len(entries))
+	}
+	if entries[1].Profile != "work" || entries[1].Action != actionRemove || !reflect.DeepEqual(*entries[1].Before, event) {
+		t.Errorf("Unexpected second entry: %+v", entries[1])
+	}
+
+	// Restoring the removal brings the event back.
+	if err := restoreHistoryEntry(entries[1]); err != nil {
+		t.Fatalf("restoreHistoryEntry() failed: %v", err)
+	}
+	events, err := readProfileEvents("work")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(events) != 1 || !reflect.DeepEqual(events[0], event) {
+		t.Errorf("Expected the removed event to be restored, got %v", events)
+	}
+	if entries, _ = readHistory(); len(entries) != 3 {
+		t.Errorf("Expected the restore to be logged, got %d entries", len(entries))
+	}
+}
+
+func TestRestoreYearlyAfterRollover(t *testing.T) {
+	newTestHelper(t)
+	yearAgo := time.Now().AddDate(-1, 0, 0)
+	birthday := Event{Name: "Grace Hopper's birthday", Yearly: "--12-09", Tags: []string{"birthday"}}
+	added := birthday.occurrence(yearAgo)
+	if err := appendHistory(defaultProfile, change{Action: actionAdd, After: &added}); err != nil {
+		t.Fatal(err)
+	}
+	// Since then the event was saved on this year's date.
+	if err := saveProfileEvents(defaultProfile, []Event{birthday.occurrence(time.Now())}); err != nil {
+		t.Fatal(err)
+	}
+
+	entries, err := readHistory()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := restoreHistoryEntry(entries[0]); err != nil {
+		t.Fatalf("Expected undoing the addition to work a year later, got %v", err)
+	}
+	if events, err := readProfileEvents(defaultProfile); err != nil || len(events) != 0 {
+		t.Errorf("Expected the birthday to be removed, got %v (err %v)", events, err)
+	}
+
+	// Redoing it adds the birthday back on its next occurrence.
+	if entries, err = readHistory(); err != nil {
+		t.Fatal(err)
+	}
+	if err := restoreHistoryEntry(entries[1]); err != nil {
+		t.Fatalf("restoreHistoryEntry() failed: %v", err)
+	}
+	events, err := readProfileEvents(defaultProfile)
+	if err != nil || len(events) != 1 || events[0].Time != birthday.occurrence(time.Now()).Time {
+		t.Errorf("Expected the birthday back on this year's date, got %v (err %v)", events, err)
+	}
+}
+
+// seedGoBirthday saves a single editable event to the default profile.
+func seedGoBirthday(t *testing.T) {
+	t.Helper()
+	event := Event{ID: newEventID(), Name: "Golang's Birthday", Time: time.Now().AddDate(0, 1, 0).Unix()}
+	if err := saveProfileEvents(defaultProfile, []Event{event}); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestUndoRedo(t *testing.T) {
+	newTestHelper(t)
+	seedGoBirthday(t)
+	m, err := NewMainModel(defaultProfile)
+	if err != nil {
+		t.Fatalf("NewMainModel() failed: %v", err)
+	}
+	press := func(msg tea.KeyPressMsg) {
+		t.Helper()
+		updated, _ := m.Update(msg)
+		m = updated.(MainModel)
+	}
+	undo := tea.KeyPressMsg{Code: 'z', Mod: tea.ModCtrl}
+	redo := tea.KeyPressMsg{Code: 'y', Mod: tea.ModCtrl}
+
+	press(tea.KeyPressMsg{Code: '-', Text: "-"})
+	if m.state != noEvents {
+		t.Fatalf("Expected noEvents after removing the only event, got %v", m.state)
+	}
+
+	press(undo)
+	if len(m.events.Items()) != 1 || m.state != showEvents {
+		t.Fatalf("Expected undo to restore the event, got %d events in state %v", len(m.events.Items()), m.state)
+	}
+	saved, err := readProfileEvents(defaultProfile)
+	if err != nil || len(saved) != 1 {
+		t.Errorf("Expected the restored event to be saved, got %v (err %v)", saved, err)
+	}
+
+	press(redo)
+	if len(m.events.Items()) != 0 {
+		t.Errorf("Expected redo to remove the event again, got %d events", len(m.events.Items()))
+	}
+
+	entries, err := readHistory()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) != 3 {
+		t.Errorf("Expected remove, undo and redo to be logged, got %d entries", len(entries))
+	}
+
+	// Reloading after a sync keeps the undo history.
+	updated, _ := m.Update(syncDoneMsg{})
+	m = updated.(MainModel)
+	press(undo)
+	if len(m.events.Items()) != 1 {
+		t.Errorf("Expected undo to work after a sync, got %d events", len(m.events.Items()))
+	}
+}
+
+func TestEditEvent(t *testing.T) {
+	newTestHelper(t)
+	seedGoBirthday(t)
+	m, err := NewMainModel(defaultProfile)
+	if err != nil {
+		t.Fatalf("NewMainModel() failed: %v", err)
+	}
+	updated, _ := m.Update(tea.KeyPressMsg{Code: 'e', Text: "e"})
+	m = updated.(MainModel)
+	if m.state != showInput || m.editing == nil {
+		t.Fatalf("Expected the edit form, got state %v", m.state)
+	}
+	if got := m.inputs[inputNameField].Value(); got != "Golang's Birthday" {
+		t.Errorf("Expected the name field to be prefilled, got %q", got)
+	}
+
+	m.inputs[inputNameField].SetValue("Go Day")
+	m.focus = int(inputSubmitButton)
+	updated, _ = m.Update(tea.KeyPressMsg{Code: tea.KeyEnter})
+	m = updated.(MainModel)
+
+	if m.state != showEvents || m.editing != nil {
+		t.Fatalf("Expected to return to the list, got state %v", m.state)
+	}
+	events := m.listEvents()
+	if len(events) != 1 || events[0].Name != "Go Day" {
+		t.Fatalf("Expected the edited event, got %v", events)
+	}
+	if len(m.undo) != 1 || m.undo[0].Action != actionEdit {
+		t.Errorf("Expected an undoable edit, got %v", m.undo)
+	}
+}
+
+func TestRunHistory(t *testing.T) {
+	newTestHelper(t)
+	event := Event{Name: "Launch", Time: time.Now().Add(time.Hour).Unix()}
+	if err := appendHistory(defaultProfile, change{Action: actionRemove, Before: &event}); err != nil {
+		t.Fatal(err)
+	}
+
+	var out bytes.Buffer
+	c := &cli{profile: defaultProfile, stdout: &out, stderr: &out}
+	if err := c.run([]string{"history"}); err != nil {
+		t.Fatalf("history failed: %v", err)
+	}
+	if !strings.Contains(out.String(), `removed "Launch"`) {
+		t.Errorf("Expected the removal in the history listing, got %q", out.String())
+	}
+
+	out.Reset()
+	if err := c.run([]string{"history", "restore", "1"}); err != nil {
+		t.Fatalf("history restore failed: %v", err)
+	}
+	events, err := readProfileEvents(defaultProfile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	found := false
+	for _, e := range events {
+		found = found || reflect.DeepEqual(e, event)
+	}
+	if !found {
+		t.Errorf("Expected Launch to be restored, got %v", events)
+	}
+
+	if err := c.run([]string{"history", "restore", "9"}); err == nil {
+		t.Error("Expected an error restoring a missing entry")
+	}
+	if err := c.run([]string{"history", "bogus"}); err == nil {
+		t.Error("Expected a usage error")
+	}
+}
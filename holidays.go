@@ -0,0 +1,354 @@
+package main
+
+import (
+	"slices"
+	"sort"
+	"time"
+)
+
+// holidayRule finds the date of one holiday in a given year.
+type holidayRule struct {
+	name string
+	// date returns the holiday in year as midnight UTC.
+	date func(year int) time.Time
+	// from is the first year the holiday was kept, or 0 if it always was.
+	from int
+}
+
+// fixedDate is a holiday on the same date every year.
+func fixedDate(month time.Month, day int) func(int) time.Time {
+	return func(year int) time.Time { return time.Date(year, month, day, 0, 0, 0, 0, time.UTC) }
+}
+
+// nthWeekday is a holiday on the nth weekday of a month, such as the fourth
+// Thursday of November. A negative n counts from the end of the month.
+func nthWeekday(n int, weekday time.Weekday, month time.Month) func(int) time.Time {
+	return func(year int) time.Time {
+		if n < 0 {
+			last := time.Date(year, month+1, 0, 0, 0, 0, 0, time.UTC)
+			back := (int(last.Weekday()) - int(weekday) + 7) % 7
+			return last.AddDate(0, 0, -back+(n+1)*7)
+		}
+		first := time.Date(year, month, 1, 0, 0, 0, 0, time.UTC)
+		ahead := (int(weekday) - int(first.Weekday()) + 7) % 7
+		return first.AddDate(0, 0, ahead+(n-1)*7)
+	}
+}
+
+// fromEaster is a moveable feast a number of days after Easter Sunday.
+func fromEaster(days int) func(int) time.Time {
+	return func(year int) time.Time { return easter(year).AddDate(0, 0, days) }
+}
+
+// easter returns Western Easter Sunday in the Gregorian calendar, using
+// the anonymous algorithm published in Nature in 1876.
+func easter(year int) time.Time {
+	a := year % 19
+	b, c := year/100, year%100
+	d, e := b/4, b%4
+	f := (b + 8) / 25
+	g := (b - f + 1) / 3
+	h := (19*a + b - d - g + 15) % 30
+	i, k := c/4, c%4
+	l := (32 + 2*e + 2*i - h - k) % 7
+	m := (a + 11*h + 22*l) / 451
+	month := (h + l - 7*m + 114) / 31
+	day := (h+l-7*m+114)%31 + 1
+	return time.Date(year, time.Month(month), day, 0, 0, 0, 0, time.UTC)
+}
+
+// japaneseEquinox returns the vernal (March) or autumnal (September)
+// equinox day as the Japanese calendar fixes it, valid from 1980 to 2099.
+func japaneseEquinox(month time.Month) func(int) time.Time {
+	base := 20.8431
+	if month == time.September {
+		base = 23.2488
+	}
+	return func(year int) time.Time {
+		n := year - 1980
+		day := int(base+0.242194*float64(n)) - n/4
+		return time.Date(year, month, day, 0, 0, 0, 0, time.UTC)
+	}
+}
+
+// substitutePolicy is how a country makes up for holidays falling on a
+// weekend.
+type substitutePolicy int
+
+const (
+	// noSubstitute leaves weekend holidays where they are.
+	noSubstitute substitutePolicy = iota
+	// nearestWeekday moves Saturday holidays to Friday and Sunday ones to
+	// Monday.
+	nearestWeekday
+	// nextWeekday gives the next weekday that isn't already a holiday.
+	nextWeekday
+	// afterSunday gives the next day that isn't already a holiday, for
+	// Sunday holidays only.
+	afterSunday
+)
+
+// holidayPack is the public holidays of one country.
+type holidayPack struct {
+	code       string
+	rules      []holidayRule
+	substitute substitutePolicy
+	// bridge makes a day between two holidays a holiday too, as in Japan.
+	bridge bool
+}
+
+// holiday is a day off: a holiday or a day given in substitute for one.
+type holiday struct {
+	name string
+	date time.Time
+}
+
+var holidayPacks = []holidayPack{
+	{
+		code: "US", substitute: nearestWeekday,
+		rules: []holidayRule{
+			{name: "New Year's Day", date: fixedDate(time.January, 1)},
+			{name: "Martin Luther King Jr. Day", date: nthWeekday(3, time.Monday, time.January)},
+			{name: "Washington's Birthday", date: nthWeekday(3, time.Monday, time.February)},
+			{name: "Memorial Day", date: nthWeekday(-1, time.Monday, time.May)},
+			{name: "Juneteenth", date: fixedDate(time.June, 19), from: 2021},
+			{name: "Independence Day", date: fixedDate(time.July, 4)},
+			{name: "Labor Day", date: nthWeekday(1, time.Monday, time.September)},
+			{name: "Columbus Day", date: nthWeekday(2, time.Monday, time.October)},
+			{name: "Veterans Day", date: fixedDate(time.November, 11)},
+			{name: "Thanksgiving Day", date: nthWeekday(4, time.Thursday, time.November)},
+			{name: "Christmas Day", date: fixedDate(time.December, 25)},
+		},
+	},
+	{
+		// Bank holidays in England and Wales.
+		code: "UK", substitute: nextWeekday,
+		rules: []holidayRule{
+			{name: "New Year's Day", date: fixedDate(time.January, 1)},
+			{name: "Good Friday", date: fromEaster(-2)},
+			{name: "Easter Monday", date: fromEaster(1)},
+			{name: "Early May Bank Holiday", date: nthWeekday(1, time.Monday, time.May)},
+			{name: "Spring Bank Holiday", date: nthWeekday(-1, time.Monday, time.May)},
+			{name: "Summer Bank Holiday", date: nthWeekday(-1, time.Monday, time.August)},
+			{name: "Christmas Day", date: fixedDate(time.December, 25)},
+			{name: "Boxing Day", date: fixedDate(time.December, 26)},
+		},
+	},
+	{
+		code: "DE",
+		rules: []holidayRule{
+			{name: "New Year's Day", date: fixedDate(time.January, 1)},
+			{name: "Good Friday", date: fromEaster(-2)},
+			{name: "Easter Monday", date: fromEaster(1)},
+			{name: "Labour Day", date: fixedDate(time.May, 1)},
+			{name: "Ascension Day", date: fromEaster(39)},
+			{name: "Whit Monday", date: fromEaster(50)},
+			{name: "German Unity Day", date: fixedDate(time.October, 3)},
+			{name: "Christmas Day", date: fixedDate(time.December, 25)},
+			{name: "St. Stephen's Day", date: fixedDate(time.December, 26)},
+		},
+	},
+	{
+		code: "FR",
+		rules: []holidayRule{
+			{name: "New Year's Day", date: fixedDate(time.January, 1)},
+			{name: "Easter Monday", date: fromEaster(1)},
+			{name: "Labour Day", date: fixedDate(time.May, 1)},
+			{name: "Victory in Europe Day", date: fixedDate(time.May, 8)},
+			{name: "Ascension Day", date: fromEaster(39)},
+			{name: "Whit Monday", date: fromEaster(50)},
+			{name: "Bastille Day", date: fixedDate(time.July, 14)},
+			{name: "Assumption of Mary", date: fixedDate(time.August, 15)},
+			{name: "All Saints' Day", date: fixedDate(time.November, 1)},
+			{name: "Armistice Day", date: fixedDate(time.November, 11)},
+			{name: "Christmas Day", date: fixedDate(time.December, 25)},
+		},
+	},
+	{
+		code: "JP", substitute: afterSunday, bridge: true,
+		rules: []holidayRule{
+			{name: "New Year's Day", date: fixedDate(time.January, 1)},
+			{name: "Coming of Age Day", date: nthWeekday(2, time.Monday, time.January)},
+			{name: "National Foundation Day", date: fixedDate(time.February, 11)},
+			{name: "Emperor's Birthday", date: fixedDate(time.February, 23), from: 2020},
+			{name: "Vernal Equinox Day", date: japaneseEquinox(time.March)},
+			{name: "Showa Day", date: fixedDate(time.April, 29)},
+			{name: "Constitution Memorial Day", date: fixedDate(time.May, 3)},
+			{name: "Greenery Day", date: fixedDate(time.May, 4)},
+			{name: "Children's Day", date: fixedDate(time.May, 5)},
+			{name: "Marine Day", date: nthWeekday(3, time.Monday, time.July)},
+			{name: "Mountain Day", date: fixedDate(time.August, 11), from: 2016},
+			{name: "Respect for the Aged Day", date: nthWeekday(3, time.Monday, time.September)},
+			{name: "Autumnal Equinox Day", date: japaneseEquinox(time.September)},
+			{name: "Sports Day", date: nthWeekday(2, time.Monday, time.October)},
+			{name: "Culture Day", date: fixedDate(time.November, 3)},
+			{name: "Labour Thanksgiving Day", date: fixedDate(time.November, 23)},
+		},
+	},
+}
+
+// year returns the holidays and substitute days off of a year, in date
+// order. A substitute day can fall in the year before, as when New Year's
+// Day on a Saturday is observed on Friday, December 31.
+func (p holidayPack) year(year int) []holiday {
+	var days []holiday
+	for _, rule := range p.rules {
+		if year >= rule.from {
+			days = append(days, holiday{name: rule.name, date: rule.date(year)})
+		}
+	}
+	sort.Slice(days, func(i, j int) bool { return days[i].date.Before(days[j].date) })
+	taken := func(t time.Time) bool {
+		return slices.ContainsFunc(days, func(h holiday) bool { return h.date.Equal(t) })
+	}
+
+	if p.bridge {
+		for i, n := 1, len(days); i < n; i++ {
+			between := days[i-1].date.AddDate(0, 0, 1)
+			if days[i].date.Sub(days[i-1].date) == 48*time.Hour && between.Weekday() != time.Sunday {
+				days = append(days, holiday{name: "Citizens' Holiday", date: between})
+			}
+		}
+	}
+	// The range covers the holidays found so far, while taken also sees
+	// the substitutes appended in the loop.
+	for _, h := range days {
+		var sub time.Time
+		switch wd := h.date.Weekday(); {
+		case p.substitute == nearestWeekday && wd == time.Saturday:
+			sub = h.date.AddDate(0, 0, -1)
+		case p.substitute == nearestWeekday && wd == time.Sunday:
+			sub = h.date.AddDate(0, 0, 1)
+		case p.substitute == nextWeekday && isWeekend(h.date):
+			sub = h.date.AddDate(0, 0, 1)
+			for isWeekend(sub) || taken(sub) {
+				sub = sub.AddDate(0, 0, 1)
+			}
+		case p.substitute == afterSunday && wd == time.Sunday:
+			sub = h.date.AddDate(0, 0, 1)
+			for taken(sub) {
+				sub = sub.AddDate(0, 0, 1)
+			}
+		default:
+			continue
+		}
+		days = append(days, holiday{name: h.name + " (observed)", date: sub})
+	}
+	sort.SliceStable(days, func(i, j int) bool { return days[i].date.Before(days[j].date) })
+	return days
+}
+
+func isWeekend(t time.Time) bool {
+	return t.Weekday() == time.Saturday || t.Weekday() == time.Sunday
+}
+
+// holidayProvider yields a country's holidays and substitute days off,
+// at midnight in the local time zone.
+type holidayProvider struct{ pack holidayPack }
+
+func (p holidayProvider) Events(from, to time.Time) ([]Event, error) {
+	var events []Event
+	// The year after to's can have a substitute day in to's year.
+	for year := from.Year(); year <= to.Year()+1; year++ {
+		for _, h := range p.pack.year(year) {
+			t := time.Date(h.date.Year(), h.date.Month(), h.date.Day(), 0, 0, 0, 0, from.Location())
+			if !t.Before(from) && t.Before(to) {
+				events = append(events, Event{Name: h.name, Time: t.Unix(), Tags: []string{"holiday", p.pack.code}})
+			}
+		}
+	}
+	sort.SliceStable(events, func(i, j int) bool { return events[i].Time < events[j].Time })
+	return events, nil
+}
+
+// holidayCalendar tells days off apart from working days, for the
+// holiday packs enabled in a profile.
+type holidayCalendar struct {
+	packs []holidayPack
+	// days caches the days off by year.
+	days map[int]map[time.Time]bool
+}
+
+func newHolidayCalendar(packs ...holidayPack) *holidayCalendar {
+	return &holidayCalendar{packs: packs, days: make(map[int]map[time.Time]bool)}
+}
+
+// daysOff returns the holidays the packs list for a year, which may
+// include days observed in the year before.
+func (c *holidayCalendar) daysOff(year int) map[time.Time]bool {
+	days, ok := c.days[year]
+	if !ok {
+		days = make(map[time.Time]bool)
+		for _, p := range c.packs {
+			for _, h := range p.year(year) {
+				days[h.date] = true
+			}
+		}
+		c.days[year] = days
+	}
+	return days
+}
+
+// isDayOff reports whether the date of t is a weekend or a holiday.
+func (c *holidayCalendar) isDayOff(t time.Time) bool {
+	date := time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, time.UTC)
+	if isWeekend(date) {
+		return true
+	}
+	if c == nil {
+		return false
+	}
+	return c.daysOff(date.Year())[date] || c.daysOff(date.Year() + 1)[date]
+}
+
+// businessDays counts the working days after from's date and before to's,
+// i.e. the whole days left to work before an event. Weekdays are counted a
+// week at a time and the holidays in between taken off, since the detail
+// panel asks on every frame, however far away the event is.
+func (c *holidayCalendar) businessDays(from, to time.Time) int {
+	start := time.Date(from.Year(), from.Month(), from.Day()+1, 0, 0, 0, 0, time.UTC)
+	end := time.Date(to.Year(), to.Month(), to.Day(), 0, 0, 0, 0, time.UTC)
+	if !start.Before(end) {
+		return 0
+	}
+	weeks := int(end.Sub(start).Hours()/24) / 7
+	n := weeks * 5
+	for day := start.AddDate(0, 0, weeks*7); day.Before(end); day = day.AddDate(0, 0, 1) {
+		if !isWeekend(day) {
+			n++
+		}
+	}
+	if c == nil {
+		return n
+	}
+	counted := make(map[time.Time]bool)
+	for year := start.Year(); year <= end.Year()+1; year++ {
+		for date := range c.daysOff(year) {
+			if !counted[date] && !date.Before(start) && date.Before(end) && !isWeekend(date) {
+				counted[date] = true
+				n--
+			}
+		}
+	}
+	return n
+}
+
+// profileHolidays returns the calendar of the holiday providers enabled
+// in a profile.
+func profileHolidays(profile string) (*holidayCalendar, error) {
+	cfg, err := loadConfig()
+	if err != nil {
+		return nil, err
+	}
+	providers, err := cfg.providers(profile)
+	if err != nil {
+		return nil, err
+	}
+	var packs []holidayPack
+	for _, p := range providers {
+		if hp, ok := p.EventProvider.(holidayProvider); ok {
+			packs = append(packs, hp.pack)
+		}
+	}
+	return newHolidayCalendar(packs...), nil
+}
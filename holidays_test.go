@@ -0,0 +1,160 @@
+package main
+
+import (
+	"testing"
+	"time"
+)
+
+func utcDate(year int, month time.Month, day int) time.Time {
+	return time.Date(year, month, day, 0, 0, 0, 0, time.UTC)
+}
+
+// findPack returns the holiday pack of a country.
+func findPack(t *testing.T, code string) holidayPack {
+	t.Helper()
+	for _, p := range holidayPacks {
+		if p.code == code {
+			return p
+		}
+	}
+	t.Fatalf("No holiday pack %s", code)
+	return holidayPack{}
+}
+
+func TestEaster(t *testing.T) {
+	for _, want := range []time.Time{
+		utcDate(1961, time.April, 2),
+		utcDate(2000, time.April, 23),
+		utcDate(2024, time.March, 31),
+		utcDate(2026, time.April, 5),
+		utcDate(2038, time.April, 25),
+	} {
+		if got := easter(want.Year()); !got.Equal(want) {
+			t.Errorf("easter(%d) = %v, want %v", want.Year(), got.Format(time.DateOnly), want.Format(time.DateOnly))
+		}
+	}
+}
+
+func TestHolidayPacks(t *testing.T) {
+	tests := []struct {
+		code string
+		year int
+		name string
+		want time.Time
+	}{
+		{"US", 2026, "Thanksgiving Day", utcDate(2026, time.November, 26)},
+		{"US", 2026, "Memorial Day", utcDate(2026, time.May, 25)},
+		{"US", 2026, "Independence Day (observed)", utcDate(2026, time.July, 3)},
+		{"US", 2022, "New Year's Day (observed)", utcDate(2021, time.December, 31)},
+		{"UK", 2021, "Christmas Day (observed)", utcDate(2021, time.December, 27)},
+		{"UK", 2021, "Boxing Day (observed)", utcDate(2021, time.December, 28)},
+		{"UK", 2022, "Christmas Day (observed)", utcDate(2022, time.December, 27)},
+		{"UK", 2026, "Spring Bank Holiday", utcDate(2026, time.May, 25)},
+		{"DE", 2026, "Ascension Day", utcDate(2026, time.May, 14)},
+		{"DE", 2026, "Whit Monday", utcDate(2026, time.May, 25)},
+		{"FR", 2026, "Easter Monday", utcDate(2026, time.April, 6)},
+		{"JP", 2026, "Vernal Equinox Day", utcDate(2026, time.March, 20)},
+		{"JP", 2026, "Constitution Memorial Day (observed)", utcDate(2026, time.May, 6)},
+		{"JP", 2026, "Citizens' Holiday", utcDate(2026, time.September, 22)},
+		{"JP", 2026, "Autumnal Equinox Day", utcDate(2026, time.September, 23)},
+	}
+	for _, tt := range tests {
+		p := findPack(t, tt.code)
+		var got []time.Time
+		for _, h := range p.year(tt.year) {
+			if h.name == tt.name {
+				got = append(got, h.date)
+			}
+		}
+		if len(got) != 1 || !got[0].Equal(tt.want) {
+			t.Errorf("%s %d %s = %v, want %v", tt.code, tt.year, tt.name, got, tt.want.Format(time.DateOnly))
+		}
+	}
+
+	for _, h := range findPack(t, "US").year(2020) {
+		if h.name == "Juneteenth" {
+			t.Error("Juneteenth was a federal holiday before 2021")
+		}
+	}
+}
+
+func TestBusinessDays(t *testing.T) {
+	cal := newHolidayCalendar(findPack(t, "US"))
+	from := time.Date(2026, time.November, 20, 15, 0, 0, 0, time.Local)
+	to := time.Date(2026, time.November, 30, 9, 0, 0, 0, time.Local)
+	if got := cal.businessDays(from, to); got != 4 {
+		t.Errorf("businessDays() over Thanksgiving = %d, want 4", got)
+	}
+	var none *holidayCalendar
+	if got := none.businessDays(from, to); got != 5 {
+		t.Errorf("businessDays() without holidays = %d, want 5", got)
+	}
+	if got := cal.businessDays(to, from); got != 0 {
+		t.Errorf("businessDays() backwards = %d, want 0", got)
+	}
+	if !cal.isDayOff(utcDate(2021, time.December, 31)) {
+		t.Error("Expected New Year's Day 2022 to be observed on December 31, 2021")
+	}
+	// Far-off events agree with counting one day at a time.
+	for _, span := range []struct{ from, to time.Time }{
+		{from, time.Date(2031, time.March, 4, 0, 0, 0, 0, time.Local)},
+		{time.Date(2021, time.December, 30, 8, 0, 0, 0, time.Local), time.Date(2022, time.January, 4, 0, 0, 0, 0, time.Local)},
+		{time.Date(2026, time.July, 4, 0, 0, 0, 0, time.Local), time.Date(2026, time.July, 6, 0, 0, 0, 0, time.Local)},
+	} {
+		want := 0
+		end := utcDate(span.to.Year(), span.to.Month(), span.to.Day())
+		for day := utcDate(span.from.Year(), span.from.Month(), span.from.Day()+1); day.Before(end); day = day.AddDate(0, 0, 1) {
+			if !cal.isDayOff(day) {
+				want++
+			}
+		}
+		if got := cal.businessDays(span.from, span.to); got != want {
+			t.Errorf("businessDays(%v, %v) = %d, want %d", span.from, span.to, got, want)
+		}
+	}
+}
+
+func TestHolidayProvider(t *testing.T) {
+	p := builtinProvider{name: "holidays-us", horizon: oneYear, EventProvider: holidayProvider{findPack(t, "US")}}
+	now := time.Date(2026, time.October, 18, 12, 0, 0, 0, time.Local)
+	events, err := p.upcoming(now)
+	if err != nil {
+		t.Fatal(err)
+	}
+	times := make(map[string]time.Time)
+	for _, e := range events {
+		if _, dup := times[e.Name]; dup {
+			t.Errorf("Duplicate event %q", e.Name)
+		}
+		times[e.Name] = time.Unix(e.Time, 0)
+	}
+	if got, want := times["Veterans Day"], time.Date(2026, time.November, 11, 0, 0, 0, 0, time.Local); !got.Equal(want) {
+		t.Errorf("Veterans Day at %v, want %v", got, want)
+	}
+	if got, want := times["Columbus Day"], time.Date(2027, time.October, 11, 0, 0, 0, 0, time.Local); !got.Equal(want) {
+		t.Errorf("Columbus Day at %v, want the next one at %v", got, want)
+	}
+	if got, want := times["Independence Day (observed)"], time.Date(2027, time.July, 5, 0, 0, 0, 0, time.Local); !got.Equal(want) {
+		t.Errorf("Independence Day observed at %v, want %v", got, want)
+	}
+}
+
+func TestProfileHolidays(t *testing.T) {
+	newTestHelper(t)
+	configFile, err := getConfigFilePath()
+	if err != nil {
+		t.Fatal(err)
+	}
+	writeJSON(t, configFile, config{Providers: map[string]providerConfig{"holidays-de": {Profiles: []string{"work"}}}})
+
+	cal, err := profileHolidays("work")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !cal.isDayOff(utcDate(2026, time.October, 3)) || !cal.isDayOff(utcDate(2026, time.December, 25)) {
+		t.Error("Expected German holidays to be days off in the work profile")
+	}
+	if cal, _ := profileHolidays(defaultProfile); cal.isDayOff(utcDate(2026, time.October, 2)) {
+		t.Error("Expected no holidays in the default profile")
+	}
+}
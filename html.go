@@ -0,0 +1,127 @@
+package main
+
+import (
+	"fmt"
+	"html/template"
+	"io"
+	"strings"
+	"time"
+)
+
+// htmlPage is a self-contained page: styles and script are inline so it
+// can be opened from disk or any static host without fetching anything.
+var htmlPage = template.Must(template.New("page").Parse(`<!DOCTYPE html>
+<html lang="en">
+<head>
+<meta charset="utf-8">
+<meta name="viewport" content="width=device-width, initial-scale=1">
+<title>Countdown</title>
+<style>{{.CSS}}</style>
+</head>
+<body>
+<h1>Countdown</h1>
+<ul>
+{{- range .Events}}
+<li data-ts="{{.Time}}"{{if .Expired}} class="expired"{{end}}>
+<div class="name">{{.Name}}{{if .Source}} <span class="source">[{{.Source}}]</span>{{end}}</div>
+<time datetime="{{.ISO}}">{{.When}}</time>
+<div class="countdown">{{.Countdown}}</div>
+{{- if .Tags}}
+<div class="tags">{{range .Tags}}<span>{{.}}</span>{{end}}</div>
+{{- end}}
+{{- if .Notes}}
+<div class="notes">{{.Notes}}</div>
+{{- end}}
+</li>
+{{- end}}
+</ul>
+<p class="generated">Generated {{.Generated}}</p>
+<script>{{.Script}}</script>
+</body>
+</html>
+`))
+
+// htmlEvent is an event as the page template sees it.
+type htmlEvent struct {
+	Event
+	ISO, When, Countdown string
+	Expired              bool
+}
+
+// htmlCSS mirrors newStyles: light colors by default and dark ones when the
+// browser prefers a dark scheme.
+var htmlCSS = fmt.Sprintf(`
+:root { --title: %[1]s; --desc: %[3]s; --text: %[5]s; --dim: %[7]s; --bg: #FFFFFF; }
+@media (prefers-color-scheme: dark) {
+  :root { --title: %[2]s; --desc: %[4]s; --text: %[6]s; --dim: %[8]s; --bg: #1A1A1A; }
+}
+body { font-family: ui-monospace, SFMono-Regular, Menlo, Consolas, monospace; background: var(--bg); color: var(--text); margin: 2em; }
+h1 { display: inline-block; color: %[9]s; background: %[10]s; padding: 0 .5em; font-size: 1.2em; }
+ul { list-style: none; padding: 0; }
+li { border-left: 3px solid var(--title); padding: .2em 0 .2em .8em; margin: 1em 0; }
+.name { color: var(--title); font-weight: bold; }
+.source, time, .generated, .notes { color: var(--dim); }
+.countdown { color: var(--desc); font-size: 1.3em; }
+.expired .countdown { color: %[11]s; }
+.tags span { color: %[9]s; background: %[12]s; padding: 0 .4em; margin-right: .4em; font-size: .85em; }
+.notes { white-space: pre-wrap; }
+`, cItemTitleLight, cItemTitleDark, cItemDescLight, cItemDescDark,
+	cDimmedTitleLight, cDimmedTitleDark, cDimmedDescLight, cDimmedDescDark,
+	cTextLightGray, cTitle, cError, cDetailTitle)
+
+// htmlScript ticks every countdown once a second, formatting the time left
+// exactly as formatCountdown does.
+var htmlScript = fmt.Sprintf(`
+const Y = %d, D = %d, H = %d, M = %d;
+function format(diff) {
+  const y = Math.floor(diff / Y); diff -= y * Y;
+  const d = Math.floor(diff / D); diff -= d * D;
+  const h = Math.floor(diff / H); diff -= h * H;
+  const m = Math.floor(diff / M);
+  const s = diff - m * M;
+  if (y > 0) return y + "y " + d + "d " + h + "h " + m + "m " + s + "s";
+  if (d > 0) return d + "d " + h + "h " + m + "m " + s + "s";
+  if (h > 0) return h + "h " + m + "m " + s + "s";
+  if (m > 0) return m + "m " + s + "s";
+  return s + "s";
+}
+function tick() {
+  const now = Date.now() / 1000;
+  for (const li of document.querySelectorAll("li[data-ts]")) {
+    const diff = Math.trunc(Number(li.dataset.ts) - now);
+    li.classList.toggle("expired", diff < 0);
+    li.querySelector(".countdown").textContent = diff < 0 ? %q : format(diff);
+  }
+}
+tick();
+setInterval(tick, 1000);
+`, secondsPerYear, secondsPerDay, secondsPerHour, secondsPerMinute, expiredText)
+
+// writeHTML writes a web page listing events with countdowns that keep
+// ticking in the browser.
+func writeHTML(w io.Writer, events []Event) error {
+	now := time.Now()
+	page := struct {
+		CSS       template.CSS
+		Script    template.JS
+		Events    []htmlEvent
+		Generated string
+	}{
+		CSS:       template.CSS(htmlCSS),
+		Script:    template.JS(htmlScript),
+		Generated: now.Format(time.RFC1123),
+	}
+	for _, e := range events {
+		t := time.Unix(e.Time, 0)
+		diff := int(t.Sub(now).Seconds())
+		he := htmlEvent{Event: e, ISO: t.Format(time.RFC3339), When: t.Format(time.RFC1123), Expired: diff < 0}
+		if he.Expired {
+			he.Countdown = expiredText
+		} else {
+			he.Countdown = formatCountdown(diff)
+		}
+		he.Notes = strings.TrimSpace(e.Notes)
+		page.Events = append(page.Events, he)
+	}
+	return htmlPage.Execute(w, page)
+}
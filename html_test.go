@@ -0,0 +1,47 @@
+package main
+
+import (
+	"bytes"
+	"regexp"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestWriteHTML(t *testing.T) {
+	future := time.Now().Add(50 * time.Hour).Unix()
+	events := []Event{
+		{Name: "Past", Time: 1_000_000_000},
+		{Name: "Freeze <b>&</b>", Time: future, Tags: []string{"release"}, Notes: "No merges", Source: "team"},
+	}
+	var buf bytes.Buffer
+	if err := writeHTML(&buf, events); err != nil {
+		t.Fatal(err)
+	}
+	page := buf.String()
+
+	for _, want := range []string{
+		"Freeze &lt;b&gt;&amp;&lt;/b&gt;",
+		`data-ts="1000000000" class="expired"`,
+		`<div class="countdown">Expired</div>`,
+		"[team]",
+		"<span>release</span>",
+		"No merges",
+		cItemTitleDark,
+		cError,
+		"setInterval(tick, 1000)",
+	} {
+		if !strings.Contains(page, want) {
+			t.Errorf("Expected the page to contain %q", want)
+		}
+	}
+	if strings.Contains(page, "<b>") {
+		t.Error("Expected event names to be escaped")
+	}
+	if got := regexp.MustCompile(`<div class="countdown">(2d 1h \d+m \d+s|2d 2h 0m 0s)</div>`).FindString(page); got == "" {
+		t.Errorf("Expected the initial countdown to match countdownParser, got page:\n%s", page)
+	}
+	if regexp.MustCompile(`(?i)(src|href)=|@import|url\(`).MatchString(page) {
+		t.Error("Expected the page not to load external resources")
+	}
+}
@@ -0,0 +1,201 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"strings"
+	"time"
+)
+
+const (
+	icsDateTimeUTC = "20060102T150405Z"
+	icsDateTime    = "20060102T150405"
+	icsDate        = "20060102"
+)
+
+// icsProperty is one content line of an iCalendar document, such as
+// DTSTART;TZID=Europe/Berlin:20261018T090000.
+type icsProperty struct {
+	Name   string
+	Params map[string]string
+	Value  string
+}
+
+// isICS reports whether data looks like an iCalendar document.
+func isICS(data []byte) bool {
+	return bytes.HasPrefix(bytes.TrimSpace(data), []byte("BEGIN:VCALENDAR"))
+}
+
+// unfoldICS splits an iCalendar document into logical lines, joining lines
+// that were folded with a leading space or tab (RFC 5545 section 3.1).
+func unfoldICS(data []byte) []string {
+	var lines []string
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	scanner.Buffer(nil, 1<<20)
+	for scanner.Scan() {
+		line := strings.TrimRight(scanner.Text(), "\r")
+		if len(line) > 0 && (line[0] == ' ' || line[0] == '\t') && len(lines) > 0 {
+			lines[len(lines)-1] += line[1:]
+			continue
+		}
+		lines = append(lines, line)
+	}
+	return lines
+}
+
+// parseICSLine splits a content line into its name, parameters and value.
+// Quoted parameter values may contain ':' and ';'.
+func parseICSLine(line string) (icsProperty, bool) {
+	p := icsProperty{Params: map[string]string{}}
+	inQuotes := false
+	start := 0
+	var fields []string
+	for i, r := range line {
+		switch {
+		case r == '"':
+			inQuotes = !inQuotes
+		case r == ';' && !inQuotes:
+			fields = append(fields, line[start:i])
+			start = i + 1
+		case r == ':' && !inQuotes:
+			fields = append(fields, line[start:i])
+			p.Name = strings.ToUpper(fields[0])
+			for _, param := range fields[1:] {
+				k, v, _ := strings.Cut(param, "=")
+				p.Params[strings.ToUpper(k)] = strings.Trim(v, `"`)
+			}
+			p.Value = line[i+1:]
+			return p, true
+		}
+	}
+	return p, false
+}
+
+// time parses a DATE or DATE-TIME value. Floating times and dates are taken
+// in the local time zone, as are TZIDs this system doesn't know.
+func (p icsProperty) time() (time.Time, error) {
+	loc := time.Local
+	if tzid := p.Params["TZID"]; tzid != "" {
+		if l, err := time.LoadLocation(tzid); err == nil {
+			loc = l
+		}
+	}
+	switch {
+	case strings.HasSuffix(p.Value, "Z"):
+		return time.Parse(icsDateTimeUTC, p.Value)
+	case len(p.Value) == len(icsDate):
+		return time.ParseInLocation(icsDate, p.Value, loc)
+	default:
+		return time.ParseInLocation(icsDateTime, p.Value, loc)
+	}
+}
+
+var icsTextUnescaper = strings.NewReplacer(`\\`, `\`, `\;`, ";", `\,`, ",", `\n`, "\n", `\N`, "\n")
+
+// text returns the value with TEXT escapes removed.
+func (p icsProperty) text() string {
+	return icsTextUnescaper.Replace(p.Value)
+}
+
+// list returns the values of a multi-valued TEXT property such as
+// CATEGORIES, split on unescaped commas.
+func (p icsProperty) list() []string {
+	var values []string
+	start := 0
+	for i := 0; i < len(p.Value); i++ {
+		switch p.Value[i] {
+		case '\\':
+			i++
+		case ',':
+			values = append(values, p.Value[start:i])
+			start = i + 1
+		}
+	}
+	values = append(values, p.Value[start:])
+	var out []string
+	for _, v := range values {
+		if v = strings.TrimSpace(icsTextUnescaper.Replace(v)); v != "" {
+			out = append(out, v)
+		}
+	}
+	return out
+}
+
+// icsComponent is a parsed VEVENT: its properties by name, first
+// occurrence only.
+type icsComponent map[string]icsProperty
+
+// parseICSEvents returns the VEVENT components of an iCalendar document.
+func parseICSEvents(data []byte) ([]icsComponent, error) {
+	var components []icsComponent
+	var current icsComponent
+	depth := 0
+	for n, line := range unfoldICS(data) {
+		if line == "" {
+			continue
+		}
+		p, ok := parseICSLine(line)
+		if !ok {
+			return nil, fmt.Errorf("line %d: malformed content line %q", n+1, line)
+		}
+		switch {
+		case p.Name == "BEGIN" && strings.EqualFold(p.Value, "VEVENT") && depth == 0:
+			current = icsComponent{}
+		case p.Name == "BEGIN" && current != nil:
+			// Nested components such as VALARM are skipped.
+			depth++
+		case p.Name == "END" && current != nil && depth > 0:
+			depth--
+		case p.Name == "END" && strings.EqualFold(p.Value, "VEVENT") && current != nil:
+			components = append(components, current)
+			current = nil
+		case current != nil && depth == 0:
+			if _, seen := current[p.Name]; !seen {
+				current[p.Name] = p
+			}
+		}
+	}
+	return components, nil
+}
+
+// event converts a VEVENT to an Event, using its UID as the event ID,
+// CATEGORIES as tags and DESCRIPTION as notes.
+func (c icsComponent) event() (Event, error) {
+	summary, ok := c["SUMMARY"]
+	if !ok {
+		return Event{}, fmt.Errorf("event %q has no SUMMARY", c["UID"].Value)
+	}
+	start, ok := c["DTSTART"]
+	if !ok {
+		return Event{}, fmt.Errorf("event %q has no DTSTART", summary.text())
+	}
+	ts, err := start.time()
+	if err != nil {
+		return Event{}, fmt.Errorf("event %q: %w", summary.text(), err)
+	}
+	return Event{
+		ID:    c["UID"].Value,
+		Name:  summary.text(),
+		Time:  ts.Unix(),
+		Tags:  c["CATEGORIES"].list(),
+		Notes: c["DESCRIPTION"].text(),
+	}, nil
+}
+
+// parseICS extracts the events of an iCalendar document.
+func parseICS(data []byte) ([]Event, error) {
+	components, err := parseICSEvents(data)
+	if err != nil {
+		return nil, err
+	}
+	events := make([]Event, 0, len(components))
+	for _, c := range components {
+		event, err := c.event()
+		if err != nil {
+			return nil, err
+		}
+		events = append(events, event)
+	}
+	return events, nil
+}
@@ -0,0 +1,92 @@
+package main
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+const testICS = "BEGIN:VCALENDAR\r\n" +
+	"VERSION:2.0\r\n" +
+	"PRODID:-//Test//EN\r\n" +
+	"BEGIN:VEVENT\r\n" +
+	"UID:release-1@example.com\r\n" +
+	"SUMMARY:Release 2.0\\, final\r\n" +
+	"DTSTART:20300115T120000Z\r\n" +
+	"BEGIN:VALARM\r\n" +
+	"SUMMARY:Alarm summary\r\n" +
+	"END:VALARM\r\n" +
+	"END:VEVENT\r\n" +
+	"BEGIN:VEVENT\r\n" +
+	"UID:freeze@example.com\r\n" +
+	"SUMMARY:Code freeze for the very long release name that gets\r\n" +
+	"  folded\r\n" +
+	"DTSTART;TZID=\"America/New_York\":20300110T090000\r\n" +
+	"END:VEVENT\r\n" +
+	"BEGIN:VEVENT\r\n" +
+	"UID:holiday\r\n" +
+	"SUMMARY:Holiday\r\n" +
+	"DTSTART;VALUE=DATE:20301225\r\n" +
+	"END:VEVENT\r\n" +
+	"END:VCALENDAR\r\n"
+
+func TestParseICS(t *testing.T) {
+	events, err := parseICS([]byte(testICS))
+	if err != nil {
+		t.Fatalf("parseICS() failed: %v", err)
+	}
+	if len(events) != 3 {
+		t.Fatalf("Expected 3 events, got %d: %v", len(events), events)
+	}
+
+	ny, err := time.LoadLocation("America/New_York")
+	if err != nil {
+		t.Skip("no tzdata")
+	}
+	want := []Event{
+		{ID: "release-1@example.com", Name: "Release 2.0, final", Time: time.Date(2030, 1, 15, 12, 0, 0, 0, time.UTC).Unix()},
+		{ID: "freeze@example.com", Name: "Code freeze for the very long release name that gets folded", Time: time.Date(2030, 1, 10, 9, 0, 0, 0, ny).Unix()},
+		{ID: "holiday", Name: "Holiday", Time: time.Date(2030, 12, 25, 0, 0, 0, 0, time.Local).Unix()},
+	}
+	for i := range want {
+		if !reflect.DeepEqual(events[i], want[i]) {
+			t.Errorf("events[%d] = %+v, want %+v", i, events[i], want[i])
+		}
+	}
+}
+
+func TestParseICSErrors(t *testing.T) {
+	tests := map[string]string{
+		"Missing summary": "BEGIN:VCALENDAR\nBEGIN:VEVENT\nDTSTART:20300101T000000Z\nEND:VEVENT\nEND:VCALENDAR\n",
+		"Missing start":   "BEGIN:VCALENDAR\nBEGIN:VEVENT\nSUMMARY:x\nEND:VEVENT\nEND:VCALENDAR\n",
+		"Bad start":       "BEGIN:VCALENDAR\nBEGIN:VEVENT\nSUMMARY:x\nDTSTART:tomorrow\nEND:VEVENT\nEND:VCALENDAR\n",
+		"Malformed line":  "BEGIN:VCALENDAR\nBEGIN:VEVENT\nno colon here\nEND:VEVENT\nEND:VCALENDAR\n",
+	}
+	for name, doc := range tests {
+		t.Run(name, func(t *testing.T) {
+			if _, err := parseICS([]byte(doc)); err == nil {
+				t.Error("Expected an error")
+			}
+		})
+	}
+}
+
+func TestParseICSLine(t *testing.T) {
+	p, ok := parseICSLine(`ATTENDEE;CN="Doe; John";ROLE=CHAIR:mailto:john@example.com`)
+	if !ok {
+		t.Fatal("Expected the line to parse")
+	}
+	if p.Name != "ATTENDEE" || p.Params["CN"] != "Doe; John" || p.Params["ROLE"] != "CHAIR" {
+		t.Errorf("Unexpected property: %+v", p)
+	}
+	if p.Value != "mailto:john@example.com" {
+		t.Errorf("Expected the value after the first unquoted colon, got %q", p.Value)
+	}
+	if !isICS([]byte("\n"+testICS)) || isICS([]byte("[]")) {
+		t.Error("isICS() misdetected the format")
+	}
+	if got := (icsProperty{Value: `a\nb\\c`}).text(); got != "a\nb\\c" || strings.Contains(got, `\n`) {
+		t.Errorf("text() = %q", got)
+	}
+}
@@ -0,0 +1,376 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"reflect"
+	"slices"
+	"strings"
+	"text/tabwriter"
+	"time"
+)
+
+// importOptions are the settings that shape how a file is read.
+type importOptions struct {
+	// columns maps event fields to CSV columns, by header or 1-based
+	// index. Fields not listed are found by their usual header names.
+	columns map[string]string
+	// dateLayout forces a Go time layout for CSV dates instead of
+	// detecting one.
+	dateLayout string
+	// zone is used for CSV rows that don't name their own time zone and
+	// for org-mode timestamps and todo.txt due dates.
+	zone *time.Location
+}
+
+// importFormat is a file format events can be imported from.
+type importFormat struct {
+	name  string
+	usage string
+	exts  []string
+	// detect reports whether data is in this format, for files whose
+	// extension doesn't say. It may be nil.
+	detect func(data []byte) bool
+	parse  func(data []byte, opts importOptions) ([]Event, error)
+}
+
+var importFormats = []importFormat{
+	{
+		name:   "taskwarrior",
+		usage:  "read tasks with a due date from `task export` output",
+		exts:   []string{".json"},
+		detect: looksLikeTaskwarrior,
+		parse:  parseTaskwarrior,
+	},
+	{
+		name:   "json",
+		usage:  "read an events JSON file",
+		exts:   []string{".json"},
+		detect: func(data []byte) bool { return bytes.HasPrefix(bytes.TrimSpace(data), []byte("[")) },
+		parse:  func(data []byte, _ importOptions) ([]Event, error) { return decodeEvents(data) },
+	},
+	{
+		name:   "ics",
+		usage:  "read an iCalendar file",
+		exts:   []string{".ics", ".ical", ".ifb"},
+		detect: isICS,
+		parse:  func(data []byte, _ importOptions) ([]Event, error) { return parseICS(data) },
+	},
+	{
+		name:   "vcard",
+		usage:  "read birthdays and anniversaries from a vCard file",
+		exts:   []string{".vcf", ".vcard"},
+		detect: looksLikeVCard,
+		parse:  parseVCard,
+	},
+	{
+		name:   "org",
+		usage:  "read DEADLINE and SCHEDULED entries from an org-mode file",
+		exts:   []string{".org"},
+		detect: looksLikeOrg,
+		parse:  parseOrg,
+	},
+	{
+		name:  "todotxt",
+		usage: "read tasks with a due: tag from a todo.txt file",
+		// .txt says too little, so todo.txt files are told by content.
+		detect: looksLikeTodoTxt,
+		parse:  parseTodoTxt,
+	},
+	{
+		name:   "csv",
+		usage:  "read a CSV file with a header row",
+		exts:   []string{".csv", ".tsv"},
+		parse:  parseCSV,
+		detect: looksLikeCSV,
+	},
+}
+
+// importFormatByName returns the import format called name.
+func importFormatByName(name string) (importFormat, bool) {
+	for _, f := range importFormats {
+		if f.name == name {
+			return f, true
+		}
+	}
+	return importFormat{}, false
+}
+
+// findImportFormat picks the format of a file: the one requested, else the
+// one its extension names, else the one its content looks like. When
+// several formats share an extension, such as events JSON and `task
+// export` output, the content decides between them.
+func findImportFormat(requested, path string, data []byte) (importFormat, error) {
+	if f, ok := importFormatByName(requested); ok {
+		return f, nil
+	}
+	ext := strings.ToLower(filepath.Ext(path))
+	var byExt []importFormat
+	for _, f := range importFormats {
+		if slices.Contains(f.exts, ext) {
+			byExt = append(byExt, f)
+		}
+	}
+	for _, f := range byExt {
+		if len(byExt) == 1 || f.detect != nil && f.detect(data) {
+			return f, nil
+		}
+	}
+	if len(byExt) > 0 {
+		// The more specific formats are listed first.
+		return byExt[len(byExt)-1], nil
+	}
+	for _, f := range importFormats {
+		if f.detect != nil && f.detect(data) {
+			return f, nil
+		}
+	}
+	return importFormat{}, fmt.Errorf("can't tell the format of %s, use --%s", path, strings.Join(importFormatNames(), ", --"))
+}
+
+func importFormatNames() []string {
+	names := make([]string, len(importFormats))
+	for i, f := range importFormats {
+		names[i] = f.name
+	}
+	return names
+}
+
+// parseColumnMap parses --map, e.g. "name=Milestone,date=Due Date,tags=3".
+func parseColumnMap(s string) (map[string]string, error) {
+	columns := make(map[string]string)
+	if s == "" {
+		return columns, nil
+	}
+	for _, pair := range strings.Split(s, ",") {
+		field, column, ok := strings.Cut(pair, "=")
+		field = strings.ToLower(strings.TrimSpace(field))
+		if !ok || !slices.Contains(csvFields, field) {
+			return nil, fmt.Errorf("invalid column mapping %q, want field=column with field one of %s",
+				pair, strings.Join(csvFields, ", "))
+		}
+		columns[field] = strings.TrimSpace(column)
+	}
+	return columns, nil
+}
+
+// duplicatePolicy says what an import does with an event the profile
+// already has.
+type duplicatePolicy string
+
+const (
+	policySkip      duplicatePolicy = "skip"
+	policyOverwrite duplicatePolicy = "overwrite"
+	policyKeep      duplicatePolicy = "keep"
+)
+
+const defaultDuplicateTolerance = time.Minute
+
+func parseDuplicatePolicy(s string) (duplicatePolicy, error) {
+	switch p := duplicatePolicy(s); p {
+	case policySkip, policyOverwrite, policyKeep:
+		return p, nil
+	}
+	return "", fmt.Errorf("unknown duplicate policy %q, want skip, overwrite or keep", s)
+}
+
+// findDuplicate returns the index of the event e duplicates: one with the
+// same ID, or with the same name (ignoring case) and either the same yearly
+// date or a time within tolerance of its time. It returns -1 if there is
+// none.
+func findDuplicate(events []Event, e Event, tolerance time.Duration) int {
+	if e.ID != "" {
+		if i := slices.IndexFunc(events, func(x Event) bool { return x.key() == e.ID }); i >= 0 {
+			return i
+		}
+	}
+	return slices.IndexFunc(events, func(x Event) bool {
+		if !strings.EqualFold(x.Name, e.Name) {
+			return false
+		}
+		if x.Yearly != "" && x.Yearly == e.Yearly {
+			return true
+		}
+		diff := time.Duration(x.Time-e.Time) * time.Second
+		return diff.Abs() <= tolerance
+	})
+}
+
+// importPlan is what an import does to a profile: the changes to make, the
+// events left alone as duplicates, and the resulting event list.
+type importPlan struct {
+	changes []change
+	skipped []Event
+	events  []Event
+}
+
+// planImport works out how imported events merge into a profile's events.
+// Duplicates are looked for among the events imported so far too, so a
+// file that lists an event twice only adds it once.
+func planImport(events, imported []Event, policy duplicatePolicy, tolerance time.Duration) (importPlan, error) {
+	plan := importPlan{events: events}
+	for _, event := range imported {
+		event.Source = ""
+		c := change{Action: actionAdd, After: &event}
+		if i := findDuplicate(plan.events, event, tolerance); i >= 0 {
+			existing := plan.events[i]
+			switch policy {
+			case policySkip:
+				plan.skipped = append(plan.skipped, event)
+				continue
+			case policyOverwrite:
+				event.ID = existing.key()
+				if reflect.DeepEqual(event, existing) {
+					plan.skipped = append(plan.skipped, event)
+					continue
+				}
+				c = change{Action: actionEdit, Before: &existing, After: &event}
+			case policyKeep:
+				if event.ID == existing.key() {
+					event.ID = ""
+				}
+			}
+		}
+		if event.ID == "" {
+			event.ID = newEventID()
+		}
+		var err error
+		if plan.events, err = c.apply(plan.events); err != nil {
+			return plan, err
+		}
+		plan.changes = append(plan.changes, c)
+	}
+	return plan, nil
+}
+
+// write lists every imported event with what happened to it, followed by
+// a summary.
+func (p importPlan) write(w io.Writer) error {
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	counts := make(map[changeAction]int)
+	for _, c := range p.changes {
+		counts[c.Action]++
+		verb := "added"
+		if c.Action == actionEdit {
+			verb = "updated"
+		}
+		fmt.Fprintf(tw, "%s\t%s\t%s\n", verb, time.Unix(c.After.Time, 0).Format(inputTimeFormLong), c.After.Name)
+	}
+	for _, e := range p.skipped {
+		fmt.Fprintf(tw, "skipped\t%s\t%s\n", time.Unix(e.Time, 0).Format(inputTimeFormLong), e.Name)
+	}
+	if err := tw.Flush(); err != nil {
+		return err
+	}
+	_, err := fmt.Fprintf(w, "%d added, %d updated, %d skipped\n", counts[actionAdd], counts[actionEdit], len(p.skipped))
+	return err
+}
+
+// apply saves the planned events and logs each change in the history.
+func (p importPlan) apply(profile string) error {
+	if len(p.changes) == 0 {
+		return nil
+	}
+	if err := saveProfileEvents(profile, p.events); err != nil {
+		return err
+	}
+	for _, c := range p.changes {
+		if err := appendHistory(profile, c); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func runImport(c *cli, args []string) error {
+	fs := c.newFlagSet("import")
+	formats := make(map[string]*bool)
+	for _, f := range importFormats {
+		formats[f.name] = fs.Bool(f.name, false, f.usage)
+	}
+	columnMap := fs.String("map", "", "CSV column for each field, e.g. `name=Milestone,date=Due`")
+	dateLayout := fs.String("date-format", "", "Go time `layout` of CSV dates (default: detected)")
+	zoneName := fs.String("zone", "", "time `zone` for CSV rows without one (default: local)")
+	onDuplicate := fs.String("on-duplicate", string(policySkip), "what to do with events the profile already has: `skip`, overwrite or keep")
+	tolerance := fs.Duration("tolerance", defaultDuplicateTolerance, "how far apart events with the same name may be and still be duplicates")
+	dryRun := fs.Bool("dry-run", false, "show what would be imported without saving")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if fs.NArg() != 1 {
+		return errUsage
+	}
+	requested := ""
+	for name, set := range formats {
+		if *set {
+			if requested != "" {
+				return fmt.Errorf("%w: choose one format", errUsage)
+			}
+			requested = name
+		}
+	}
+
+	policy, err := parseDuplicatePolicy(*onDuplicate)
+	if err != nil {
+		return err
+	}
+	opts := importOptions{dateLayout: *dateLayout, zone: time.Local}
+	if opts.columns, err = parseColumnMap(*columnMap); err != nil {
+		return err
+	}
+	if *zoneName != "" {
+		if opts.zone, err = parseZone(*zoneName); err != nil {
+			return err
+		}
+	}
+	// "-" reads stdin, whose format is told by content.
+	path := fs.Arg(0)
+	var data []byte
+	if path == "-" {
+		path = "stdin"
+		data, err = io.ReadAll(c.stdin)
+	} else {
+		data, err = os.ReadFile(path)
+	}
+	if err != nil {
+		return err
+	}
+	format, err := findImportFormat(requested, path, data)
+	if err != nil {
+		return err
+	}
+	events, err := format.parse(data, opts)
+	if err != nil {
+		return fmt.Errorf("%s: %w", path, err)
+	}
+
+	current, err := readProfileEvents(c.profile)
+	if err != nil {
+		return err
+	}
+	// Imported yearly events are on their next occurrence, so compare
+	// them with the profile's on theirs rather than a past year's.
+	now := time.Now()
+	for i := range current {
+		current[i] = current[i].occurrence(now)
+	}
+	plan, err := planImport(current, events, policy, *tolerance)
+	if err != nil {
+		return err
+	}
+	if !*dryRun {
+		if err := plan.apply(c.profile); err != nil {
+			return err
+		}
+	}
+	if err := plan.write(c.stdout); err != nil {
+		return err
+	}
+	if *dryRun {
+		fmt.Fprintf(c.stdout, "Dry run: nothing was saved to %s\n", c.profile)
+	}
+	return nil
+}
@@ -0,0 +1,355 @@
+package main
+
+import (
+	"bytes"
+	"io"
+	"os"
+	"path/filepath"
+	"reflect"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	tea "charm.land/bubbletea/v2"
+)
+
+func TestFindImportFormat(t *testing.T) {
+	tests := []struct {
+		requested, path, data, want string
+	}{
+		{path: "deadlines.csv", data: "name,date\n", want: "csv"},
+		{path: "cal.ICS", data: "", want: "ics"},
+		{path: "export", data: "  [{\"name\":\"A\",\"ts\":1}]", want: "json"},
+		{path: "export", data: testICS, want: "ics"},
+		{path: "stdin", data: "Title;Due Date;Labels\nLaunch;2030-02-01;\n", want: "csv"},
+		{requested: "csv", path: "export.txt", data: "[", want: "csv"},
+	}
+	for _, tt := range tests {
+		f, err := findImportFormat(tt.requested, tt.path, []byte(tt.data))
+		if err != nil || f.name != tt.want {
+			t.Errorf("findImportFormat(%q, %q) = %q, %v, want %q", tt.requested, tt.path, f.name, err, tt.want)
+		}
+	}
+	if _, err := findImportFormat("", "notes.txt", []byte("hello")); err == nil {
+		t.Error("Expected an error for an unrecognised file")
+	}
+}
+
+func TestParseColumnMap(t *testing.T) {
+	got, err := parseColumnMap("name=Milestone, date = Due Date,tags=3")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got["name"] != "Milestone" || got["date"] != "Due Date" || got["tags"] != "3" {
+		t.Errorf("parseColumnMap() = %v", got)
+	}
+	for _, bad := range []string{"owner=Owner", "name"} {
+		if _, err := parseColumnMap(bad); err == nil {
+			t.Errorf("Expected parseColumnMap(%q) to fail", bad)
+		}
+	}
+}
+
+func TestImportAndExportCommands(t *testing.T) {
+	th := newTestHelper(t)
+	if err := saveProfileEvents(defaultProfile, []Event{}); err != nil {
+		t.Fatal(err)
+	}
+	path := filepath.Join(th.testConfigDir, "deadlines.csv")
+	csv := "Milestone,Due,Labels\nCode freeze,2030-01-15,release\nLaunch,2030-02-01,\n"
+	if err := os.WriteFile(path, []byte(csv), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	var out bytes.Buffer
+	c := &cli{profile: defaultProfile, stdout: &out, stderr: &out}
+	if err := c.run([]string{"import", "--dry-run", "--map", "name=Milestone", path}); err != nil {
+		t.Fatalf("import --dry-run failed: %v", err)
+	}
+	if !strings.Contains(out.String(), "Code freeze") || !strings.Contains(out.String(), "2 added, 0 updated, 0 skipped") {
+		t.Errorf("Expected a preview of both events, got %q", out.String())
+	}
+	if events, _ := readProfileEvents(defaultProfile); len(events) != 0 {
+		t.Fatalf("Expected a dry run not to save, got %v", events)
+	}
+
+	if err := c.run([]string{"import", "--map", "name=Milestone", path}); err != nil {
+		t.Fatalf("import failed: %v", err)
+	}
+	events, err := readProfileEvents(defaultProfile)
+	if err != nil || len(events) != 2 || events[0].Name != "Code freeze" || events[0].ID == "" {
+		t.Fatalf("Expected both events with IDs, got %v (err %v)", events, err)
+	}
+	if entries, _ := readHistory(); len(entries) != 2 || entries[0].Action != actionAdd {
+		t.Errorf("Expected each import to be logged, got %v", entries)
+	}
+
+	out.Reset()
+	if err := c.run([]string{"import", "--map", "name=Milestone", path}); err != nil {
+		t.Fatalf("second import failed: %v", err)
+	}
+	if again, _ := readProfileEvents(defaultProfile); len(again) != 2 {
+		t.Errorf("Expected importing the same file twice to skip duplicates, got %v", again)
+	}
+	if !strings.Contains(out.String(), "0 added, 0 updated, 2 skipped") {
+		t.Errorf("Expected a summary of skipped duplicates, got %q", out.String())
+	}
+
+	out.Reset()
+	if err := c.run([]string{"export", "--csv"}); err != nil {
+		t.Fatalf("export failed: %v", err)
+	}
+	exported, err := parseCSV(out.Bytes(), importOptions{})
+	if err != nil || !equalEvents(exported, events) {
+		t.Errorf("Expected the export to read back as the saved events, got %v (err %v)", exported, err)
+	}
+
+	if err := c.run([]string{"export"}); err == nil {
+		t.Error("Expected export without a format to fail")
+	}
+}
+
+func TestExportOwnEvents(t *testing.T) {
+	newTestHelper(t)
+	future := time.Now().Add(24 * time.Hour).Unix()
+	writeJSON(t, systemEventsFile, []Event{{Name: "Company Holiday", Time: future}})
+	birthday := Event{ID: "ada", Name: "Ada's birthday", Yearly: "1815-12-10", Tags: []string{"birthday"}}
+	birthday = birthday.occurrence(time.Now())
+	if err := saveProfileEvents(defaultProfile, []Event{birthday}); err != nil {
+		t.Fatal(err)
+	}
+
+	var out bytes.Buffer
+	c := &cli{profile: defaultProfile, stdout: &out, stderr: io.Discard}
+	if err := c.run([]string{"export", "--csv"}); err != nil {
+		t.Fatalf("export failed: %v", err)
+	}
+	exported, err := parseCSV(out.Bytes(), importOptions{})
+	if err != nil || !equalEvents(exported, []Event{birthday}) {
+		t.Errorf("Expected only the profile's own event, yearly date included, got %v (err %v)", exported, err)
+	}
+
+	out.Reset()
+	if err := c.run([]string{"export", "--csv", "--all"}); err != nil {
+		t.Fatalf("export --all failed: %v", err)
+	}
+	if !strings.Contains(out.String(), "Company Holiday") {
+		t.Errorf("Expected --all to include read-only events, got %q", out.String())
+	}
+}
+
+func TestImportFromStdin(t *testing.T) {
+	newTestHelper(t)
+	var out bytes.Buffer
+	c := &cli{profile: defaultProfile, stdin: strings.NewReader(testICS), stdout: &out, stderr: &out}
+	if err := c.run([]string{"import", "-"}); err != nil {
+		t.Fatalf("import - failed: %v", err)
+	}
+	if events, _ := readProfileEvents(defaultProfile); len(events) != 3 {
+		t.Errorf("Expected the calendar on stdin to be imported, got %v", events)
+	}
+
+	c.stdin = strings.NewReader("Code freeze,2030-01-15\n")
+	if err := c.run([]string{"import", "-"}); err == nil || !strings.Contains(err.Error(), "stdin") {
+		t.Errorf("Expected undetectable stdin to ask for a format, got %v", err)
+	}
+	c.stdin = strings.NewReader("name,date\nCode freeze,2030-01-15\n")
+	if err := c.run([]string{"import", "-"}); err != nil {
+		t.Fatalf("import - of CSV failed: %v", err)
+	}
+	if events, _ := readProfileEvents(defaultProfile); len(events) != 4 {
+		t.Errorf("Expected the CSV on stdin to be imported, got %v", events)
+	}
+}
+
+func TestImportKeepSurvivesReload(t *testing.T) {
+	newTestHelper(t)
+	launch := Event{ID: "launch", Name: "Launch", Time: time.Now().Add(48 * time.Hour).Unix()}
+	if err := saveProfileEvents(defaultProfile, []Event{launch}); err != nil {
+		t.Fatal(err)
+	}
+	file := filepath.Join(t.TempDir(), "launch.json")
+	writeJSON(t, file, []Event{launch})
+	c := &cli{profile: defaultProfile, stdout: io.Discard, stderr: io.Discard}
+	if err := c.run([]string{"import", "--on-duplicate", "keep", file}); err != nil {
+		t.Fatalf("import --on-duplicate keep failed: %v", err)
+	}
+	// The copy has to survive loading, or the next save would delete it.
+	events, err := loadProfileEvents(defaultProfile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(events) != 2 || events[0].Name != "Launch" || events[1].Name != "Launch" || events[0].ID == events[1].ID {
+		t.Errorf("Expected both copies of the event after reloading, got %v", events)
+	}
+}
+
+func TestRemoveKeptDuplicate(t *testing.T) {
+	newTestHelper(t)
+	launch := Event{ID: "launch", Name: "Launch", Time: time.Now().Add(48 * time.Hour).Unix()}
+	if err := saveProfileEvents(defaultProfile, []Event{launch}); err != nil {
+		t.Fatal(err)
+	}
+	file := filepath.Join(t.TempDir(), "launch.json")
+	writeJSON(t, file, []Event{launch})
+	c := &cli{profile: defaultProfile, stdout: io.Discard, stderr: io.Discard}
+	if err := c.run([]string{"import", "--on-duplicate", "keep", file}); err != nil {
+		t.Fatalf("import --on-duplicate keep failed: %v", err)
+	}
+
+	m, err := NewMainModel(defaultProfile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	m.events.Select(1)
+	copyID := m.events.SelectedItem().(Event).ID
+	if copyID == launch.ID {
+		t.Fatalf("Expected the imported copy second, got %v", m.listEvents())
+	}
+	updated, _ := m.Update(tea.KeyPressMsg{Code: '-', Text: "-"})
+	m = updated.(MainModel)
+
+	events, err := readProfileEvents(defaultProfile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(events) != 1 || events[0].ID != launch.ID {
+		t.Errorf("Expected removing the copy to keep the original, got %v", events)
+	}
+}
+
+func TestReimportVCardInLaterYear(t *testing.T) {
+	newTestHelper(t)
+	file := filepath.Join(t.TempDir(), "contacts.vcf")
+	if err := os.WriteFile(file, []byte(testVCard), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	c := &cli{profile: defaultProfile, stdout: io.Discard, stderr: io.Discard}
+	if err := c.run([]string{"import", file}); err != nil {
+		t.Fatalf("import failed: %v", err)
+	}
+	// Saved a year ago, the profile still has last year's dates.
+	events, err := readProfileEvents(defaultProfile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i := range events {
+		events[i] = events[i].occurrence(time.Now().AddDate(-1, 0, 0))
+	}
+	if err := saveProfileEvents(defaultProfile, events); err != nil {
+		t.Fatal(err)
+	}
+
+	var out bytes.Buffer
+	c.stdout = &out
+	if err := c.run([]string{"import", file}); err != nil {
+		t.Fatalf("import failed: %v", err)
+	}
+	if got, err := readProfileEvents(defaultProfile); err != nil || len(got) != len(events) {
+		t.Errorf("Expected no birthdays to be added again, got %v (err %v)\n%s", got, err, out.String())
+	}
+	if !strings.Contains(out.String(), "0 added, 0 updated, 4 skipped") {
+		t.Errorf("Expected every contact to be skipped:\n%s", out.String())
+	}
+}
+
+func TestPlanImport(t *testing.T) {
+	launch := Event{ID: "launch", Name: "Launch", Time: 1_000_000}
+	legacy := Event{Name: "Legacy", Time: 2_000_000}
+	events := []Event{launch, legacy}
+
+	tests := []struct {
+		name                    string
+		imported                []Event
+		policy                  duplicatePolicy
+		added, updated, skipped int
+		want                    []string
+	}{
+		{
+			name:     "New events are added",
+			imported: []Event{{Name: "New", Time: 3_000_000}},
+			policy:   policySkip,
+			added:    1,
+			want:     []string{"Launch", "Legacy", "New"},
+		},
+		{
+			name:     "Same ID is skipped",
+			imported: []Event{{ID: "launch", Name: "Launch (moved)", Time: 1_500_000}},
+			policy:   policySkip,
+			skipped:  1,
+			want:     []string{"Launch", "Legacy"},
+		},
+		{
+			name:     "Same name within tolerance is skipped",
+			imported: []Event{{Name: "legacy", Time: 2_000_030}},
+			policy:   policySkip,
+			skipped:  1,
+			want:     []string{"Launch", "Legacy"},
+		},
+		{
+			name:     "Same name outside tolerance is added",
+			imported: []Event{{Name: "Legacy", Time: 2_000_600}},
+			policy:   policySkip,
+			added:    1,
+			want:     []string{"Launch", "Legacy", "Legacy"},
+		},
+		{
+			name:     "Overwrite updates in place",
+			imported: []Event{{ID: "launch", Name: "Launch (moved)", Time: 2_500_000}},
+			policy:   policyOverwrite,
+			updated:  1,
+			want:     []string{"Legacy", "Launch (moved)"},
+		},
+		{
+			name:     "Overwrite with identical event is skipped",
+			imported: []Event{launch},
+			policy:   policyOverwrite,
+			skipped:  1,
+			want:     []string{"Launch", "Legacy"},
+		},
+		{
+			name:     "Keep adds a copy",
+			imported: []Event{launch},
+			policy:   policyKeep,
+			added:    1,
+			want:     []string{"Launch", "Launch", "Legacy"},
+		},
+		{
+			name:     "Duplicates within the file",
+			imported: []Event{{Name: "New", Time: 3_000_000}, {Name: "New", Time: 3_000_000}},
+			policy:   policySkip,
+			added:    1,
+			skipped:  1,
+			want:     []string{"Launch", "Legacy", "New"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			plan, err := planImport(events, tt.imported, tt.policy, defaultDuplicateTolerance)
+			if err != nil {
+				t.Fatal(err)
+			}
+			counts := map[changeAction]int{}
+			for _, c := range plan.changes {
+				counts[c.Action]++
+			}
+			if counts[actionAdd] != tt.added || counts[actionEdit] != tt.updated || len(plan.skipped) != tt.skipped {
+				t.Errorf("Got %d added, %d updated, %d skipped", counts[actionAdd], counts[actionEdit], len(plan.skipped))
+			}
+			if got := names(plan.events); !slices.Equal(got, tt.want) {
+				t.Errorf("Events = %v, want %v", got, tt.want)
+			}
+			ids := map[string]bool{}
+			for _, e := range plan.events {
+				if ids[e.key()] {
+					t.Errorf("Duplicate ID %q in %v", e.key(), plan.events)
+				}
+				ids[e.key()] = true
+			}
+		})
+	}
+	if !reflect.DeepEqual(events, []Event{launch, legacy}) {
+		t.Errorf("planImport() modified its input: %v", events)
+	}
+}
@@ -1,11 +1,16 @@
 package main
 
 import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
 	"encoding/json"
 	"errors"
+	"flag"
 	"fmt"
 	"os"
 	"path/filepath"
+	"slices"
 	"strconv"
 	"strings"
 	"time"
@@ -46,12 +51,18 @@ const (
 	cDimmedDescDark    = "#999999"
 	cDimmedDescLight   = "#555555"
 	cTextLightGray     = "#FFFDF5"
+	expiredText        = "Expired"
+	todayText          = "Today"
 )
 
 // errStyle is package-level because countdownParser is called from
 // Event.Description, which has no access to the model's styles.
 var errStyle = lipgloss.NewStyle().Foreground(lipgloss.Color(cError))
 
+// clock is the time the TUI counts down from. countdown render sets it to
+// draw a frame for a fixed moment.
+var clock = time.Now
+
 // getEventsFilePath returns the path to the events file in the user's config directory
 func getEventsFilePath() (string, error) {
 	configDir, err := os.UserConfigDir()
@@ -89,6 +100,8 @@ type styles struct {
 	detailsLeft   lipgloss.Style
 	detailsRight  lipgloss.Style
 	help          lipgloss.Style
+	tab           lipgloss.Style
+	activeTab     lipgloss.Style
 }
 
 func newStyles(isDark bool) styles {
@@ -161,17 +174,30 @@ func newStyles(isDark bool) styles {
 		help: list.DefaultStyles(isDark).HelpStyle.
 			Width(defaultListWidth).
 			Height(defaultHelpHeight),
+		tab: lipgloss.NewStyle().
+			Foreground(normalText).
+			Padding(0, 1),
+		activeTab: lipgloss.NewStyle().
+			Foreground(lipgloss.Color(cTextLightGray)).
+			Background(lipgloss.Color(cDetailTitle)).
+			Padding(0, 1),
 	}
 }
 
 type keymap struct {
-	Add    key.Binding
-	Remove key.Binding
-	Next   key.Binding
-	Prev   key.Binding
-	Enter  key.Binding
-	Back   key.Binding
-	Quit   key.Binding
+	Add         key.Binding
+	Remove      key.Binding
+	Next        key.Binding
+	Prev        key.Binding
+	NextProfile key.Binding
+	PrevProfile key.Binding
+	Edit        key.Binding
+	Undo        key.Binding
+	Redo        key.Binding
+	Sync        key.Binding
+	Enter       key.Binding
+	Back        key.Binding
+	Quit        key.Binding
 }
 
 // Keymap reusable key mappings shared across models
@@ -190,6 +216,30 @@ var Keymap = keymap{
 	Prev: key.NewBinding(
 		key.WithKeys("shift+tab"),
 	),
+	NextProfile: key.NewBinding(
+		key.WithKeys("tab"),
+		key.WithHelp("tab", "profile"),
+	),
+	PrevProfile: key.NewBinding(
+		key.WithKeys("shift+tab"),
+	),
+	Edit: key.NewBinding(
+		key.WithKeys("e"),
+		key.WithHelp("e", "edit"),
+	),
+	// "u" already pages the list, so undo/redo use the editor-style chords.
+	Undo: key.NewBinding(
+		key.WithKeys("ctrl+z"),
+		key.WithHelp("ctrl+z", "undo"),
+	),
+	Redo: key.NewBinding(
+		key.WithKeys("ctrl+y"),
+		key.WithHelp("ctrl+y", "redo"),
+	),
+	Sync: key.NewBinding(
+		key.WithKeys("s"),
+		key.WithHelp("s", "sync"),
+	),
 	Enter: key.NewBinding(
 		key.WithKeys("enter"),
 	),
@@ -221,46 +271,150 @@ const (
 )
 
 type Event struct {
+	// ID identifies the event across edits and machines. Events written
+	// before IDs existed have none; see key.
+	ID   string `json:"id,omitempty"`
 	Name string `json:"name"`
 	Time int64  `json:"ts"`
+	// Tags and Notes are free-form details carried over from imports and
+	// calendars. The TUI shows them but doesn't edit them.
+	Tags  []string `json:"tags,omitempty"`
+	Notes string   `json:"notes,omitempty"`
+	// Yearly is the date a yearly event such as a birthday recurs on, as
+	// YYYY-MM-DD, or --MM-DD when the year isn't known. Time is then its
+	// next occurrence, updated whenever the events are loaded.
+	Yearly string `json:"yearly,omitempty"`
+	// Source names the read-only layer the event was loaded from. It is
+	// empty for the profile's own, editable events.
+	Source string `json:"-"`
 }
 
 func (e Event) ToBasicString() string {
 	return time.Unix(e.Time, 0).String()
 }
 
-func (e Event) Title() string       { return e.Name }
-func (e Event) Description() string { return countdownParser(e.Time) }
+// key returns the event's ID, or for older events without one, an ID
+// derived from the name and time so every machine agrees on it.
+func (e Event) key() string {
+	if e.ID != "" {
+		return e.ID
+	}
+	sum := sha256.Sum256([]byte(e.Name + "\x00" + strconv.FormatInt(e.Time, 10)))
+	return hex.EncodeToString(sum[:8])
+}
+
+// newEventID returns a random ID for a new event.
+func newEventID() string {
+	b := make([]byte, 8)
+	_, _ = rand.Read(b)
+	return hex.EncodeToString(b)
+}
+
+// ReadOnly reports whether the event comes from a read-only layer.
+func (e Event) ReadOnly() bool { return e.Source != "" }
+
+// SourceName returns the name of the layer the event belongs to.
+func (e Event) SourceName() string {
+	if e.Source == "" {
+		return personalSourceName
+	}
+	return e.Source
+}
+
+// hasTag reports whether the event has a tag, ignoring case.
+func (e Event) hasTag(tag string) bool {
+	return slices.ContainsFunc(e.Tags, func(t string) bool { return strings.EqualFold(t, tag) })
+}
+
+// Title shows a badge naming the source of read-only events.
+func (e Event) Title() string {
+	if e.ReadOnly() {
+		return e.Name + " [" + e.Source + "]"
+	}
+	return e.Name
+}
+
+func (e Event) Description() string { return e.countdown() }
+
+// countdown is the time left as the list shows it, or Today during a
+// yearly event's day.
+func (e Event) countdown() string {
+	if e.onItsDay(clock()) {
+		return todayText
+	}
+	return countdownParser(e.Time)
+}
 func (e Event) FilterValue() string { return e.Name }
 
 type MainModel struct {
 	state       sessionState
 	focus       int
+	profile     string
+	profiles    []string
 	events      list.Model
 	inputs      []textinput.Model
 	timer       timer.Model
 	inputStatus string
-	styles      styles
-	isDark      bool
+	// editing is the event being edited in the input form, or nil when
+	// the form adds a new event.
+	editing *Event
+	// undo and redo hold the changes made in the active profile this
+	// session, most recent last.
+	undo []change
+	redo []change
+	// syncEnabled is set when config.json configures a git remote or a
+	// CalDAV calendar.
+	syncEnabled bool
+	// feedsEnabled is set when config.json lists at least one feed or plugin.
+	feedsEnabled bool
+	// holidays are the days off of the active profile's holiday sources,
+	// left out when counting business days.
+	holidays *holidayCalendar
+	styles   styles
+	isDark   bool
+	// dryRun keeps changes in memory instead of saving them, for
+	// countdown render.
+	dryRun bool
 }
 
-func NewMainModel() (MainModel, error) {
+// syncDoneMsg reports the end of a background git sync.
+type syncDoneMsg struct{ err error }
+
+// feedTickMsg asks for feeds that are due to be refetched.
+type feedTickMsg struct{}
+
+// feedsRefreshedMsg reports the end of a background feed refresh.
+type feedsRefreshedMsg struct {
+	changed bool
+	errs    []error
+}
+
+func NewMainModel(profile string) (MainModel, error) {
 	m := MainModel{
-		state: showEvents,
-		timer: timer.New(timeout),
+		state:   showEvents,
+		profile: profile,
+		timer:   timer.New(timeout),
 		// Assume a dark background until the terminal reports otherwise
 		// via tea.BackgroundColorMsg.
 		isDark: true,
 	}
 	m.styles = newStyles(m.isDark)
-	events, err := readEventsFile()
+	events, err := loadProfileEvents(profile)
 	if err != nil {
 		return m, err
 	}
-	items := make([]list.Item, len(events))
-	for i := range events {
-		items[i] = events[i]
+	if m.holidays, err = profileHolidays(profile); err != nil {
+		return m, err
 	}
+	if m.profiles, err = listProfiles(); err != nil {
+		return m, err
+	}
+	cfg, err := loadConfig()
+	if err != nil {
+		return m, err
+	}
+	m.syncEnabled = cfg.Sync.enabled() || cfg.CalDAV.enabled()
+	m.feedsEnabled = len(cfg.feeds()) > 0
 	m.inputs = make([]textinput.Model, 2)
 	for i := range m.inputs {
 		t := textinput.New()
@@ -278,14 +432,74 @@ func NewMainModel() (MainModel, error) {
 		}
 		m.inputs[i] = t
 	}
-	m.events = list.New(items, m.newDelegate(), defaultListWidth, defaultListHeight)
-	m.events.Title = "Events"
+	m.events = list.New(nil, m.newDelegate(), defaultListWidth, defaultListHeight)
 	m.events.SetShowPagination(true)
 	m.applyStyles()
+	m.setEvents(events)
+	return m, nil
+}
+
+// setEvents replaces the list contents with the active profile's events.
+func (m *MainModel) setEvents(events []Event) {
+	m.events.ResetFilter()
+	m.setItems(events)
+	m.events.Select(0)
+	m.events.Title = m.profile
+	m.undo, m.redo = nil, nil
+}
+
+// setItems replaces the list items and returns to the list view.
+func (m *MainModel) setItems(events []Event) {
+	items := make([]list.Item, len(events))
+	for i := range events {
+		items[i] = events[i]
+	}
+	m.events.SetItems(items)
+	m.showList()
+}
+
+// showList switches to the list view, or the placeholder if it's empty.
+func (m *MainModel) showList() {
+	m.state = showEvents
 	if len(m.events.Items()) == 0 {
 		m.state = noEvents
 	}
-	return m, nil
+}
+
+// listEvents returns every event in the list, including read-only ones.
+func (m MainModel) listEvents() []Event {
+	items := m.events.Items()
+	events := make([]Event, len(items))
+	for i, item := range items {
+		events[i] = item.(Event)
+	}
+	return events
+}
+
+// switchProfile activates the profile offset by delta from the current
+// one, wrapping around at either end.
+func (m *MainModel) switchProfile(delta int) tea.Cmd {
+	if len(m.profiles) < 2 {
+		return nil
+	}
+	current := 0
+	for i, name := range m.profiles {
+		if name == m.profile {
+			current = i
+		}
+	}
+	next := m.profiles[(current+delta+len(m.profiles))%len(m.profiles)]
+	events, err := loadProfileEvents(next)
+	if err != nil {
+		return m.events.NewStatusMessage(errStyle.Render(err.Error()))
+	}
+	holidays, err := profileHolidays(next)
+	if err != nil {
+		return m.events.NewStatusMessage(errStyle.Render(err.Error()))
+	}
+	m.profile, m.holidays = next, holidays
+	m.setEvents(events)
+	return nil
 }
 
 // newDelegate builds the list item delegate from the current styles.
@@ -297,7 +511,12 @@ func (m MainModel) newDelegate() list.DefaultDelegate {
 	delegate.Styles.DimmedTitle = m.styles.dimmedTitle
 	delegate.Styles.DimmedDesc = m.styles.dimmedDesc
 	delegate.ShortHelpFunc = func() []key.Binding { return []key.Binding{Keymap.Add, Keymap.Remove} }
-	delegate.FullHelpFunc = func() [][]key.Binding { return [][]key.Binding{{Keymap.Add, Keymap.Remove}} }
+	delegate.FullHelpFunc = func() [][]key.Binding {
+		return [][]key.Binding{
+			{Keymap.Add, Keymap.Remove, Keymap.Edit},
+			{Keymap.Undo, Keymap.Redo, Keymap.NextProfile, Keymap.Sync},
+		}
+	}
 	return delegate
 }
 
@@ -316,12 +535,84 @@ func (m *MainModel) applyStyles() {
 }
 
 func (m MainModel) Init() tea.Cmd {
-	return tea.Batch(m.timer.Init(), tea.RequestBackgroundColor)
+	return tea.Batch(m.timer.Init(), tea.RequestBackgroundColor, m.syncCmd(), m.refreshFeedsCmd())
+}
+
+// refreshFeedsCmd fetches feeds that are due in the background.
+func (m MainModel) refreshFeedsCmd() tea.Cmd {
+	if !m.feedsEnabled {
+		return nil
+	}
+	return func() tea.Msg {
+		changed, errs := refreshFeeds(false)
+		return feedsRefreshedMsg{changed: changed, errs: errs}
+	}
+}
+
+// syncCmd pulls and pushes events in the background when sync is enabled.
+func (m MainModel) syncCmd() tea.Cmd {
+	if !m.syncEnabled {
+		return nil
+	}
+	return func() tea.Msg { return syncDoneMsg{err: syncEvents()} }
+}
+
+// reloadEvents rereads the profiles and the active profile's events after
+// something outside the TUI changed them, staying in the input form if it
+// is open. Changes apply by event ID, so undo and redo survive.
+func (m *MainModel) reloadEvents() error {
+	profiles, err := listProfiles()
+	if err != nil {
+		return err
+	}
+	events, err := loadProfileEvents(m.profile)
+	if err != nil {
+		return err
+	}
+	if m.holidays, err = profileHolidays(m.profile); err != nil {
+		return err
+	}
+	state, index := m.state, m.events.Index()
+	undo, redo := m.undo, m.redo
+	m.profiles = profiles
+	m.setEvents(events)
+	m.undo, m.redo = undo, redo
+	m.events.Select(min(index, max(len(events)-1, 0)))
+	if state == showInput {
+		m.state = showInput
+	}
+	return nil
 }
 
 func (m MainModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 	var cmds []tea.Cmd
 	switch msg := msg.(type) {
+	case syncDoneMsg:
+		if msg.err == nil {
+			msg.err = m.reloadEvents()
+		}
+		if msg.err != nil {
+			return m, m.events.NewStatusMessage(errStyle.Render("Sync failed: " + msg.err.Error()))
+		}
+		return m, m.events.NewStatusMessage("Synced")
+	case feedTickMsg:
+		return m, m.refreshFeedsCmd()
+	case feedsRefreshedMsg:
+		next := tea.Tick(feedCheckInterval, func(time.Time) tea.Msg { return feedTickMsg{} })
+		errs := msg.errs
+		if msg.changed {
+			if err := m.reloadEvents(); err != nil {
+				errs = append(errs, err)
+			}
+		}
+		if len(errs) > 0 {
+			msgs := make([]string, len(errs))
+			for i, err := range errs {
+				msgs[i] = err.Error()
+			}
+			return m, tea.Batch(next, m.events.NewStatusMessage(errStyle.Render(strings.Join(msgs, "; "))))
+		}
+		return m, next
 	case tea.BackgroundColorMsg:
 		m.isDark = msg.IsDark()
 		m.styles = newStyles(m.isDark)
@@ -329,7 +620,7 @@ func (m MainModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		return m, nil
 	case tea.WindowSizeMsg:
 		_, v := m.styles.app.GetFrameSize()
-		m.events.SetSize(defaultListWidth, msg.Height-v)
+		m.events.SetSize(defaultListWidth, msg.Height-v-lipgloss.Height(m.tabsView()))
 		return m, nil
 	case tea.KeyPressMsg:
 		if msg.String() == "ctrl+c" {
@@ -345,6 +636,14 @@ func (m MainModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 				return m, tea.Quit
 			case key.Matches(msg, Keymap.Add):
 				m.state = showInput
+			case key.Matches(msg, Keymap.Undo):
+				cmds = append(cmds, m.undoChange())
+			case key.Matches(msg, Keymap.Redo):
+				cmds = append(cmds, m.redoChange())
+			case key.Matches(msg, Keymap.NextProfile):
+				cmds = append(cmds, m.switchProfile(1))
+			case key.Matches(msg, Keymap.PrevProfile):
+				cmds = append(cmds, m.switchProfile(-1))
 			}
 		}
 	case showEvents:
@@ -355,13 +654,38 @@ func (m MainModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 			case key.Matches(msg, Keymap.Add):
 				m.state = showInput
 			case key.Matches(msg, Keymap.Remove):
-				m.events.RemoveItem(m.events.Index())
-				if err := m.saveEventsToFile(); err != nil {
-					cmds = append(cmds, m.events.NewStatusMessage(errStyle.Render(err.Error())))
+				event, ok := m.events.SelectedItem().(Event)
+				if !ok {
+					break
+				}
+				if event.ReadOnly() {
+					cmds = append(cmds, m.readOnlyStatus(event))
+					break
 				}
-				if len(m.events.Items()) == 0 {
-					m.state = noEvents
+				cmds = append(cmds, m.commitChange(change{Action: actionRemove, Before: &event}))
+			case key.Matches(msg, Keymap.Edit):
+				event, ok := m.events.SelectedItem().(Event)
+				if !ok {
+					break
 				}
+				if event.ReadOnly() {
+					cmds = append(cmds, m.readOnlyStatus(event))
+					break
+				}
+				m.editing = &event
+				m.inputs[inputNameField].SetValue(event.Name)
+				m.inputs[inputTimeField].SetValue(time.Unix(event.Time, 0).Format(inputTimeFormLong))
+				m.state = showInput
+			case key.Matches(msg, Keymap.Undo):
+				cmds = append(cmds, m.undoChange())
+			case key.Matches(msg, Keymap.Redo):
+				cmds = append(cmds, m.redoChange())
+			case key.Matches(msg, Keymap.Sync):
+				cmds = append(cmds, m.syncCmd())
+			case key.Matches(msg, Keymap.NextProfile):
+				cmds = append(cmds, m.switchProfile(1))
+			case key.Matches(msg, Keymap.PrevProfile):
+				cmds = append(cmds, m.switchProfile(-1))
 			}
 		}
 		var cmd tea.Cmd
@@ -372,7 +696,7 @@ func (m MainModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 			switch {
 			case key.Matches(msg, Keymap.Back):
 				m.resetInputs()
-				m.state = showEvents
+				m.showList()
 			case key.Matches(msg, Keymap.Next):
 				m.focus++
 				if m.focus > int(inputSubmitButton) {
@@ -389,7 +713,7 @@ func (m MainModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 					m.focus++
 				case inputCancelButton:
 					m.resetInputs()
-					m.state = showEvents
+					m.showList()
 				case inputSubmitButton:
 					event, err := m.validateInputs()
 					if err != nil {
@@ -399,12 +723,21 @@ func (m MainModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 						m.inputStatus = fmt.Sprintf("Error: %v", err)
 						break
 					}
-					m.insertEvent(event)
-					if err := m.saveEventsToFile(); err != nil {
-						cmds = append(cmds, m.events.NewStatusMessage(errStyle.Render(err.Error())))
+					c := change{Action: actionAdd, After: &event}
+					if m.editing != nil {
+						event.ID = m.editing.key()
+						event.Tags, event.Notes = m.editing.Tags, m.editing.Notes
+						if d, err := parseYearly(m.editing.Yearly); err == nil {
+							// Keep it yearly, on the date it was moved to.
+							t := time.Unix(event.Time, 0)
+							d.month, d.day = t.Month(), t.Day()
+							event.Yearly = d.String()
+							event = event.occurrence(clock())
+						}
+						c = change{Action: actionEdit, Before: m.editing, After: &event}
 					}
 					m.resetInputs()
-					m.state = showEvents
+					cmds = append(cmds, m.commitChange(c))
 				}
 			}
 		}
@@ -425,12 +758,16 @@ func (m MainModel) View() tea.View {
 	var content string
 	switch m.state {
 	case noEvents:
-		content = m.styles.input.Render("No events, add one with '+'")
+		content = lipgloss.JoinVertical(lipgloss.Left,
+			m.tabsView(),
+			m.styles.input.Render("No events, add one with '+'"))
 	case showInput:
 		content = m.inputView()
 	default:
 		listStr := m.styles.app.Render(m.events.View())
-		content = lipgloss.JoinHorizontal(0.05, listStr, m.detailsString())
+		content = lipgloss.JoinVertical(lipgloss.Left,
+			m.tabsView(),
+			lipgloss.JoinHorizontal(0.05, listStr, m.detailsString()))
 	}
 	view := tea.NewView(content)
 	view.AltScreen = true
@@ -438,26 +775,131 @@ func (m MainModel) View() tea.View {
 }
 
 func main() {
-	model, err := NewMainModel()
+	profile := flag.String("profile", defaultProfile, "name of the event profile to open")
+	flag.Usage = func() { printUsage(os.Stderr, flag.CommandLine) }
+	flag.Parse()
+	if err := validateProfileName(*profile); err != nil {
+		fmt.Fprintf(os.Stderr, "%s: %v\n", appName, err)
+		os.Exit(2)
+	}
+	prompt := true
+	if flag.NArg() > 0 {
+		cmd, ok := findCommand(flag.Arg(0))
+		prompt = ok && !cmd.quiet
+	}
+	if err := configureStorage(os.Stdin, os.Stderr, prompt); err != nil {
+		fmt.Fprintf(os.Stderr, "%s: %v\n", appName, err)
+		os.Exit(1)
+	}
+	if flag.NArg() > 0 {
+		c := &cli{profile: *profile, stdin: os.Stdin, stdout: os.Stdout, stderr: os.Stderr}
+		if err := c.run(flag.Args()); err != nil {
+			if errors.Is(err, flag.ErrHelp) {
+				return
+			}
+			fmt.Fprintf(os.Stderr, "%s: %v\n", appName, err)
+			if errors.Is(err, errUsage) {
+				os.Exit(2)
+			}
+			os.Exit(1)
+		}
+		return
+	}
+	model, err := NewMainModel(*profile)
+	var corrupt *corruptEventsError
+	if errors.As(err, &corrupt) {
+		restored, restoreErr := offerBackupRestore(os.Stdin, os.Stderr, corrupt)
+		if restoreErr != nil {
+			err = restoreErr
+		} else if restored {
+			model, err = NewMainModel(*profile)
+		}
+	}
 	if err != nil {
 		fmt.Fprintf(os.Stderr, "%s: %v\n", appName, err)
 		os.Exit(1)
 	}
+	// The TUI owns the terminal from here on, so it can't prompt.
+	secrets.prompt = nil
 	if _, err := tea.NewProgram(model).Run(); err != nil {
 		fmt.Fprintf(os.Stderr, "%s: %v\n", appName, err)
 		os.Exit(1)
 	}
 }
 
-// insertEvent inserts an event into the list, keeping it sorted by time.
-func (m *MainModel) insertEvent(event Event) {
-	index := 0
-	for _, item := range m.events.Items() {
-		if event.Time >= item.(Event).Time {
-			index++
+// applyChange applies c to the list, saves the profile and logs the change.
+func (m *MainModel) applyChange(c change) error {
+	events, err := c.apply(m.listEvents())
+	if err != nil {
+		return err
+	}
+	index := m.events.Index()
+	m.setItems(events)
+	if c.After != nil {
+		index = indexOfEvent(events, *c.After)
+	}
+	m.events.Select(min(index, len(events)-1))
+	if m.dryRun {
+		return nil
+	}
+	if err := m.saveEventsToFile(); err != nil {
+		return err
+	}
+	return appendHistory(m.profile, c)
+}
+
+// commitChange applies a change made by the user and makes it undoable.
+func (m *MainModel) commitChange(c change) tea.Cmd {
+	if err := m.applyChange(c); err != nil {
+		return m.events.NewStatusMessage(errStyle.Render(err.Error()))
+	}
+	m.undo = append(m.undo, c)
+	m.redo = nil
+	return nil
+}
+
+func (m *MainModel) undoChange() tea.Cmd {
+	if len(m.undo) == 0 {
+		return nil
+	}
+	c := m.undo[len(m.undo)-1]
+	if err := m.applyChange(c.inverse()); err != nil {
+		return m.events.NewStatusMessage(errStyle.Render(err.Error()))
+	}
+	m.undo = m.undo[:len(m.undo)-1]
+	m.redo = append(m.redo, c)
+	return m.events.NewStatusMessage("Undid: " + c.String())
+}
+
+func (m *MainModel) redoChange() tea.Cmd {
+	if len(m.redo) == 0 {
+		return nil
+	}
+	c := m.redo[len(m.redo)-1]
+	if err := m.applyChange(c); err != nil {
+		return m.events.NewStatusMessage(errStyle.Render(err.Error()))
+	}
+	m.redo = m.redo[:len(m.redo)-1]
+	m.undo = append(m.undo, c)
+	return m.events.NewStatusMessage("Redid: " + c.String())
+}
+
+func (m MainModel) readOnlyStatus(event Event) tea.Cmd {
+	return m.events.NewStatusMessage(errStyle.Render(
+		fmt.Sprintf("%q is read-only (%s)", event.Name, event.Source)))
+}
+
+// tabsView renders one tab per profile with the active profile highlighted.
+func (m MainModel) tabsView() string {
+	tabs := make([]string, len(m.profiles))
+	for i, name := range m.profiles {
+		style := m.styles.tab
+		if name == m.profile {
+			style = m.styles.activeTab
 		}
+		tabs[i] = style.Render(name)
 	}
-	m.events.InsertItem(index, event)
+	return m.styles.app.Render(lipgloss.JoinHorizontal(lipgloss.Top, tabs...))
 }
 
 func (m MainModel) detailsString() string {
@@ -474,12 +916,30 @@ func (m MainModel) detailsString() string {
 	b.WriteByte('\n')
 	b.WriteString(m.styles.normalText.Render("    When (ISO): "))
 	b.WriteString(m.styles.brightText.Render(event.ToBasicString()))
+	b.WriteByte('\n')
+	b.WriteString(m.styles.normalText.Render("        Source: "))
+	b.WriteString(m.styles.brightText.Render(event.SourceName()))
+	if event.Yearly != "" {
+		b.WriteByte('\n')
+		b.WriteString(m.styles.normalText.Render("        Yearly: "))
+		b.WriteString(m.styles.brightText.Render(event.yearlySummary()))
+	}
+	if len(event.Tags) > 0 {
+		b.WriteByte('\n')
+		b.WriteString(m.styles.normalText.Render("          Tags: "))
+		b.WriteString(m.styles.brightText.Render(strings.Join(event.Tags, ", ")))
+	}
+	if event.Notes != "" {
+		b.WriteByte('\n')
+		b.WriteString(m.styles.normalText.Render("         Notes: "))
+		b.WriteString(m.styles.brightText.Render(event.Notes))
+	}
 	b.WriteString("\n\n\n")
 	b.WriteString(m.styles.detailTitle.Render("Countdown"))
 	b.WriteByte('\n')
-	b.WriteString(m.styles.specialText.Render(countdownParser(event.Time)))
+	b.WriteString(m.styles.specialText.Render(event.countdown()))
 	b.WriteByte('\n')
-	diff := time.Until(ts).Seconds()
+	diff := ts.Sub(clock()).Seconds()
 	var left strings.Builder
 	left.WriteString(strconv.FormatInt(int64(diff), 10))
 	left.WriteByte('\n')
@@ -490,16 +950,24 @@ func (m MainModel) detailsString() string {
 	left.WriteString(strconv.FormatFloat(diff/secondsPerDay, 'f', 5, 64))
 	left.WriteByte('\n')
 	left.WriteString(strconv.FormatFloat(diff/secondsPerYear, 'f', 7, 64))
-	right := " seconds\n minutes\n hours\n days\n years"
+	left.WriteByte('\n')
+	left.WriteString(strconv.Itoa(m.holidays.businessDays(clock(), ts)))
+	right := " seconds\n minutes\n hours\n days\n years\n business days"
 	return m.styles.detail.Render(b.String() +
 		lipgloss.JoinHorizontal(lipgloss.Bottom, m.styles.detailsLeft.Render(left.String()), m.styles.detailsRight.Render(right)))
 }
 
 func countdownParser(ts int64) string {
-	diff := int(time.Until(time.Unix(ts, 0)).Seconds())
+	diff := int(time.Unix(ts, 0).Sub(clock()).Seconds())
 	if diff < 0 {
-		return errStyle.Render("Expired")
+		return errStyle.Render(expiredText)
 	}
+	return formatCountdown(diff)
+}
+
+// formatCountdown formats a number of seconds left the way the list shows
+// it, e.g. "3d 4h 0m 12s".
+func formatCountdown(diff int) string {
 	years := diff / secondsPerYear
 	diff -= years * secondsPerYear
 	days := diff / secondsPerDay
@@ -522,29 +990,60 @@ func countdownParser(ts int64) string {
 	}
 }
 
+// shortCountdown formats the time left compactly for badges and status
+// lines, e.g. "12 days", "5h 3m" or "expired".
+func shortCountdown(left time.Duration) string {
+	days := int(left / (secondsPerDay * time.Second))
+	switch {
+	case left < 0:
+		return strings.ToLower(expiredText)
+	case days > 1:
+		return fmt.Sprintf("%d days", days)
+	case days == 1:
+		return "1 day"
+	case left >= time.Hour:
+		return fmt.Sprintf("%dh %dm", int(left.Hours()), int(left.Minutes())%60)
+	case left >= time.Minute:
+		return fmt.Sprintf("%dm", int(left.Minutes()))
+	default:
+		return "<1m"
+	}
+}
+
+// readEventsFile returns the default profile's events merged with any
+// read-only sources.
 func readEventsFile() ([]Event, error) {
-	eventsFile, err := getEventsFilePath()
+	return loadProfileEvents(defaultProfile)
+}
+
+// readProfileEvents reads a profile's events, creating an empty file on
+// first use.
+func readProfileEvents(profile string) ([]Event, error) {
+	eventsFile, err := getProfileFilePath(profile)
 	if err != nil {
 		return nil, fmt.Errorf("failed to get events file path: %w", err)
 	}
 
 	data, err := os.ReadFile(eventsFile)
 	if errors.Is(err, os.ErrNotExist) {
-		events := []Event{nextGolangAnniversary()}
+		events := []Event{}
 		return events, writeEventsFile(eventsFile, events)
 	}
 	if err != nil {
 		return nil, err
 	}
+	if data, err = secrets.open(data); err != nil {
+		return nil, fmt.Errorf("%s: %w", eventsFile, err)
+	}
 	var events []Event
 	if err := json.Unmarshal(data, &events); err != nil {
-		return nil, err
+		return nil, &corruptEventsError{Profile: profile, Path: eventsFile, Err: err}
 	}
 	return events, nil
 }
 
 func writeEventsFile(path string, events []Event) error {
-	data, err := json.MarshalIndent(events, "", "  ")
+	data, err := encodeEvents(events)
 	if err != nil {
 		return err
 	}
@@ -552,22 +1051,22 @@ func writeEventsFile(path string, events []Event) error {
 }
 
 func (m MainModel) saveEventsToFile() error {
-	eventsFile, err := getEventsFilePath()
-	if err != nil {
-		return fmt.Errorf("failed to get events file path: %w", err)
-	}
-
-	items := m.events.Items()
-	events := make([]Event, len(items))
-	for i, item := range items {
-		events[i] = item.(Event)
+	events := []Event{}
+	for _, item := range m.events.Items() {
+		if event := item.(Event); !event.ReadOnly() {
+			events = append(events, event)
+		}
 	}
-	return writeEventsFile(eventsFile, events)
+	return saveProfileEvents(m.profile, events)
 }
 
 func (m MainModel) inputView() string {
 	var b strings.Builder
-	b.WriteString(m.styles.inputTitle.Render("New Event"))
+	title := "New Event"
+	if m.editing != nil {
+		title = "Edit Event"
+	}
+	b.WriteString(m.styles.inputTitle.Render(title))
 	b.WriteByte('\n')
 	for i := range m.inputs {
 		b.WriteString(m.inputs[i].View())
@@ -614,6 +1113,7 @@ func (m *MainModel) resetInputs() {
 	m.inputs[inputTimeField].Reset()
 	m.focus = 0
 	m.inputStatus = ""
+	m.editing = nil
 }
 
 func (m MainModel) validateInputs() (Event, error) {
@@ -631,20 +1131,11 @@ func (m MainModel) validateInputs() (Event, error) {
 	if err != nil {
 		return event, err
 	}
-	if ts.Before(time.Now()) {
+	// A yearly event being edited moves on to its next occurrence, so its
+	// date may be past, as it is all through the event's own day.
+	if ts.Before(clock()) && (m.editing == nil || m.editing.Yearly == "") {
 		return event, fmt.Errorf("event time is in the past")
 	}
-	event = Event{Name: name, Time: ts.Unix()}
+	event = Event{ID: newEventID(), Name: name, Time: ts.Unix()}
 	return event, nil
 }
-
-func nextGolangAnniversary() Event {
-	nameStr := "Golang's Birthday"
-	now := time.Now()
-	year := now.Year()
-	thisYear := time.Date(year, 11, 10, 0, 0, 0, 0, time.Local)
-	if now.Before(thisYear) {
-		return Event{nameStr, thisYear.Unix()}
-	}
-	return Event{nameStr, time.Date(year+1, 11, 10, 0, 0, 0, 0, time.Local).Unix()}
-}
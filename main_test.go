@@ -3,6 +3,8 @@ package main
 import (
 	"os"
 	"path/filepath"
+	"reflect"
+	"slices"
 	"strings"
 	"testing"
 	"time"
@@ -16,82 +18,62 @@ type testHelper struct {
 	testConfigDir string
 }
 
-// newTestHelper points the user config directory at a temporary directory.
+// newTestHelper points the user config and cache directories at a temporary
+// directory and moves the system events layer there too, so a real
+// /etc/countdown/events.json can't leak into the tests. Each test also gets
+// a fresh keyring with encryption off.
 // Cleanup of the directory and environment is handled by the testing package.
 func newTestHelper(t *testing.T) *testHelper {
 	t.Helper()
 	testDir := t.TempDir()
 	t.Setenv("XDG_CONFIG_HOME", testDir)
+	t.Setenv("XDG_CACHE_HOME", filepath.Join(testDir, "cache"))
+	oldSystemEventsFile, oldSecrets, oldProviders := systemEventsFile, secrets, builtinProviders
+	systemEventsFile = filepath.Join(testDir, "system", eventsFileName)
+	secrets = &keyring{}
+	// Start without generated events; tests turn providers on in
+	// config.json.
+	builtinProviders = slices.Clone(builtinProviders)
+	for i := range builtinProviders {
+		builtinProviders[i].byDefault = nil
+	}
+	t.Cleanup(func() { systemEventsFile, secrets, builtinProviders = oldSystemEventsFile, oldSecrets, oldProviders })
 	return &testHelper{testConfigDir: testDir}
 }
 
+// enableProviders turns on the named built-in providers in config.json.
+func (th *testHelper) enableProviders(t *testing.T, names ...string) {
+	t.Helper()
+	configFile, err := getConfigFilePath()
+	if err != nil {
+		t.Fatal(err)
+	}
+	providers := make(map[string]providerConfig)
+	for _, name := range names {
+		providers[name] = providerConfig{}
+	}
+	writeJSON(t, configFile, config{Providers: providers})
+}
+
 func (th *testHelper) removeEventsFile() {
 	if eventsFile, err := getEventsFilePath(); err == nil {
 		_ = os.Remove(eventsFile)
 	}
 }
 
-func TestNextGolangAnniversary(t *testing.T) {
-	tests := []struct {
-		name     string
-		now      time.Time
-		expected time.Time
-	}{
-		{
-			name:     "Before November 10th",
-			now:      time.Date(2023, 6, 15, 12, 0, 0, 0, time.Local),
-			expected: time.Date(2023, 11, 10, 0, 0, 0, 0, time.Local),
-		},
-		{
-			name:     "On November 10th",
-			now:      time.Date(2023, 11, 10, 12, 0, 0, 0, time.Local),
-			expected: time.Date(2024, 11, 10, 0, 0, 0, 0, time.Local),
-		},
-		{
-			name:     "After November 10th",
-			now:      time.Date(2023, 11, 15, 12, 0, 0, 0, time.Local),
-			expected: time.Date(2024, 11, 10, 0, 0, 0, 0, time.Local),
-		},
-		{
-			name:     "December 31st",
-			now:      time.Date(2023, 12, 31, 23, 59, 59, 0, time.Local),
-			expected: time.Date(2024, 11, 10, 0, 0, 0, 0, time.Local),
-		},
-		{
-			name:     "January 1st",
-			now:      time.Date(2024, 1, 1, 0, 0, 0, 0, time.Local),
-			expected: time.Date(2024, 11, 10, 0, 0, 0, 0, time.Local),
-		},
-	}
-
-	for _, tt := range tests {
-		t.Run(tt.name, func(t *testing.T) {
-			// Test the logic manually since we can't override time.Now
-			year := tt.now.Year()
-			thisYear := time.Date(year, 11, 10, 0, 0, 0, 0, time.Local)
-			nextYear := time.Date(year+1, 11, 10, 0, 0, 0, 0, time.Local)
-
-			var expectedEvent Event
-			if tt.now.Before(thisYear) {
-				expectedEvent = Event{"Golang's Birthday", thisYear.Unix()}
-			} else {
-				expectedEvent = Event{"Golang's Birthday", nextYear.Unix()}
-			}
-
-			// For testing purposes, we'll manually calculate what the function should return
-			// given the current time logic in nextGolangAnniversary()
-			expectedUnix := tt.expected.Unix()
-			if expectedEvent.Time != expectedUnix {
-				t.Errorf("Expected time %d (%s), got %d (%s)",
-					expectedUnix, tt.expected.Format(time.RFC3339),
-					expectedEvent.Time, time.Unix(expectedEvent.Time, 0).Format(time.RFC3339))
-			}
+// equalEvents reports whether two event lists hold the same events in the
+// same order.
+func equalEvents(a, b []Event) bool {
+	return slices.EqualFunc(a, b, func(x, y Event) bool { return reflect.DeepEqual(x, y) })
+}
 
-			if expectedEvent.Name != "Golang's Birthday" {
-				t.Errorf("Expected name 'Golang's Birthday', got '%s'", expectedEvent.Name)
-			}
-		})
+// newTestList builds a list model holding the given events in order.
+func newTestList(events ...Event) list.Model {
+	items := make([]list.Item, len(events))
+	for i := range events {
+		items[i] = events[i]
 	}
+	return list.New(items, list.NewDefaultDelegate(), 0, 0)
 }
 
 func TestCountdownParser(t *testing.T) {
@@ -173,6 +155,23 @@ func TestCountdownParser(t *testing.T) {
 	}
 }
 
+func TestShortCountdown(t *testing.T) {
+	tests := map[time.Duration]string{
+		-time.Second:                "expired",
+		30 * time.Second:            "<1m",
+		5 * time.Minute:             "5m",
+		5*time.Hour + 3*time.Minute: "5h 3m",
+		25 * time.Hour:              "1 day",
+		12*24*time.Hour + time.Hour: "12 days",
+		400 * 24 * time.Hour:        "400 days",
+	}
+	for left, want := range tests {
+		if got := shortCountdown(left); got != want {
+			t.Errorf("shortCountdown(%v) = %q, want %q", left, got, want)
+		}
+	}
+}
+
 func TestEventMethods(t *testing.T) {
 	event := Event{
 		Name: "Test Event",
@@ -299,17 +298,23 @@ func TestReadEventsFile(t *testing.T) {
 		th := newTestHelper(t)
 		th.removeEventsFile()
 
+		th.enableProviders(t, "golang")
+
 		events, err := readEventsFile()
 		if err != nil {
 			t.Errorf("Unexpected error: %v", err)
 		}
 
 		if len(events) != 1 {
-			t.Errorf("Expected 1 event (default Golang birthday), got %d", len(events))
+			t.Fatalf("Expected 1 event (generated Golang birthday), got %d", len(events))
+		}
+
+		if events[0].Name != "Golang's Birthday" || !events[0].ReadOnly() {
+			t.Errorf("Expected a read-only 'Golang's Birthday', got %v", events[0])
 		}
 
-		if events[0].Name != "Golang's Birthday" {
-			t.Errorf("Expected 'Golang's Birthday', got '%s'", events[0].Name)
+		if personal, err := readProfileEvents(defaultProfile); err != nil || len(personal) != 0 {
+			t.Errorf("Expected an empty events file, got %v (err %v)", personal, err)
 		}
 
 		// Clean up
@@ -357,8 +362,9 @@ func TestMainModelInitialization(t *testing.T) {
 	// Remove events file to test default initialization
 	th := newTestHelper(t)
 	th.removeEventsFile()
+	th.enableProviders(t, "golang")
 
-	model, err := NewMainModel()
+	model, err := NewMainModel(defaultProfile)
 	if err != nil {
 		t.Fatalf("NewMainModel() failed: %v", err)
 	}
@@ -379,8 +385,8 @@ func TestMainModelInitialization(t *testing.T) {
 	}
 
 	// Test events list initialization
-	if model.events.Title != "Events" {
-		t.Errorf("Expected events title to be 'Events', got '%s'", model.events.Title)
+	if model.events.Title != defaultProfile {
+		t.Errorf("Expected events title to be '%s', got '%s'", defaultProfile, model.events.Title)
 	}
 }
 
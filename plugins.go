@@ -0,0 +1,123 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"strings"
+	"time"
+)
+
+const (
+	defaultPluginTimeout = 10 * time.Second
+	// pluginWaitDelay is how long a timed-out plugin's children may hold
+	// on to its output before they are abandoned.
+	pluginWaitDelay = time.Second
+)
+
+// isPlugin reports whether a source's events come from running a command.
+// Plugins are refreshed and cached like feeds.
+func (s eventSource) isPlugin() bool { return len(s.Command) > 0 }
+
+// kind names the sort of source in messages.
+func (s eventSource) kind() string {
+	if s.isPlugin() {
+		return "plugin"
+	}
+	return "feed"
+}
+
+// location returns the URL of a feed or the command line of a plugin.
+func (s eventSource) location() string {
+	if s.isPlugin() {
+		return strings.Join(s.Command, " ")
+	}
+	return s.URL
+}
+
+// cacheKey identifies the cached copy of a feed or plugin.
+func (s eventSource) cacheKey() string {
+	if s.isPlugin() {
+		return "command:" + strings.Join(s.Command, "\x00")
+	}
+	return s.URL
+}
+
+// timeout returns how long a plugin may run.
+func (s eventSource) timeout() time.Duration {
+	if d, err := time.ParseDuration(s.Timeout); err == nil && d > 0 {
+		return d
+	}
+	return defaultPluginTimeout
+}
+
+// runPlugin runs a plugin's command and returns what it printed on stdout.
+// The command is looked up on the PATH unless it contains a slash, in
+// which case it is resolved like a source path. The source's name is
+// passed in COUNTDOWN_SOURCE.
+func runPlugin(src eventSource) ([]byte, error) {
+	name := src.Command[0]
+	if strings.ContainsRune(name, '/') || strings.HasPrefix(name, "~") {
+		var err error
+		if name, err = resolvePath(name); err != nil {
+			return nil, err
+		}
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), src.timeout())
+	defer cancel()
+	cmd := exec.CommandContext(ctx, name, src.Command[1:]...)
+	cmd.Env = append(os.Environ(), "COUNTDOWN_SOURCE="+src.Name)
+	cmd.WaitDelay = pluginWaitDelay
+	var stdout, stderr bytes.Buffer
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+	err := cmd.Run()
+	if errors.Is(ctx.Err(), context.DeadlineExceeded) {
+		return nil, fmt.Errorf("timed out after %v", src.timeout())
+	}
+	if err != nil {
+		// The last line a failing plugin printed usually says why.
+		lines := strings.Split(strings.TrimSpace(stderr.String()), "\n")
+		if last := lines[len(lines)-1]; last != "" {
+			return nil, fmt.Errorf("%w: %s", err, last)
+		}
+		return nil, err
+	}
+	if stdout.Len() > maxFeedSize {
+		return nil, fmt.Errorf("output is larger than %d bytes", maxFeedSize)
+	}
+	return stdout.Bytes(), nil
+}
+
+// parsePluginOutput decodes a plugin's output: one event per line, as a
+// JSON object with the fields of events.json. Blank lines are skipped.
+func parsePluginOutput(data []byte) ([]Event, error) {
+	var events []Event
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	scanner.Buffer(nil, maxFeedSize)
+	for n := 1; scanner.Scan(); n++ {
+		line := bytes.TrimSpace(scanner.Bytes())
+		if len(line) == 0 {
+			continue
+		}
+		var event Event
+		if err := json.Unmarshal(line, &event); err != nil {
+			return nil, fmt.Errorf("line %d: %w", n, err)
+		}
+		if event.Name == "" {
+			return nil, fmt.Errorf("line %d: event has no name", n)
+		}
+		if event.Yearly != "" {
+			if _, err := parseYearly(event.Yearly); err != nil {
+				return nil, fmt.Errorf("line %d: %w", n, err)
+			}
+		}
+		events = append(events, event)
+	}
+	return events, scanner.Err()
+}
@@ -0,0 +1,106 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+// writePlugin writes a shell script plugin to the config directory.
+func writePlugin(t *testing.T, name, script string) string {
+	t.Helper()
+	configFile, err := getConfigFilePath()
+	if err != nil {
+		t.Fatal(err)
+	}
+	path := filepath.Join(filepath.Dir(configFile), name)
+	if err := os.WriteFile(path, []byte("#!/bin/sh\n"+script), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestParsePluginOutput(t *testing.T) {
+	events, err := parsePluginOutput([]byte(`{"id":"f1","name":"Deploy freeze","ts":1900000000,"tags":["freeze"]}
+
+{"name":"Sprint 42 ends","ts":1900500000}
+`))
+	if err != nil {
+		t.Fatalf("parsePluginOutput() failed: %v", err)
+	}
+	want := []Event{
+		{ID: "f1", Name: "Deploy freeze", Time: 1900000000, Tags: []string{"freeze"}},
+		{Name: "Sprint 42 ends", Time: 1900500000},
+	}
+	if !equalEvents(events, want) {
+		t.Errorf("Expected %v, got %v", want, events)
+	}
+
+	for _, bad := range []string{`{"name":"ok","ts":1}` + "\nnot json", `{"ts":1}`, `{"name":"x","yearly":"someday"}`} {
+		if _, err := parsePluginOutput([]byte(bad)); err == nil {
+			t.Errorf("Expected an error for %q", bad)
+		}
+	}
+}
+
+func TestPluginSource(t *testing.T) {
+	newTestHelper(t)
+	writePlugin(t, "freeze.sh",
+		`echo "{\"name\":\"Freeze for $COUNTDOWN_SOURCE\",\"ts\":1900000000}"`+"\n")
+	setFeeds(t, eventSource{Name: "deploys", Command: []string{"./freeze.sh"}})
+
+	// Nothing shows up until the plugin has run.
+	events, err := loadProfileEvents(defaultProfile)
+	if err != nil || len(events) != 0 {
+		t.Fatalf("Expected no events before the first run, got %v (err %v)", events, err)
+	}
+	if changed, errs := refreshFeeds(false); !changed || len(errs) > 0 {
+		t.Fatalf("refreshFeeds() = %v, %v", changed, errs)
+	}
+	events, err = loadProfileEvents(defaultProfile)
+	if err != nil {
+		t.Fatalf("loadProfileEvents() failed: %v", err)
+	}
+	if len(events) != 1 || events[0].Name != "Freeze for deploys" || events[0].Source != "deploys" {
+		t.Fatalf("Expected the plugin's event as read-only, got %+v", events)
+	}
+}
+
+func TestPluginFailureKeepsCache(t *testing.T) {
+	newTestHelper(t)
+	path := writePlugin(t, "sprint.sh", `echo '{"name":"Sprint ends","ts":1900000000}'`+"\n")
+	plugin := eventSource{Name: "sprints", Command: []string{path}}
+	if _, err := fetchFeed(plugin); err != nil {
+		t.Fatalf("fetchFeed() failed: %v", err)
+	}
+
+	writePlugin(t, "sprint.sh", "echo 'token expired' >&2\nexit 3\n")
+	_, err := fetchFeed(plugin)
+	if err == nil || !strings.Contains(err.Error(), "token expired") {
+		t.Errorf("Expected the plugin's stderr in the error, got %v", err)
+	}
+	writePlugin(t, "sprint.sh", "echo garbage\n")
+	if _, err := fetchFeed(plugin); err == nil {
+		t.Error("Expected unparseable output to be rejected")
+	}
+	if events, _ := readFeedEvents(plugin); len(events) != 1 {
+		t.Errorf("Expected the previous output to be kept, got %v", events)
+	}
+}
+
+func TestPluginTimeout(t *testing.T) {
+	newTestHelper(t)
+	writePlugin(t, "slow.sh", "sleep 5\n")
+	setFeeds(t, eventSource{Name: "slow", Command: []string{"./slow.sh"}, Timeout: "100ms"})
+
+	start := time.Now()
+	_, errs := refreshFeeds(true)
+	if len(errs) != 1 || !strings.Contains(errs[0].Error(), "plugin slow: timed out") {
+		t.Errorf("Expected a timeout error, got %v", errs)
+	}
+	if elapsed := time.Since(start); elapsed > 3*time.Second {
+		t.Errorf("Expected the plugin to be stopped, waited %v", elapsed)
+	}
+}
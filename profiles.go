@@ -0,0 +1,72 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+const (
+	defaultProfile  = "default"
+	profilesDirName = "profiles"
+)
+
+// validateProfileName rejects names that can't be used as a file name
+// inside the profiles directory.
+func validateProfileName(name string) error {
+	switch {
+	case name == "":
+		return errors.New("profile name is empty")
+	case name == "." || name == "..", strings.ContainsAny(name, `/\`):
+		return fmt.Errorf("invalid profile name %q", name)
+	}
+	return nil
+}
+
+// getProfileFilePath returns the events file backing a profile. The default
+// profile keeps using events.json so existing setups are unaffected; every
+// other profile lives in profiles/<name>.json. An empty name is treated as
+// the default profile so a zero MainModel still saves somewhere sensible.
+func getProfileFilePath(profile string) (string, error) {
+	if profile == "" || profile == defaultProfile {
+		return getEventsFilePath()
+	}
+	if err := validateProfileName(profile); err != nil {
+		return "", err
+	}
+	eventsFile, err := getEventsFilePath()
+	if err != nil {
+		return "", err
+	}
+	profilesDir := filepath.Join(filepath.Dir(eventsFile), profilesDirName)
+	if err := os.MkdirAll(profilesDir, 0o755); err != nil {
+		return "", fmt.Errorf("failed to create profiles directory: %w", err)
+	}
+	return filepath.Join(profilesDir, profile+".json"), nil
+}
+
+// listProfiles returns the default profile followed by every profile found
+// in the profiles directory, sorted by name.
+func listProfiles() ([]string, error) {
+	eventsFile, err := getEventsFilePath()
+	if err != nil {
+		return nil, err
+	}
+	entries, err := os.ReadDir(filepath.Join(filepath.Dir(eventsFile), profilesDirName))
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
+		return nil, err
+	}
+	var names []string
+	for _, entry := range entries {
+		name, ok := strings.CutSuffix(entry.Name(), ".json")
+		if entry.IsDir() || !ok || name == defaultProfile || validateProfileName(name) != nil {
+			continue
+		}
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return append([]string{defaultProfile}, names...), nil
+}
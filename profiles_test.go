@@ -0,0 +1,168 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestValidateProfileName(t *testing.T) {
+	tests := []struct {
+		name    string
+		profile string
+		wantErr bool
+	}{
+		{name: "Simple name", profile: "work"},
+		{name: "Name with dash", profile: "on-call"},
+		{name: "Empty", profile: "", wantErr: true},
+		{name: "Dot", profile: ".", wantErr: true},
+		{name: "Parent dir", profile: "..", wantErr: true},
+		{name: "Slash", profile: "a/b", wantErr: true},
+		{name: "Backslash", profile: `a\b`, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := validateProfileName(tt.profile)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("validateProfileName(%q) error = %v, wantErr %v", tt.profile, err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestGetProfileFilePath(t *testing.T) {
+	th := newTestHelper(t)
+
+	defaultPath, err := getProfileFilePath(defaultProfile)
+	if err != nil {
+		t.Fatalf("getProfileFilePath(default) failed: %v", err)
+	}
+	if want := filepath.Join(th.testConfigDir, appName, eventsFileName); defaultPath != want {
+		t.Errorf("Expected default profile path %s, got %s", want, defaultPath)
+	}
+
+	workPath, err := getProfileFilePath("work")
+	if err != nil {
+		t.Fatalf("getProfileFilePath(work) failed: %v", err)
+	}
+	if want := filepath.Join(th.testConfigDir, appName, profilesDirName, "work.json"); workPath != want {
+		t.Errorf("Expected work profile path %s, got %s", want, workPath)
+	}
+
+	if _, err := getProfileFilePath("../escape"); err == nil {
+		t.Error("Expected error for profile name containing a path separator")
+	}
+}
+
+func TestListProfiles(t *testing.T) {
+	newTestHelper(t)
+
+	profiles, err := listProfiles()
+	if err != nil {
+		t.Fatalf("listProfiles() failed: %v", err)
+	}
+	if !reflect.DeepEqual(profiles, []string{defaultProfile}) {
+		t.Errorf("Expected only the default profile, got %v", profiles)
+	}
+
+	for _, name := range []string{"work", "personal"} {
+		if _, err := readProfileEvents(name); err != nil {
+			t.Fatalf("readProfileEvents(%s) failed: %v", name, err)
+		}
+	}
+	stray := filepath.Join(filepath.Dir(mustProfilePath(t, "work")), "notes.txt")
+	if err := os.WriteFile(stray, nil, 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	profiles, err = listProfiles()
+	if err != nil {
+		t.Fatalf("listProfiles() failed: %v", err)
+	}
+	want := []string{defaultProfile, "personal", "work"}
+	if !reflect.DeepEqual(profiles, want) {
+		t.Errorf("Expected %v, got %v", want, profiles)
+	}
+}
+
+func TestReadProfileEvents(t *testing.T) {
+	newTestHelper(t)
+
+	events, err := readProfileEvents("work")
+	if err != nil {
+		t.Fatalf("readProfileEvents(work) failed: %v", err)
+	}
+	if len(events) != 0 {
+		t.Errorf("Expected new profile to start empty, got %d events", len(events))
+	}
+
+	m := MainModel{profile: "work"}
+	m.events = newTestList(Event{Name: "Standup", Time: time.Now().Add(time.Hour).Unix()})
+	if err := m.saveEventsToFile(); err != nil {
+		t.Fatalf("saveEventsToFile() failed: %v", err)
+	}
+
+	events, err = readProfileEvents("work")
+	if err != nil {
+		t.Fatalf("readProfileEvents(work) failed: %v", err)
+	}
+	if len(events) != 1 || events[0].Name != "Standup" {
+		t.Errorf("Expected the saved event in the work profile, got %v", events)
+	}
+
+	defaults, err := readEventsFile()
+	if err != nil {
+		t.Fatalf("readEventsFile() failed: %v", err)
+	}
+	for _, e := range defaults {
+		if e.Name == "Standup" {
+			t.Error("Work profile event leaked into the default profile")
+		}
+	}
+}
+
+func TestSwitchProfile(t *testing.T) {
+	newTestHelper(t)
+	seedGoBirthday(t)
+	if _, err := readProfileEvents("work"); err != nil {
+		t.Fatal(err)
+	}
+
+	m, err := NewMainModel(defaultProfile)
+	if err != nil {
+		t.Fatalf("NewMainModel() failed: %v", err)
+	}
+
+	m.switchProfile(1)
+	if m.profile != "work" || m.events.Title != "work" {
+		t.Errorf("Expected work profile after switching forward, got %q (title %q)", m.profile, m.events.Title)
+	}
+	if m.state != noEvents {
+		t.Errorf("Expected noEvents state for an empty profile, got %v", m.state)
+	}
+
+	m.switchProfile(1)
+	if m.profile != defaultProfile {
+		t.Errorf("Expected switching to wrap around to %q, got %q", defaultProfile, m.profile)
+	}
+	if m.state != showEvents {
+		t.Errorf("Expected showEvents state, got %v", m.state)
+	}
+
+	m.switchProfile(-1)
+	if m.profile != "work" {
+		t.Errorf("Expected switching backward to wrap to work, got %q", m.profile)
+	}
+}
+
+func mustProfilePath(t *testing.T, profile string) string {
+	t.Helper()
+	path, err := getProfileFilePath(profile)
+	if err != nil {
+		t.Fatalf("getProfileFilePath(%s) failed: %v", profile, err)
+	}
+	return path
+}
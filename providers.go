@@ -0,0 +1,181 @@
+package main
+
+import (
+	"cmp"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+)
+
+// EventProvider generates events instead of reading them from a file, so
+// they stay up to date without being written to events.json.
+type EventProvider interface {
+	// Events returns the provider's events from from up to, but not
+	// including, to.
+	Events(from, to time.Time) ([]Event, error)
+}
+
+// builtinProvider is an EventProvider that ships with countdown and can be
+// turned on or off in config.json by name.
+type builtinProvider struct {
+	name string
+	// byDefault is the provider's setting when config.json doesn't
+	// mention it. Nil means off.
+	byDefault *providerConfig
+	// horizon is how far ahead events are shown. Only the first event of
+	// each name in it is, so a yearly event shows once.
+	horizon time.Duration
+	EventProvider
+}
+
+const oneYear = 365 * 24 * time.Hour
+
+var builtinProviders = append([]builtinProvider{
+	{
+		// The Go birthday is the example event of a fresh install.
+		name:          "golang",
+		byDefault:     &providerConfig{Profiles: []string{defaultProfile}},
+		horizon:       oneYear + 24*time.Hour,
+		EventProvider: goAnniversaryProvider{},
+	},
+	{
+		name:          "unix",
+		horizon:       20 * oneYear,
+		EventProvider: unixMilestoneProvider{},
+	},
+	{
+		name:          "astronomy",
+		horizon:       oneYear,
+		EventProvider: astronomyProvider{},
+	},
+}, holidayProviders()...)
+
+// holidayProviders returns a provider for each holiday pack, named e.g.
+// "holidays-us".
+func holidayProviders() []builtinProvider {
+	providers := make([]builtinProvider, len(holidayPacks))
+	for i, p := range holidayPacks {
+		providers[i] = builtinProvider{
+			name:          "holidays-" + strings.ToLower(p.code),
+			horizon:       oneYear,
+			EventProvider: holidayProvider{p},
+		}
+	}
+	return providers
+}
+
+// providerConfig turns a built-in provider on or off.
+type providerConfig struct {
+	// Enabled defaults to true for providers listed in config.json.
+	Enabled *bool `json:"enabled,omitempty"`
+	// Profiles limits the provider to the named profiles. Empty means all.
+	Profiles []string `json:"profiles,omitempty"`
+}
+
+// appliesTo reports whether a provider listed in config.json runs in a
+// profile.
+func (pc providerConfig) appliesTo(profile string) bool {
+	return (pc.Enabled == nil || *pc.Enabled) && (len(pc.Profiles) == 0 || slices.Contains(pc.Profiles, profile))
+}
+
+// providers returns the built-in providers enabled in a profile.
+func (c config) providers(profile string) ([]builtinProvider, error) {
+	for name := range c.Providers {
+		if !slices.ContainsFunc(builtinProviders, func(p builtinProvider) bool { return p.name == name }) {
+			names := make([]string, len(builtinProviders))
+			for i, p := range builtinProviders {
+				names[i] = p.name
+			}
+			return nil, fmt.Errorf("unknown provider %q, use one of %s", name, strings.Join(names, ", "))
+		}
+	}
+	var enabled []builtinProvider
+	for _, p := range builtinProviders {
+		pc, ok := c.Providers[p.name]
+		if !ok && p.byDefault != nil {
+			pc, ok = *p.byDefault, true
+		}
+		if ok && pc.appliesTo(profile) {
+			enabled = append(enabled, p)
+		}
+	}
+	return enabled, nil
+}
+
+// upcoming returns the events of a provider from the start of today to
+// its horizon, keeping only the first of each name, so like personal
+// events one that has started shows until its day ends. They are tagged
+// with the provider's name as their read-only source.
+func (p builtinProvider) upcoming(now time.Time) ([]Event, error) {
+	today := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, now.Location())
+	events, err := p.Events(today, now.Add(p.horizon))
+	if err != nil {
+		return nil, fmt.Errorf("%s provider: %w", p.name, err)
+	}
+	seen := make(map[string]bool)
+	var upcoming []Event
+	for _, e := range events {
+		if !seen[e.Name] {
+			seen[e.Name] = true
+			upcoming = append(upcoming, e)
+		}
+	}
+	return tagSource(upcoming, p.name), nil
+}
+
+// goAnniversaryProvider yields the Go language's birthday, November 10.
+type goAnniversaryProvider struct{}
+
+func (goAnniversaryProvider) Events(from, to time.Time) ([]Event, error) {
+	var events []Event
+	for year := from.Year(); year <= to.Year(); year++ {
+		t := time.Date(year, time.November, 10, 0, 0, 0, 0, from.Location())
+		if !t.Before(from) && t.Before(to) {
+			events = append(events, Event{Name: "Golang's Birthday", Time: t.Unix()})
+		}
+	}
+	return events, nil
+}
+
+// unixMilestones are the powers of two the Unix time counter reaches, and
+// what happens there.
+var unixMilestones = []struct {
+	seconds int64
+	name    string
+}{
+	{1 << 31, "Unix time 2^31 (32-bit time_t overflows)"},
+	{1 << 32, "Unix time 2^32 (unsigned 32-bit time_t overflows)"},
+}
+
+// unixMilestoneProvider yields the moments Unix time passes a multiple of
+// 100,000,000 seconds or a power-of-two overflow.
+type unixMilestoneProvider struct{}
+
+func (unixMilestoneProvider) Events(from, to time.Time) ([]Event, error) {
+	const step = 100_000_000
+	var events []Event
+	for s := (from.Unix() + step - 1) / step * step; s < to.Unix(); s += step {
+		events = append(events, Event{Name: "Unix time " + groupDigits(s), Time: s})
+	}
+	for _, m := range unixMilestones {
+		if m.seconds >= from.Unix() && m.seconds < to.Unix() {
+			events = append(events, Event{Name: m.name, Time: m.seconds})
+		}
+	}
+	slices.SortFunc(events, func(a, b Event) int { return cmp.Compare(a.Time, b.Time) })
+	return events, nil
+}
+
+// groupDigits formats n with thousands separators, e.g. 2,000,000,000.
+func groupDigits(n int64) string {
+	s := fmt.Sprint(n)
+	var b strings.Builder
+	for i, r := range s {
+		if i > 0 && (len(s)-i)%3 == 0 {
+			b.WriteByte(',')
+		}
+		b.WriteRune(r)
+	}
+	return b.String()
+}
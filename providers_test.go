@@ -0,0 +1,138 @@
+package main
+
+import (
+	"testing"
+	"time"
+)
+
+func TestGoAnniversaryProvider(t *testing.T) {
+	tests := []struct {
+		name     string
+		now      time.Time
+		expected time.Time
+	}{
+		{
+			name:     "Before November 10th",
+			now:      time.Date(2023, 6, 15, 12, 0, 0, 0, time.Local),
+			expected: time.Date(2023, 11, 10, 0, 0, 0, 0, time.Local),
+		},
+		{
+			// It shows until the day ends, like personal events.
+			name:     "On November 10th",
+			now:      time.Date(2023, 11, 10, 12, 0, 0, 0, time.Local),
+			expected: time.Date(2023, 11, 10, 0, 0, 0, 0, time.Local),
+		},
+		{
+			name:     "Last second of November 10th",
+			now:      time.Date(2023, 11, 10, 23, 59, 59, 0, time.Local),
+			expected: time.Date(2023, 11, 10, 0, 0, 0, 0, time.Local),
+		},
+		{
+			name:     "After November 10th",
+			now:      time.Date(2023, 11, 15, 12, 0, 0, 0, time.Local),
+			expected: time.Date(2024, 11, 10, 0, 0, 0, 0, time.Local),
+		},
+		{
+			name:     "December 31st",
+			now:      time.Date(2023, 12, 31, 23, 59, 59, 0, time.Local),
+			expected: time.Date(2024, 11, 10, 0, 0, 0, 0, time.Local),
+		},
+		{
+			name:     "January 1st",
+			now:      time.Date(2024, 1, 1, 0, 0, 0, 0, time.Local),
+			expected: time.Date(2024, 11, 10, 0, 0, 0, 0, time.Local),
+		},
+	}
+	p := builtinProviders[0]
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			events, err := p.upcoming(tt.now)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(events) != 1 || events[0].Name != "Golang's Birthday" || events[0].Source != "golang" {
+				t.Fatalf("Expected the golang provider's birthday event, got %v", events)
+			}
+			if got := time.Unix(events[0].Time, 0); !got.Equal(tt.expected) {
+				t.Errorf("Expected %s, got %s", tt.expected.Format(time.RFC3339), got.Format(time.RFC3339))
+			}
+		})
+	}
+}
+
+func TestUnixMilestoneProvider(t *testing.T) {
+	events, err := unixMilestoneProvider{}.Events(time.Unix(2_000_000_000, 0), time.Unix(2_200_000_000, 0))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var names []string
+	for _, e := range events {
+		names = append(names, e.Name)
+	}
+	want := []string{"Unix time 2,000,000,000", "Unix time 2,100,000,000", "Unix time 2^31 (32-bit time_t overflows)"}
+	if len(names) != len(want) {
+		t.Fatalf("Events() = %v, want %v", names, want)
+	}
+	for i := range want {
+		if names[i] != want[i] {
+			t.Errorf("Events()[%d] = %q, want %q", i, names[i], want[i])
+		}
+	}
+}
+
+func TestConfigProviders(t *testing.T) {
+	enabled := func(cfg config, profile string) []string {
+		t.Helper()
+		providers, err := cfg.providers(profile)
+		if err != nil {
+			t.Fatal(err)
+		}
+		var names []string
+		for _, p := range providers {
+			names = append(names, p.name)
+		}
+		return names
+	}
+	if got := enabled(config{}, defaultProfile); len(got) != 1 || got[0] != "golang" {
+		t.Errorf("Expected only the golang provider by default, got %v", got)
+	}
+	off := false
+	cfg := config{Providers: map[string]providerConfig{
+		"golang":      {Enabled: &off},
+		"unix":        {},
+		"holidays-jp": {Profiles: []string{"work"}},
+	}}
+	if got := enabled(cfg, defaultProfile); len(got) != 1 || got[0] != "unix" {
+		t.Errorf("enabled(default) = %v, want [unix]", got)
+	}
+	if got := enabled(cfg, "work"); len(got) != 2 || got[1] != "holidays-jp" {
+		t.Errorf("enabled(work) = %v, want [unix holidays-jp]", got)
+	}
+	if _, err := (config{Providers: map[string]providerConfig{"holidays-xx": {}}}).providers(defaultProfile); err == nil {
+		t.Error("Expected an error for an unknown provider")
+	}
+}
+
+func TestProviderEventsAreNotSaved(t *testing.T) {
+	th := newTestHelper(t)
+	th.enableProviders(t, "golang", "astronomy")
+
+	events, err := loadProfileEvents(defaultProfile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	sources := make(map[string]int)
+	for _, e := range events {
+		sources[e.Source]++
+	}
+	if sources["golang"] != 1 || sources["astronomy"] != 8 || len(sources) != 2 {
+		t.Errorf("Expected the golang and astronomy events, got %v", sources)
+	}
+	personal, err := readProfileEvents(defaultProfile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(personal) != 0 {
+		t.Errorf("Expected generated events to stay out of the events file, got %v", personal)
+	}
+}
@@ -0,0 +1,134 @@
+package main
+
+import (
+	"fmt"
+	"image/color"
+	"strings"
+	"time"
+
+	tea "charm.land/bubbletea/v2"
+	"github.com/charmbracelet/x/ansi"
+)
+
+// renderKeys are the key names countdown render --keys accepts besides
+// single characters and ctrl+ chords.
+var renderKeys = map[string]rune{
+	"enter":     tea.KeyEnter,
+	"tab":       tea.KeyTab,
+	"esc":       tea.KeyEscape,
+	"space":     tea.KeySpace,
+	"backspace": tea.KeyBackspace,
+	"delete":    tea.KeyDelete,
+	"up":        tea.KeyUp,
+	"down":      tea.KeyDown,
+	"left":      tea.KeyLeft,
+	"right":     tea.KeyRight,
+	"home":      tea.KeyHome,
+	"end":       tea.KeyEnd,
+	"pgup":      tea.KeyPgUp,
+	"pgdown":    tea.KeyPgDown,
+}
+
+// parseRenderKeys turns a comma-separated list of keys into key presses.
+// A key is a name from renderKeys, shift+tab, ctrl+ and a character, or a
+// single character; anything else is typed one character at a time.
+func parseRenderKeys(s string) []tea.KeyPressMsg {
+	var msgs []tea.KeyPressMsg
+	for token := range strings.SplitSeq(s, ",") {
+		if code, ok := renderKeys[token]; ok {
+			msg := tea.KeyPressMsg{Code: code}
+			if code == tea.KeySpace {
+				msg.Text = " "
+			}
+			msgs = append(msgs, msg)
+			continue
+		}
+		if token == "shift+tab" {
+			msgs = append(msgs, tea.KeyPressMsg{Code: tea.KeyTab, Mod: tea.ModShift})
+			continue
+		}
+		if c, ok := strings.CutPrefix(token, "ctrl+"); ok && len([]rune(c)) == 1 {
+			msgs = append(msgs, tea.KeyPressMsg{Code: []rune(c)[0], Mod: tea.ModCtrl})
+			continue
+		}
+		for _, r := range token {
+			msgs = append(msgs, tea.KeyPressMsg{Code: r, Text: string(r)})
+		}
+	}
+	return msgs
+}
+
+// renderFrame builds the TUI for profile, feeds it a window size, the
+// background and the key presses, and returns the last frame clipped to
+// the window. Commands the model returns are dropped, so nothing runs in
+// the background and changes are never saved.
+func renderFrame(profile string, width, height int, light bool, keys []tea.KeyPressMsg) (string, error) {
+	m, err := NewMainModel(profile)
+	if err != nil {
+		return "", err
+	}
+	m.dryRun = true
+	background := color.Color(color.Black)
+	if light {
+		background = color.White
+	}
+	msgs := []tea.Msg{tea.BackgroundColorMsg{Color: background}, tea.WindowSizeMsg{Width: width, Height: height}}
+	for _, k := range keys {
+		msgs = append(msgs, k)
+	}
+	var model tea.Model = m
+	for _, msg := range msgs {
+		model, _ = model.Update(msg)
+	}
+	lines := strings.Split(model.View().Content, "\n")
+	if len(lines) > height {
+		lines = lines[:height]
+	}
+	for i, line := range lines {
+		lines[i] = ansi.Truncate(line, width, "")
+	}
+	return strings.Join(lines, "\n"), nil
+}
+
+// runRender prints a single frame of the TUI at a fixed size and time,
+// for screenshots and visual regression checks.
+func runRender(c *cli, args []string) error {
+	fs := c.newFlagSet("render")
+	width := fs.Int("width", 100, "terminal width in columns")
+	height := fs.Int("height", 30, "terminal height in lines")
+	at := fs.String("now", "", "draw the frame at this time, e.g. `2026-11-10T09:00:00Z`, instead of now")
+	keys := fs.String("keys", "", "comma-separated keys to press first, e.g. `down,e`; other words are typed")
+	light := fs.Bool("light", false, "use the colors for a light terminal background")
+	withANSI := fs.Bool("ansi", false, "keep colors and styles as ANSI escape codes")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if fs.NArg() > 0 || *width <= 0 || *height <= 0 {
+		return errUsage
+	}
+	if *at != "" {
+		t, err := parseTOMLTime(*at, time.Local)
+		if err != nil {
+			return fmt.Errorf("-now: %w", err)
+		}
+		defer func(now func() time.Time) { clock = now }(clock)
+		clock = func() time.Time { return t }
+	}
+	var presses []tea.KeyPressMsg
+	if *keys != "" {
+		presses = parseRenderKeys(*keys)
+	}
+	frame, err := renderFrame(c.profile, *width, *height, *light, presses)
+	if err != nil {
+		return err
+	}
+	if !*withANSI {
+		lines := strings.Split(ansi.Strip(frame), "\n")
+		for i, line := range lines {
+			lines[i] = strings.TrimRight(line, " ")
+		}
+		frame = strings.Join(lines, "\n")
+	}
+	_, err = fmt.Fprintln(c.stdout, frame)
+	return err
+}
@@ -0,0 +1,96 @@
+package main
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+	"time"
+
+	tea "charm.land/bubbletea/v2"
+	"github.com/charmbracelet/x/ansi"
+)
+
+func TestParseRenderKeys(t *testing.T) {
+	var got []string
+	for _, msg := range parseRenderKeys("down,shift+tab,ctrl+z,e,Hi there,space,enter") {
+		got = append(got, msg.String())
+	}
+	want := "down shift+tab ctrl+z e H i space t h e r e space enter"
+	if strings.Join(got, " ") != want {
+		t.Errorf("Expected %q, got %q", want, strings.Join(got, " "))
+	}
+}
+
+func TestRenderCommand(t *testing.T) {
+	newTestHelper(t)
+	launch := time.Date(2026, 11, 10, 9, 0, 0, 0, time.UTC)
+	events := []Event{{ID: "a1", Name: "Launch", Time: launch.Unix()}}
+	if err := saveProfileEvents(defaultProfile, events); err != nil {
+		t.Fatal(err)
+	}
+	render := func(args ...string) string {
+		t.Helper()
+		var out bytes.Buffer
+		c := &cli{profile: defaultProfile, stdout: &out}
+		if err := c.run(append([]string{"render", "--now", "2026-11-08T09:00:00Z"}, args...)); err != nil {
+			t.Fatalf("render %v failed: %v", args, err)
+		}
+		return out.String()
+	}
+
+	frame := render("--width", "80", "--height", "20")
+	for _, want := range []string{"Launch", "2d 0h 0m 0s", "172800 seconds"} {
+		if !strings.Contains(frame, want) {
+			t.Errorf("Expected %q in the frame:\n%s", want, frame)
+		}
+	}
+	lines := strings.Split(strings.TrimSuffix(frame, "\n"), "\n")
+	if len(lines) > 20 {
+		t.Errorf("Expected at most 20 lines, got %d", len(lines))
+	}
+	for _, line := range lines {
+		if ansi.StringWidth(line) > 80 || line != strings.TrimRight(line, " ") {
+			t.Errorf("Expected lines clipped to 80 columns without trailing spaces, got %q", line)
+		}
+	}
+	if strings.Contains(frame, "\x1b[") {
+		t.Error("Expected plain text without --ansi")
+	}
+	if !strings.Contains(render("--ansi"), "\x1b[") {
+		t.Error("Expected escape codes with --ansi")
+	}
+	if render() != render() {
+		t.Error("Expected the same frame for the same time")
+	}
+	if time.Since(clock()) > time.Minute {
+		t.Error("Expected --now to be reset after rendering")
+	}
+
+	// Keys drive the form, but what they submit is never saved.
+	if form := render("--keys", "+,Review"); !strings.Contains(form, "New Event") || !strings.Contains(form, "> Review") {
+		t.Errorf("Expected the add form with the typed name:\n%s", form)
+	}
+	render("--keys", "-")
+	if got, err := readProfileEvents(defaultProfile); err != nil || !equalEvents(got, events) {
+		t.Errorf("Expected the profile untouched, got %v (err %v)", got, err)
+	}
+
+	c := &cli{profile: defaultProfile, stdout: &bytes.Buffer{}}
+	if err := c.run([]string{"render", "--now", "soon"}); err == nil {
+		t.Error("Expected an invalid --now to fail")
+	}
+}
+
+func TestRenderFrameKeys(t *testing.T) {
+	newTestHelper(t)
+	if err := saveProfileEvents(defaultProfile, []Event{{ID: "a1", Name: "Launch", Time: 1900000000}}); err != nil {
+		t.Fatal(err)
+	}
+	frame, err := renderFrame(defaultProfile, 100, 30, false, []tea.KeyPressMsg{{Code: 'e', Text: "e"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if plain := ansi.Strip(frame); !strings.Contains(plain, "Edit Event") || !strings.Contains(plain, "Launch") {
+		t.Errorf("Expected the edit form for the event:\n%s", ansi.Strip(frame))
+	}
+}
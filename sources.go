@@ -0,0 +1,198 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"sort"
+	"strings"
+	"time"
+)
+
+const (
+	personalSourceName = "personal"
+	systemSourceName   = "system"
+)
+
+// systemEventsFile is the machine-wide layer merged beneath every profile.
+// It is a variable so tests can point it somewhere harmless.
+var systemEventsFile = filepath.Join(string(filepath.Separator), "etc", appName, eventsFileName)
+
+// eventSource is a read-only events file merged into a profile, such as a
+// team calendar checked into a repository. A source with a URL instead of
+// a path is a feed, fetched periodically and read from a local cache, and
+// one with a command is a plugin, run periodically and cached the same way.
+type eventSource struct {
+	Name string `json:"name"`
+	Path string `json:"path,omitempty"`
+	URL  string `json:"url,omitempty"`
+	// Command is a plugin's executable and its arguments. It prints one
+	// event per line as JSON on stdout.
+	Command []string `json:"command,omitempty"`
+	// Timeout is how long a plugin may run, e.g. "30s".
+	Timeout string `json:"timeout,omitempty"`
+	// Format names the import format of a file kept by another tool, such
+	// as "taskwarrior" or "todotxt". It is read afresh on every launch.
+	// Empty means an events JSON file.
+	Format string `json:"format,omitempty"`
+	// Interval is how often a feed is refetched or a plugin rerun, e.g.
+	// "30m".
+	Interval string `json:"interval,omitempty"`
+	// Profiles limits the source to the named profiles. Empty means all.
+	Profiles []string `json:"profiles,omitempty"`
+}
+
+func (s eventSource) appliesTo(profile string) bool {
+	return len(s.Profiles) == 0 || slices.Contains(s.Profiles, profile)
+}
+
+// layers returns the read-only sources for a profile, lowest precedence
+// first. The system file always comes first and the configured sources
+// follow in the order they are listed.
+func (c config) layers(profile string) []eventSource {
+	layers := []eventSource{{Name: systemSourceName, Path: systemEventsFile}}
+	for _, src := range c.Sources {
+		if src.appliesTo(profile) {
+			layers = append(layers, src)
+		}
+	}
+	return layers
+}
+
+// readSourceFile reads a read-only layer and tags each event with the
+// layer's name. A missing file yields no events, since shared locations
+// such as network mounts aren't always available. Feeds are read from
+// their cached copy, which is empty until the first fetch.
+func readSourceFile(src eventSource) ([]Event, error) {
+	if src.isFeed() {
+		events, err := readFeedEvents(src)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse %s %s: %w", src.Name, src.kind(), err)
+		}
+		return tagSource(events, src.Name), nil
+	}
+	path, err := resolvePath(src.Path)
+	if err != nil {
+		return nil, err
+	}
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	events, err := decodeSource(src, data)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse %s source %s: %w", src.Name, path, err)
+	}
+	return tagSource(events, src.Name), nil
+}
+
+// decodeSource parses a source file in its configured format.
+func decodeSource(src eventSource, data []byte) ([]Event, error) {
+	if src.Format == "" {
+		return decodeEvents(data)
+	}
+	format, ok := importFormatByName(src.Format)
+	if !ok {
+		return nil, fmt.Errorf("unknown format %q, use one of %s", src.Format, strings.Join(importFormatNames(), ", "))
+	}
+	return format.parse(data, importOptions{zone: time.Local})
+}
+
+// tagSource marks events as belonging to the named read-only layer.
+func tagSource(events []Event, name string) []Event {
+	for i := range events {
+		events[i].Source = name
+	}
+	return events
+}
+
+// mergeLayers combines event layers, lowest precedence first. An event in a
+// later layer replaces the event with the same key from an earlier one;
+// events within a layer are all kept, so none of the last layer's events
+// are ever dropped. The result is sorted by time.
+func mergeLayers(layers ...[]Event) []Event {
+	index := make(map[string]int)
+	var merged []Event
+	for _, layer := range layers {
+		// seen holds the keys used in this layer, which only later layers
+		// may replace.
+		seen := make(map[string]bool)
+		for _, event := range layer {
+			key := event.key()
+			if i, ok := index[key]; ok && !seen[key] {
+				merged[i] = event
+			} else {
+				if !seen[key] {
+					index[key] = len(merged)
+				}
+				merged = append(merged, event)
+			}
+			seen[key] = true
+		}
+	}
+	sort.SliceStable(merged, func(i, j int) bool { return merged[i].Time < merged[j].Time })
+	return merged
+}
+
+// loadProfileEvents returns a profile's own events merged with the events
+// of the enabled providers and every read-only layer that applies to it,
+// with yearly events moved to their next occurrence. Generated events have
+// the lowest precedence.
+func loadProfileEvents(profile string) ([]Event, error) {
+	cfg, err := loadConfig()
+	if err != nil {
+		return nil, err
+	}
+	providers, err := cfg.providers(profile)
+	if err != nil {
+		return nil, err
+	}
+	now := clock()
+	var layers [][]Event
+	for _, p := range providers {
+		events, err := p.upcoming(now)
+		if err != nil {
+			return nil, err
+		}
+		layers = append(layers, events)
+	}
+	for _, src := range cfg.layers(profile) {
+		events, err := readSourceFile(src)
+		if err != nil {
+			return nil, err
+		}
+		layers = append(layers, events)
+	}
+	personal, err := readProfileEvents(profile)
+	if err != nil {
+		return nil, err
+	}
+	layers = append(layers, personal)
+	for _, layer := range layers {
+		for i := range layer {
+			layer[i] = layer[i].occurrence(now)
+		}
+	}
+	return mergeLayers(layers...), nil
+}
+
+// loadOwnEvents returns only a profile's own events, with yearly events
+// moved to their next occurrence and sorted by time, for commands that
+// leave out what the user didn't create unless asked.
+func loadOwnEvents(profile string) ([]Event, error) {
+	events, err := readProfileEvents(profile)
+	if err != nil {
+		return nil, err
+	}
+	now := clock()
+	for i := range events {
+		events[i] = events[i].occurrence(now)
+	}
+	sort.SliceStable(events, func(i, j int) bool { return events[i].Time < events[j].Time })
+	return events, nil
+}
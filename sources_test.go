@@ -0,0 +1,159 @@
+package main
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+
+	tea "charm.land/bubbletea/v2"
+)
+
+// writeJSON marshals v into path, creating parent directories as needed.
+func writeJSON(t *testing.T, path string, v any) {
+	t.Helper()
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	data, err := json.Marshal(v)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(path, data, 0o644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestMergeLayers(t *testing.T) {
+	base := []Event{
+		{Name: "Holiday", Time: 300, Source: "company"},
+		{Name: "Release", Time: 100, Source: "company"},
+		{ID: "q4", Name: "Planning", Time: 400, Source: "company"},
+	}
+	team := []Event{
+		{Name: "Release", Time: 100, Source: "team", Tags: []string{"team"}},
+		{Name: "Holiday", Time: 350, Source: "team"},
+	}
+	personal := []Event{
+		{ID: "a", Name: "Dentist", Time: 50},
+		{ID: "b", Name: "Dentist", Time: 60},
+		{ID: "q4", Name: "Planning (moved)", Time: 450},
+	}
+
+	merged := mergeLayers(base, team, personal)
+	want := []Event{
+		{ID: "a", Name: "Dentist", Time: 50},
+		{ID: "b", Name: "Dentist", Time: 60},
+		{Name: "Release", Time: 100, Source: "team", Tags: []string{"team"}},
+		{Name: "Holiday", Time: 300, Source: "company"},
+		{Name: "Holiday", Time: 350, Source: "team"},
+		{ID: "q4", Name: "Planning (moved)", Time: 450},
+	}
+	if !reflect.DeepEqual(merged, want) {
+		t.Errorf("mergeLayers() = %v, want %v", merged, want)
+	}
+
+	// Events with the same key in one layer are all kept.
+	twice := []Event{{ID: "x", Name: "One", Time: 1}, {ID: "x", Name: "Two", Time: 2}}
+	if merged := mergeLayers([]Event{{ID: "x", Name: "Old", Time: 1}}, twice); len(merged) != 2 || merged[0].Name != "One" {
+		t.Errorf("Expected both events of the layer, got %v", merged)
+	}
+}
+
+func TestLoadProfileEventsKeepsSameNameEvents(t *testing.T) {
+	newTestHelper(t)
+	future := time.Now().Add(24 * time.Hour).Unix()
+	events := []Event{
+		{ID: "a", Name: "Dentist", Time: future},
+		{ID: "b", Name: "Dentist", Time: future + 3600},
+	}
+	if err := saveProfileEvents(defaultProfile, events); err != nil {
+		t.Fatal(err)
+	}
+	loaded, err := loadProfileEvents(defaultProfile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !equalEvents(loaded, events) {
+		t.Errorf("Expected both Dentist events, got %v", loaded)
+	}
+}
+
+func TestLoadProfileEvents(t *testing.T) {
+	th := newTestHelper(t)
+	future := time.Now().Add(24 * time.Hour).Unix()
+
+	writeJSON(t, systemEventsFile, []Event{{Name: "Company Holiday", Time: future}})
+	writeJSON(t, filepath.Join(th.testConfigDir, "team.json"), []Event{
+		{Name: "Code Freeze", Time: future + 10},
+	})
+	configFile, err := getConfigFilePath()
+	if err != nil {
+		t.Fatal(err)
+	}
+	writeJSON(t, configFile, config{Sources: []eventSource{
+		{Name: "team", Path: filepath.Join(th.testConfigDir, "team.json"), Profiles: []string{"work"}},
+		{Name: "missing", Path: "does-not-exist.json"},
+	}})
+
+	events, err := loadProfileEvents("work")
+	if err != nil {
+		t.Fatalf("loadProfileEvents(work) failed: %v", err)
+	}
+	sources := map[string]string{}
+	for _, e := range events {
+		sources[e.Name] = e.SourceName()
+	}
+	if sources["Company Holiday"] != systemSourceName {
+		t.Errorf("Expected system source for Company Holiday, got %q", sources["Company Holiday"])
+	}
+	if sources["Code Freeze"] != "team" {
+		t.Errorf("Expected team source for Code Freeze, got %q", sources["Code Freeze"])
+	}
+
+	events, err = loadProfileEvents(defaultProfile)
+	if err != nil {
+		t.Fatalf("loadProfileEvents(default) failed: %v", err)
+	}
+	for _, e := range events {
+		if e.Name == "Code Freeze" {
+			t.Error("Team source restricted to the work profile leaked into the default profile")
+		}
+	}
+}
+
+func TestReadOnlyEvents(t *testing.T) {
+	newTestHelper(t)
+	seedGoBirthday(t)
+	future := time.Now().Add(24 * time.Hour).Unix()
+	writeJSON(t, systemEventsFile, []Event{{Name: "Company Holiday", Time: future}})
+
+	m, err := NewMainModel(defaultProfile)
+	if err != nil {
+		t.Fatalf("NewMainModel() failed: %v", err)
+	}
+	if event, _ := m.events.SelectedItem().(Event); !event.ReadOnly() {
+		t.Fatalf("Expected the earliest event to be the read-only holiday, got %v", event)
+	}
+
+	updated, _ := m.Update(tea.KeyPressMsg{Code: '-', Text: "-"})
+	m = updated.(MainModel)
+	if len(m.events.Items()) != 2 {
+		t.Errorf("Expected removing a read-only event to be refused, got %d events", len(m.events.Items()))
+	}
+
+	if err := m.saveEventsToFile(); err != nil {
+		t.Fatalf("saveEventsToFile() failed: %v", err)
+	}
+	personal, err := readProfileEvents(defaultProfile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, e := range personal {
+		if e.Name == "Company Holiday" {
+			t.Error("Read-only event was written to the personal events file")
+		}
+	}
+}
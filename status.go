@@ -0,0 +1,262 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"text/template"
+	"time"
+
+	"github.com/charmbracelet/x/ansi"
+)
+
+const (
+	defaultStatusFormat = "{{.Name}} {{.Short}}"
+	statusCacheDirName  = "status"
+)
+
+// statusFields are what a status --format template can use.
+type statusFields struct {
+	ID, Name, Source string
+	Tags             []string
+	// Short is the time left as shortCountdown writes it, Long as the TUI
+	// does.
+	Short, Long string
+	// Date is when the event happens, e.g. "Tue Nov 10 00:00".
+	Date string
+	Time time.Time
+	Left time.Duration
+}
+
+func newStatusFields(e Event, now time.Time) statusFields {
+	t := time.Unix(e.Time, 0)
+	left := e.timeLeft(now).Truncate(time.Second)
+	short, long := shortCountdown(left), formatCountdown(int(left.Seconds()))
+	if e.onItsDay(now) {
+		short, long = strings.ToLower(todayText), todayText
+	}
+	return statusFields{
+		ID:     e.ID,
+		Name:   e.Name,
+		Source: e.Source,
+		Tags:   e.Tags,
+		Short:  short,
+		Long:   long,
+		Date:   t.Format("Mon Jan 2 15:04"),
+		Time:   t,
+		Left:   left,
+	}
+}
+
+// nextEvent returns the first event that hasn't happened yet. Events are
+// sorted by time.
+func nextEvent(events []Event, now time.Time) (Event, bool) {
+	for _, e := range events {
+		if e.Time >= now.Unix() || e.onItsDay(now) {
+			return e, true
+		}
+	}
+	return Event{}, false
+}
+
+// statusCache is the next event of a profile, kept so shell prompts don't
+// merge every layer on each redraw. It is stale once the event has
+// passed, at midnight, when generated and yearly events move on, or when
+// any file it was built from changes.
+type statusCache struct {
+	// Inputs maps each file read to its modification time in nanoseconds,
+	// or 0 if it didn't exist.
+	Inputs  map[string]int64 `json:"inputs"`
+	Expires int64            `json:"expires"`
+	Event   *Event           `json:"event,omitempty"`
+	// Source is the event's layer, which events.json doesn't store.
+	Source string `json:"source,omitempty"`
+}
+
+// getStatusCachePath returns where a profile's status cache is kept.
+func getStatusCachePath(profile string) (string, error) {
+	cacheDir, err := os.UserCacheDir()
+	if err != nil {
+		return "", fmt.Errorf("failed to get user cache directory: %w", err)
+	}
+	dir := filepath.Join(cacheDir, appName, statusCacheDirName)
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return "", fmt.Errorf("failed to create status cache directory: %w", err)
+	}
+	return filepath.Join(dir, profile+".json"), nil
+}
+
+func modTime(path string) int64 {
+	info, err := os.Stat(path)
+	if err != nil {
+		return 0
+	}
+	return info.ModTime().UnixNano()
+}
+
+// fresh reports whether a cache can still be used at now.
+func (sc statusCache) fresh(now time.Time) bool {
+	if now.Unix() >= sc.Expires || len(sc.Inputs) == 0 {
+		return false
+	}
+	return unchanged(sc.Inputs)
+}
+
+// snapshotInputs notes the modification times of the files a profile's
+// events are read from.
+func snapshotInputs(cfg config, profile string) (map[string]int64, error) {
+	inputs, err := statusInputs(cfg, profile)
+	if err != nil {
+		return nil, err
+	}
+	snapshot := make(map[string]int64, len(inputs))
+	for _, path := range inputs {
+		snapshot[path] = modTime(path)
+	}
+	return snapshot, nil
+}
+
+// unchanged reports whether no file in a snapshot has changed since.
+func unchanged(snapshot map[string]int64) bool {
+	for path, mtime := range snapshot {
+		if modTime(path) != mtime {
+			return false
+		}
+	}
+	return true
+}
+
+// statusInputs returns the files a profile's events are read from.
+func statusInputs(cfg config, profile string) ([]string, error) {
+	configFile, err := getConfigFilePath()
+	if err != nil {
+		return nil, err
+	}
+	eventsFile, err := getProfileFilePath(profile)
+	if err != nil {
+		return nil, err
+	}
+	inputs := []string{configFile, eventsFile}
+	for _, src := range cfg.layers(profile) {
+		var path string
+		if src.isFeed() {
+			path, err = getFeedCachePath(src.cacheKey())
+		} else {
+			path, err = resolvePath(src.Path)
+		}
+		if err != nil {
+			return nil, err
+		}
+		inputs = append(inputs, path)
+	}
+	return inputs, nil
+}
+
+// buildStatusCache works out a profile's next event and how long that
+// answer holds.
+func buildStatusCache(profile string, now time.Time) (statusCache, error) {
+	cfg, err := loadConfig()
+	if err != nil {
+		return statusCache{}, err
+	}
+	// Note the modification times first, so a change made while the
+	// events load makes the cache stale rather than lost.
+	inputs, err := snapshotInputs(cfg, profile)
+	if err != nil {
+		return statusCache{}, err
+	}
+	sc := statusCache{Inputs: inputs}
+	events, err := loadProfileEvents(profile)
+	if err != nil {
+		return statusCache{}, err
+	}
+	sc.Expires = time.Date(now.Year(), now.Month(), now.Day()+1, 0, 0, 0, 0, now.Location()).Unix()
+	if e, ok := nextEvent(events, now); ok {
+		sc.Event, sc.Source = &e, e.Source
+		sc.Expires = min(sc.Expires, e.Time+1)
+	}
+	return sc, nil
+}
+
+// cachedNextEvent returns a profile's next event from its status cache,
+// rebuilding the cache when it is stale. Encrypted profiles are never
+// cached, since the cache is kept in plain text.
+func cachedNextEvent(profile string, now time.Time) (Event, bool, error) {
+	path, err := getStatusCachePath(profile)
+	if err != nil {
+		return Event{}, false, err
+	}
+	var sc statusCache
+	// A missing or unreadable cache is simply rebuilt.
+	data, err := os.ReadFile(path)
+	if err != nil || json.Unmarshal(data, &sc) != nil || !sc.fresh(now) {
+		if sc, err = buildStatusCache(profile, now); err != nil {
+			return Event{}, false, err
+		}
+		if err := writeStatusCache(path, sc); err != nil {
+			return Event{}, false, err
+		}
+	}
+	if sc.Event == nil {
+		return Event{}, false, nil
+	}
+	sc.Event.Source = sc.Source
+	return *sc.Event, true, nil
+}
+
+func writeStatusCache(path string, sc statusCache) error {
+	if secrets.encrypt {
+		return nil
+	}
+	data, err := json.Marshal(sc)
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(path, data, 0o600)
+}
+
+// runStatus prints a single line about the next event for shell prompts
+// and status bars. It prints nothing when no event is to come.
+func runStatus(c *cli, args []string) error {
+	fs := c.newFlagSet("status")
+	format := fs.String("format", defaultStatusFormat, "Go template with .Name, .Short, .Long, .Date, .Tags, .Source, .ID, .Time and .Left")
+	maxWidth := fs.Int("max-width", 0, "truncate the line to this many columns, 0 for no limit")
+	cached := fs.Bool("cache", false, "reuse the last answer until the event passes or the events change")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if fs.NArg() > 0 {
+		return errUsage
+	}
+	tmpl, err := template.New("status").Parse(*format)
+	if err != nil {
+		return fmt.Errorf("-format: %w", err)
+	}
+
+	now := time.Now()
+	var event Event
+	var ok bool
+	if *cached {
+		event, ok, err = cachedNextEvent(c.profile, now)
+	} else {
+		var events []Event
+		if events, err = loadProfileEvents(c.profile); err == nil {
+			event, ok = nextEvent(events, now)
+		}
+	}
+	if err != nil || !ok {
+		return err
+	}
+	var b strings.Builder
+	if err := tmpl.Execute(&b, newStatusFields(event, now)); err != nil {
+		return err
+	}
+	line := strings.ReplaceAll(strings.TrimRight(b.String(), "\n"), "\n", " ")
+	if *maxWidth > 0 {
+		line = ansi.Truncate(line, *maxWidth, "…")
+	}
+	_, err = fmt.Fprintln(c.stdout, line)
+	return err
+}
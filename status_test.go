@@ -0,0 +1,95 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"os"
+	"testing"
+	"time"
+)
+
+func runStatusLine(t *testing.T, args ...string) string {
+	t.Helper()
+	var out bytes.Buffer
+	c := &cli{profile: defaultProfile, stdout: &out, stderr: &out}
+	if err := c.run(append([]string{"status"}, args...)); err != nil {
+		t.Fatalf("status %v failed: %v", args, err)
+	}
+	return out.String()
+}
+
+func TestStatusCommand(t *testing.T) {
+	newTestHelper(t)
+	if got := runStatusLine(t); got != "" {
+		t.Errorf("Expected no output without events, got %q", got)
+	}
+
+	now := time.Now()
+	events := []Event{
+		{Name: "Retro", Time: now.Add(-time.Hour).Unix()},
+		{ID: "f", Name: "Code freeze", Time: now.Add(3*time.Hour + 30*time.Second).Unix(), Tags: []string{"release"}},
+		{Name: "Launch", Time: now.Add(10 * 24 * time.Hour).Unix()},
+	}
+	if err := saveProfileEvents(defaultProfile, events); err != nil {
+		t.Fatal(err)
+	}
+	if got := runStatusLine(t); got != "Code freeze 3h 0m\n" {
+		t.Errorf("Expected the next event, got %q", got)
+	}
+	got := runStatusLine(t, "--format", "{{.ID}}|{{index .Tags 0}}|{{.Long}}")
+	if got != "f|release|3h 0m 29s\n" && got != "f|release|3h 0m 30s\n" {
+		t.Errorf("Expected the template fields, got %q", got)
+	}
+	if got := runStatusLine(t, "--max-width", "8"); got != "Code fr…\n" {
+		t.Errorf("Expected the line truncated to 8 columns, got %q", got)
+	}
+
+	var out bytes.Buffer
+	c := &cli{profile: defaultProfile, stdout: &out, stderr: &out}
+	if err := c.run([]string{"status", "--format", "{{.Nope"}); err == nil {
+		t.Error("Expected an invalid template to fail")
+	}
+}
+
+func TestStatusCache(t *testing.T) {
+	newTestHelper(t)
+	now := time.Now()
+	if err := saveProfileEvents(defaultProfile, []Event{{Name: "Launch", Time: now.Add(49 * time.Hour).Unix()}}); err != nil {
+		t.Fatal(err)
+	}
+	if got := runStatusLine(t, "--cache"); got != "Launch 2 days\n" {
+		t.Fatalf("Expected the next event, got %q", got)
+	}
+
+	// A fresh cache is answered without reading the events again.
+	path, err := getStatusCachePath(defaultProfile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var sc statusCache
+	data, err := os.ReadFile(path)
+	if err != nil || json.Unmarshal(data, &sc) != nil || sc.Event == nil {
+		t.Fatalf("Expected a status cache, got %s (err %v)", data, err)
+	}
+	sc.Event.Name = "Cached"
+	data, _ = json.Marshal(sc)
+	if err := os.WriteFile(path, data, 0o600); err != nil {
+		t.Fatal(err)
+	}
+	if got := runStatusLine(t, "--cache"); got != "Cached 2 days\n" {
+		t.Errorf("Expected the cached event, got %q", got)
+	}
+
+	// Changing the events makes it stale.
+	if err := saveProfileEvents(defaultProfile, []Event{{Name: "Demo", Time: now.Add(5 * time.Hour).Unix()}}); err != nil {
+		t.Fatal(err)
+	}
+	if got := runStatusLine(t, "--cache"); got != "Demo 5h 0m\n" && got != "Demo 4h 59m\n" {
+		t.Errorf("Expected the cache to be rebuilt, got %q", got)
+	}
+
+	sc.Expires = now.Unix()
+	if sc.fresh(now) {
+		t.Error("Expected an expired cache to be stale")
+	}
+}
@@ -0,0 +1,413 @@
+package main
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"strings"
+	"sync"
+)
+
+const (
+	defaultSyncBranch = "main"
+	syncRemoteName    = "origin"
+)
+
+// repoMu serializes saves with the git commands a sync runs in the config
+// directory, since the TUI saves while a background sync is running and
+// git fails on a concurrent index.lock.
+var repoMu sync.Mutex
+
+// syncConfig turns the config directory into a git repository that is
+// committed on every save and pulled/pushed against Remote.
+type syncConfig struct {
+	Remote string `json:"remote"`
+	Branch string `json:"branch,omitempty"`
+}
+
+func (s syncConfig) enabled() bool { return s.Remote != "" }
+
+func (s syncConfig) branch() string {
+	if s.Branch == "" {
+		return defaultSyncBranch
+	}
+	return s.Branch
+}
+
+// syncIgnore keeps machine-local files out of the repository. The history
+// log is append-only on every machine, so it merges as a union of lines.
+const (
+	syncIgnore     = "backups/\n*.corrupt\n" + configFileName + "\n" + caldavStateFileName + "\n"
+	syncAttributes = historyFileName + " merge=union\n"
+)
+
+// syncedPaths are the only paths committed. Everything else in the config
+// directory, such as a key file, stays on the machine.
+var syncedPaths = []string{eventsFileName, profilesDirName, historyFileName, ".gitignore", ".gitattributes"}
+
+// gitRepo runs git commands in the config directory.
+type gitRepo struct {
+	dir string
+	// identity supplies a committer when the user hasn't configured one,
+	// so commits don't fail on a fresh machine.
+	identity []string
+}
+
+// output runs git and returns its raw stdout.
+func (r gitRepo) output(args ...string) ([]byte, error) {
+	cmd := exec.Command("git", append(append([]string{"-C", r.dir}, r.identity...), args...)...)
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+	out, err := cmd.Output()
+	if err != nil {
+		return nil, fmt.Errorf("git %s: %w: %s", args[0], err, strings.TrimSpace(stderr.String()))
+	}
+	return out, nil
+}
+
+// run runs git and returns its stdout with surrounding space trimmed.
+func (r gitRepo) run(args ...string) (string, error) {
+	out, err := r.output(args...)
+	return strings.TrimSpace(string(out)), err
+}
+
+// succeeds runs git and reports whether it exited successfully, for
+// commands that answer yes/no through their exit status.
+func (r gitRepo) succeeds(args ...string) bool {
+	_, err := r.output(args...)
+	return err == nil
+}
+
+// openSyncRepo initialises the config directory as a git repository on
+// first use and points its remote at the configured URL.
+func openSyncRepo(cfg syncConfig) (gitRepo, error) {
+	eventsFile, err := getEventsFilePath()
+	if err != nil {
+		return gitRepo{}, err
+	}
+	r := gitRepo{dir: filepath.Dir(eventsFile)}
+	if _, err := os.Stat(filepath.Join(r.dir, ".git")); errors.Is(err, os.ErrNotExist) {
+		if _, err := r.run("init", "-q"); err != nil {
+			return r, err
+		}
+		if _, err := r.run("symbolic-ref", "HEAD", "refs/heads/"+cfg.branch()); err != nil {
+			return r, err
+		}
+	}
+	for name, content := range map[string]string{".gitignore": syncIgnore, ".gitattributes": syncAttributes} {
+		p := filepath.Join(r.dir, name)
+		if _, err := os.Stat(p); errors.Is(err, os.ErrNotExist) {
+			if err := os.WriteFile(p, []byte(content), 0o644); err != nil {
+				return r, err
+			}
+		}
+	}
+	if url, err := r.run("remote", "get-url", syncRemoteName); err != nil {
+		if _, err := r.run("remote", "add", syncRemoteName, cfg.Remote); err != nil {
+			return r, err
+		}
+	} else if url != cfg.Remote {
+		if _, err := r.run("remote", "set-url", syncRemoteName, cfg.Remote); err != nil {
+			return r, err
+		}
+	}
+	if email, _ := r.run("config", "user.email"); email == "" {
+		r.identity = []string{"-c", "user.name=" + appName, "-c", "user.email=" + appName + "@localhost"}
+	}
+	return r, nil
+}
+
+// commitAll commits every change to the synced paths, if there are any,
+// and concludes a merge in progress.
+func (r gitRepo) commitAll(message string) error {
+	args := []string{"add", "-A", "--"}
+	for _, p := range syncedPaths {
+		// git add fails on a pathspec that matches nothing, so skip paths
+		// that neither exist nor were ever committed.
+		_, err := os.Stat(filepath.Join(r.dir, p))
+		if tracked, _ := r.run("ls-files", "--", p); err == nil || tracked != "" {
+			args = append(args, p)
+		}
+	}
+	if _, err := r.run(args...); err != nil {
+		return err
+	}
+	if r.succeeds("diff", "--cached", "--quiet") && !r.succeeds("rev-parse", "-q", "--verify", "MERGE_HEAD") {
+		return nil
+	}
+	_, err := r.run("commit", "-q", "-m", message)
+	return err
+}
+
+// commitSave records a save of a profile when sync is enabled.
+func commitSave(cfg syncConfig, profile string) error {
+	if !cfg.enabled() {
+		return nil
+	}
+	r, err := openSyncRepo(cfg)
+	if err != nil {
+		return err
+	}
+	return r.commitAll("Update " + profile)
+}
+
+// syncEvents syncs the configured CalDAV calendar, then commits any local
+// changes, merges the remote branch and pushes the result. It does nothing
+// for whichever of the two isn't configured.
+func syncEvents() error {
+	cfg, err := loadConfig()
+	if err != nil {
+		return err
+	}
+	if cfg.CalDAV.enabled() {
+		if err := syncCalDAV(cfg.CalDAV); err != nil {
+			return fmt.Errorf("caldav: %w", err)
+		}
+	}
+	if !cfg.Sync.enabled() {
+		return nil
+	}
+	repoMu.Lock()
+	defer repoMu.Unlock()
+	r, err := openSyncRepo(cfg.Sync)
+	if err != nil {
+		return err
+	}
+	if err := r.commitAll("Sync"); err != nil {
+		return err
+	}
+	if err := r.pull(cfg.Sync.branch()); err != nil {
+		return err
+	}
+	if !r.succeeds("rev-parse", "-q", "--verify", "HEAD") {
+		return nil
+	}
+	_, err = r.run("push", "-q", syncRemoteName, "HEAD:refs/heads/"+cfg.Sync.branch())
+	return err
+}
+
+// pull fetches the remote branch and brings it into the local one, fast
+// forwarding when possible and merging event lists by ID otherwise.
+func (r gitRepo) pull(branch string) error {
+	if heads, err := r.run("ls-remote", "--heads", syncRemoteName, branch); err != nil || heads == "" {
+		return err
+	}
+	if _, err := r.run("fetch", "-q", syncRemoteName, branch); err != nil {
+		return err
+	}
+	switch {
+	case !r.succeeds("rev-parse", "-q", "--verify", "HEAD"):
+		_, err := r.run("reset", "-q", "--hard", "FETCH_HEAD")
+		return err
+	case r.succeeds("merge-base", "--is-ancestor", "FETCH_HEAD", "HEAD"):
+		return nil
+	case r.succeeds("merge-base", "--is-ancestor", "HEAD", "FETCH_HEAD"):
+		_, err := r.run("merge", "-q", "--ff-only", "FETCH_HEAD")
+		return err
+	}
+	return r.mergeEventFiles()
+}
+
+// isSyncedEventsFile reports whether a repository path holds events that
+// should be merged by ID.
+func isSyncedEventsFile(p string) bool {
+	dir, file := path.Split(p)
+	return p == eventsFileName || (dir == profilesDirName+"/" && path.Ext(file) == ".json")
+}
+
+// show returns a file's contents at a revision, or nil if it doesn't exist
+// there.
+func (r gitRepo) show(rev, p string) ([]byte, error) {
+	if rev == "" || !r.succeeds("cat-file", "-e", rev+":"+p) {
+		return nil, nil
+	}
+	return r.output("show", rev+":"+p)
+}
+
+// mergeEventFiles merges FETCH_HEAD. git merges most files, but event
+// files are merged by ID here and the history log is the union of both
+// sides, whatever git made of them.
+func (r gitRepo) mergeEventFiles() error {
+	base, _ := r.run("merge-base", "HEAD", "FETCH_HEAD")
+	var paths []string
+	for _, rev := range []string{"HEAD", "FETCH_HEAD"} {
+		out, err := r.run("ls-tree", "-r", "--name-only", rev)
+		if err != nil {
+			return err
+		}
+		paths = append(paths, strings.Split(out, "\n")...)
+	}
+	sort.Strings(paths)
+
+	merged := make(map[string][]byte)
+	removed := make(map[string]bool)
+	for i, p := range paths {
+		if i > 0 && paths[i-1] == p || (!isSyncedEventsFile(p) && p != historyFileName) {
+			continue
+		}
+		var sides [3][]byte
+		for j, rev := range []string{base, "HEAD", "FETCH_HEAD"} {
+			data, err := r.show(rev, p)
+			if err != nil {
+				return err
+			}
+			sides[j] = data
+		}
+		if p == historyFileName {
+			merged[p] = unionLines(sides[1], sides[2])
+			continue
+		}
+		var lists [3][]Event
+		for j, data := range sides {
+			if data == nil {
+				continue
+			}
+			events, err := decodeEvents(data)
+			if err != nil {
+				return fmt.Errorf("failed to merge %s: %w", p, err)
+			}
+			lists[j] = events
+		}
+		events := mergeEvents(lists[0], lists[1], lists[2])
+		if len(events) == 0 && (sides[1] == nil || sides[2] == nil) {
+			// A side removed the file and nothing was added to it since.
+			removed[p] = true
+			continue
+		}
+		data, err := encodeEvents(events)
+		if err != nil {
+			return err
+		}
+		merged[p] = data
+	}
+
+	args := []string{"merge", "-q", "--no-commit", "--no-ff"}
+	if base == "" {
+		args = append(args, "--allow-unrelated-histories")
+	}
+	if _, mergeErr := r.run(append(args, "FETCH_HEAD")...); mergeErr != nil {
+		// Conflicts in the files merged here are expected; any others
+		// are left for the user.
+		conflicts, err := r.run("diff", "--name-only", "--diff-filter=U")
+		if err != nil {
+			return err
+		}
+		resolved := conflicts != ""
+		for line := range strings.Lines(conflicts) {
+			p := strings.TrimSpace(line)
+			if _, ok := merged[p]; !ok && !removed[p] {
+				resolved = false
+			}
+		}
+		if !resolved {
+			_, _ = r.run("merge", "--abort")
+			return mergeErr
+		}
+	}
+	for p := range removed {
+		if err := os.Remove(filepath.Join(r.dir, filepath.FromSlash(p))); err != nil && !errors.Is(err, os.ErrNotExist) {
+			return err
+		}
+	}
+	for p, data := range merged {
+		file := filepath.Join(r.dir, filepath.FromSlash(p))
+		if err := os.MkdirAll(filepath.Dir(file), 0o755); err != nil {
+			return err
+		}
+		if err := os.WriteFile(file, data, 0o644); err != nil {
+			return err
+		}
+	}
+	return r.commitAll("Merge events from " + syncRemoteName)
+}
+
+// unionLines returns the lines of ours followed by the lines of theirs that
+// ours doesn't already have.
+func unionLines(ours, theirs []byte) []byte {
+	seen := make(map[string]bool)
+	var out bytes.Buffer
+	for _, data := range [][]byte{ours, theirs} {
+		for line := range strings.Lines(string(data)) {
+			line = strings.TrimSuffix(line, "\n")
+			if line == "" || seen[line] {
+				continue
+			}
+			seen[line] = true
+			out.WriteString(line + "\n")
+		}
+	}
+	return out.Bytes()
+}
+
+// mergeEvents is a three-way merge of event lists keyed by event ID. A side
+// that changed an event since base wins over one that didn't; when both
+// changed it differently the local edit wins, and an edit beats a removal.
+func mergeEvents(base, ours, theirs []Event) []Event {
+	index := func(events []Event) map[string]Event {
+		m := make(map[string]Event, len(events))
+		for _, e := range events {
+			m[e.key()] = e
+		}
+		return m
+	}
+	b, o, t := index(base), index(ours), index(theirs)
+
+	var keys []string
+	seen := make(map[string]bool)
+	for _, e := range append(append([]Event(nil), ours...), theirs...) {
+		if k := e.key(); !seen[k] {
+			seen[k] = true
+			keys = append(keys, k)
+		}
+	}
+
+	merged := []Event{}
+	for _, k := range keys {
+		be, inBase := b[k]
+		oe, inOurs := o[k]
+		te, inTheirs := t[k]
+		oursChanged := inOurs != inBase || !reflect.DeepEqual(oe, be)
+		theirsChanged := inTheirs != inBase || !reflect.DeepEqual(te, be)
+		event, keep := oe, inOurs
+		if theirsChanged && (!oursChanged || !inOurs) {
+			event, keep = te, inTheirs
+		}
+		if keep {
+			merged = append(merged, event)
+		}
+	}
+	sort.SliceStable(merged, func(i, j int) bool { return merged[i].Time < merged[j].Time })
+	return merged
+}
+
+func runSync(c *cli, args []string) error {
+	fs := c.newFlagSet("sync")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if fs.NArg() > 0 {
+		return errUsage
+	}
+	cfg, err := loadConfig()
+	if err != nil {
+		return err
+	}
+	if !cfg.Sync.enabled() && !cfg.CalDAV.enabled() {
+		return fmt.Errorf("sync is not configured: set \"sync\" or \"caldav\" in %s", configFileName)
+	}
+	if err := syncEvents(); err != nil {
+		return err
+	}
+	for _, target := range []string{cfg.CalDAV.URL, cfg.Sync.Remote} {
+		if target != "" {
+			fmt.Fprintf(c.stdout, "Synced with %s\n", target)
+		}
+	}
+	return nil
+}
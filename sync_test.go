@@ -0,0 +1,337 @@
+package main
+
+import (
+	"errors"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+func TestMergeEvents(t *testing.T) {
+	a := Event{ID: "a", Name: "A", Time: 100}
+	b := Event{ID: "b", Name: "B", Time: 200}
+	c := Event{ID: "c", Name: "C", Time: 300}
+	aOurs := Event{ID: "a", Name: "A (ours)", Time: 100}
+	aTheirs := Event{ID: "a", Name: "A (theirs)", Time: 150}
+
+	tests := []struct {
+		name               string
+		base, ours, theirs []Event
+		want               []Event
+	}{
+		{
+			name: "Both add different events",
+			base: []Event{a}, ours: []Event{a, b}, theirs: []Event{a, c},
+			want: []Event{a, b, c},
+		},
+		{
+			name: "Remote removal applies",
+			base: []Event{a, b}, ours: []Event{a, b}, theirs: []Event{a},
+			want: []Event{a},
+		},
+		{
+			name: "Local removal applies",
+			base: []Event{a, b}, ours: []Event{b}, theirs: []Event{a, b},
+			want: []Event{b},
+		},
+		{
+			name: "Remote edit applies",
+			base: []Event{a}, ours: []Event{a}, theirs: []Event{aTheirs},
+			want: []Event{aTheirs},
+		},
+		{
+			name: "Conflicting edits keep ours",
+			base: []Event{a}, ours: []Event{aOurs}, theirs: []Event{aTheirs},
+			want: []Event{aOurs},
+		},
+		{
+			name: "Edit beats removal",
+			base: []Event{a, b}, ours: []Event{b}, theirs: []Event{aTheirs, b},
+			want: []Event{aTheirs, b},
+		},
+		{
+			name: "Unrelated histories union",
+			base: nil, ours: []Event{b}, theirs: []Event{a, b},
+			want: []Event{a, b},
+		},
+		{
+			name: "Legacy events without IDs match by name and time",
+			base: nil, ours: []Event{{Name: "Old", Time: 5}}, theirs: []Event{{Name: "Old", Time: 5}},
+			want: []Event{{Name: "Old", Time: 5}},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := mergeEvents(tt.base, tt.ours, tt.theirs)
+			if !equalEvents(got, tt.want) {
+				t.Errorf("mergeEvents() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestUnionLines(t *testing.T) {
+	got := string(unionLines([]byte("1\n2\n3\n"), []byte("1\n2\n4\n")))
+	if want := "1\n2\n3\n4\n"; got != want {
+		t.Errorf("unionLines() = %q, want %q", got, want)
+	}
+}
+
+func TestIsSyncedEventsFile(t *testing.T) {
+	for p, want := range map[string]bool{
+		"events.json":          true,
+		"profiles/work.json":   true,
+		"profiles/notes.txt":   false,
+		"config.json":          false,
+		"backups/default/x.js": false,
+		"history.jsonl":        false,
+	} {
+		if got := isSyncedEventsFile(p); got != want {
+			t.Errorf("isSyncedEventsFile(%q) = %v, want %v", p, got, want)
+		}
+	}
+}
+
+func TestGitSync(t *testing.T) {
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git not installed")
+	}
+	th := newTestHelper(t)
+	remote := filepath.Join(th.testConfigDir, "remote.git")
+	if out, err := exec.Command("git", "init", "-q", "--bare", remote).CombinedOutput(); err != nil {
+		t.Fatalf("git init --bare: %v: %s", err, out)
+	}
+	laptop := filepath.Join(th.testConfigDir, "laptop")
+	workstation := filepath.Join(th.testConfigDir, "workstation")
+	// machine switches the config directory, standing in for another host.
+	machine := func(dir string) {
+		t.Setenv("XDG_CONFIG_HOME", dir)
+	}
+
+	shared := Event{ID: "shared", Name: "Shared", Time: 1000}
+	onLaptop := Event{ID: "laptop", Name: "Laptop", Time: 2000}
+	onWorkstation := Event{ID: "workstation", Name: "Workstation", Time: 3000}
+	for _, dir := range []string{laptop, workstation} {
+		machine(dir)
+		configFile, err := getConfigFilePath()
+		if err != nil {
+			t.Fatal(err)
+		}
+		writeJSON(t, configFile, config{Sync: syncConfig{Remote: remote}})
+	}
+
+	machine(laptop)
+	if err := saveProfileEvents(defaultProfile, []Event{shared}); err != nil {
+		t.Fatalf("saveProfileEvents() on laptop failed: %v", err)
+	}
+	if err := syncEvents(); err != nil {
+		t.Fatalf("first sync from laptop failed: %v", err)
+	}
+
+	machine(workstation)
+	if err := syncEvents(); err != nil {
+		t.Fatalf("first sync on workstation failed: %v", err)
+	}
+	events, err := readProfileEvents(defaultProfile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !equalEvents(events, []Event{shared}) {
+		t.Fatalf("Expected the laptop's event on the workstation, got %v", events)
+	}
+
+	// Concurrent edits on both machines to the same file.
+	if err := saveProfileEvents(defaultProfile, []Event{shared, onWorkstation}); err != nil {
+		t.Fatal(err)
+	}
+	if err := syncEvents(); err != nil {
+		t.Fatalf("sync from workstation failed: %v", err)
+	}
+	machine(laptop)
+	if err := saveProfileEvents(defaultProfile, []Event{shared, onLaptop}); err != nil {
+		t.Fatal(err)
+	}
+	if err := syncEvents(); err != nil {
+		t.Fatalf("merging sync on laptop failed: %v", err)
+	}
+
+	want := []Event{shared, onLaptop, onWorkstation}
+	if events, _ = readProfileEvents(defaultProfile); !equalEvents(events, want) {
+		t.Errorf("Expected merged events %v on laptop, got %v", want, events)
+	}
+	machine(workstation)
+	if err := syncEvents(); err != nil {
+		t.Fatalf("final sync on workstation failed: %v", err)
+	}
+	if events, _ = readProfileEvents(defaultProfile); !equalEvents(events, want) {
+		t.Errorf("Expected merged events %v on workstation, got %v", want, events)
+	}
+}
+
+func TestSaveDuringSync(t *testing.T) {
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git not installed")
+	}
+	th := newTestHelper(t)
+	remote := filepath.Join(th.testConfigDir, "remote.git")
+	if out, err := exec.Command("git", "init", "-q", "--bare", remote).CombinedOutput(); err != nil {
+		t.Fatalf("git init --bare: %v: %s", err, out)
+	}
+	configFile, err := getConfigFilePath()
+	if err != nil {
+		t.Fatal(err)
+	}
+	writeJSON(t, configFile, config{Sync: syncConfig{Remote: remote}})
+
+	// The TUI saves while its background sync runs; neither may fail on
+	// the other's git index lock.
+	errs := make(chan error, 1)
+	go func() {
+		var err error
+		for range 5 {
+			if err = syncEvents(); err != nil {
+				break
+			}
+		}
+		errs <- err
+	}()
+	for i := range 5 {
+		event := Event{ID: strconv.Itoa(i), Name: "Save " + strconv.Itoa(i), Time: int64(1000 + i)}
+		if err := saveProfileEvents(defaultProfile, []Event{event}); err != nil {
+			t.Errorf("save %d during sync failed: %v", i, err)
+		}
+	}
+	if err := <-errs; err != nil {
+		t.Errorf("sync during saves failed: %v", err)
+	}
+}
+
+// newSyncRemote creates a bare repository and configures the current config
+// directory to sync with it, merging extra into the config.
+func newSyncRemote(t *testing.T, th *testHelper, extra config) string {
+	t.Helper()
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git not installed")
+	}
+	remote := filepath.Join(th.testConfigDir, "remote.git")
+	if out, err := exec.Command("git", "init", "-q", "--bare", remote).CombinedOutput(); err != nil {
+		t.Fatalf("git init --bare: %v: %s", err, out)
+	}
+	configFile, err := getConfigFilePath()
+	if err != nil {
+		t.Fatal(err)
+	}
+	extra.Sync = syncConfig{Remote: remote}
+	writeJSON(t, configFile, extra)
+	return remote
+}
+
+// remoteFiles lists the files on the remote's default branch.
+func remoteFiles(t *testing.T, remote string) []string {
+	t.Helper()
+	out, err := exec.Command("git", "-C", remote, "ls-tree", "-r", "--name-only", defaultSyncBranch).Output()
+	if err != nil {
+		t.Fatalf("git ls-tree: %v", err)
+	}
+	return strings.Fields(string(out))
+}
+
+func TestSyncKeepsKeyFileLocal(t *testing.T) {
+	th := newTestHelper(t)
+	remote := newSyncRemote(t, th, config{KeyFile: "passphrase.txt"})
+	eventsFile, err := getEventsFilePath()
+	if err != nil {
+		t.Fatal(err)
+	}
+	keyFile := filepath.Join(filepath.Dir(eventsFile), "passphrase.txt")
+	if err := os.WriteFile(keyFile, []byte("s3cret\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	if err := saveProfileEvents(defaultProfile, []Event{{ID: "a", Name: "A", Time: 1000}}); err != nil {
+		t.Fatal(err)
+	}
+	if err := syncEvents(); err != nil {
+		t.Fatalf("syncEvents() failed: %v", err)
+	}
+
+	files := remoteFiles(t, remote)
+	if !slices.Contains(files, eventsFileName) {
+		t.Errorf("Expected the events to be pushed, remote has %v", files)
+	}
+	if slices.Contains(files, "passphrase.txt") {
+		t.Errorf("Expected the key file to stay local, remote has %v", files)
+	}
+}
+
+func TestGitSyncMergesOtherFiles(t *testing.T) {
+	th := newTestHelper(t)
+	laptop := filepath.Join(th.testConfigDir, "laptop")
+	workstation := filepath.Join(th.testConfigDir, "workstation")
+	t.Setenv("XDG_CONFIG_HOME", laptop)
+	remote := newSyncRemote(t, th, config{})
+	t.Setenv("XDG_CONFIG_HOME", workstation)
+	configFile, err := getConfigFilePath()
+	if err != nil {
+		t.Fatal(err)
+	}
+	writeJSON(t, configFile, config{Sync: syncConfig{Remote: remote}})
+
+	t.Setenv("XDG_CONFIG_HOME", laptop)
+	shared := Event{ID: "shared", Name: "Shared", Time: 1000}
+	for _, profile := range []string{defaultProfile, "work"} {
+		if err := saveProfileEvents(profile, []Event{shared}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := syncEvents(); err != nil {
+		t.Fatalf("first sync from laptop failed: %v", err)
+	}
+
+	// The workstation drops a profile and changes .gitignore while the
+	// laptop edits its events.
+	t.Setenv("XDG_CONFIG_HOME", workstation)
+	if err := syncEvents(); err != nil {
+		t.Fatalf("first sync on workstation failed: %v", err)
+	}
+	workFile, err := getProfileFilePath("work")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Remove(workFile); err != nil {
+		t.Fatal(err)
+	}
+	ignore := filepath.Join(workstation, appName, ".gitignore")
+	if err := os.WriteFile(ignore, []byte(syncIgnore+"notes/\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if err := syncEvents(); err != nil {
+		t.Fatalf("sync from workstation failed: %v", err)
+	}
+
+	t.Setenv("XDG_CONFIG_HOME", laptop)
+	edited := Event{ID: "shared", Name: "Shared, edited", Time: 1000}
+	if err := saveProfileEvents(defaultProfile, []Event{edited}); err != nil {
+		t.Fatal(err)
+	}
+	if err := syncEvents(); err != nil {
+		t.Fatalf("merging sync on laptop failed: %v", err)
+	}
+
+	if events, _ := readProfileEvents(defaultProfile); !equalEvents(events, []Event{edited}) {
+		t.Errorf("Expected the local edit to survive the merge, got %v", events)
+	}
+	if data, err := os.ReadFile(filepath.Join(laptop, appName, ".gitignore")); err != nil || !strings.Contains(string(data), "notes/") {
+		t.Errorf("Expected the workstation's .gitignore, got %q (err %v)", data, err)
+	}
+	if workFile, err = getProfileFilePath("work"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := os.Stat(workFile); !errors.Is(err, os.ErrNotExist) {
+		t.Errorf("Expected the removed profile to be gone after the merge, got %v", err)
+	}
+}
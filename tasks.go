@@ -0,0 +1,145 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"regexp"
+	"strings"
+	"time"
+)
+
+// taskwarriorTask is the part of a task in `task export` output that
+// countdown uses.
+type taskwarriorTask struct {
+	UUID        string   `json:"uuid"`
+	Description string   `json:"description"`
+	Status      string   `json:"status"`
+	Due         string   `json:"due"`
+	Project     string   `json:"project"`
+	Tags        []string `json:"tags"`
+	Annotations []struct {
+		Description string `json:"description"`
+	} `json:"annotations"`
+}
+
+// looksLikeTaskwarrior reports whether data is `task export` output rather
+// than an events file.
+func looksLikeTaskwarrior(data []byte) bool {
+	data = bytes.TrimSpace(data)
+	return (bytes.HasPrefix(data, []byte("[")) || bytes.HasPrefix(data, []byte("{"))) &&
+		bytes.Contains(data, []byte(`"uuid"`)) && bytes.Contains(data, []byte(`"description"`))
+}
+
+// parseTaskwarrior reads the pending tasks with a due date from `task
+// export` output. Both the JSON array of current versions and the one
+// object per line of older ones are accepted. The task's UUID becomes the
+// event ID, its project and tags become tags and its annotations notes.
+func parseTaskwarrior(data []byte, _ importOptions) ([]Event, error) {
+	var tasks []taskwarriorTask
+	if bytes.HasPrefix(bytes.TrimSpace(data), []byte("[")) {
+		if err := json.Unmarshal(data, &tasks); err != nil {
+			return nil, err
+		}
+	} else {
+		dec := json.NewDecoder(bytes.NewReader(data))
+		for {
+			var task taskwarriorTask
+			err := dec.Decode(&task)
+			if err == io.EOF {
+				break
+			}
+			if err != nil {
+				return nil, err
+			}
+			tasks = append(tasks, task)
+		}
+	}
+	var events []Event
+	for _, task := range tasks {
+		// Completed and deleted tasks are done with; recurring ones are
+		// templates whose pending instances are exported separately.
+		if task.Due == "" || task.Status != "" && task.Status != "pending" && task.Status != "waiting" {
+			continue
+		}
+		due, err := time.Parse(icsDateTimeUTC, task.Due)
+		if err != nil {
+			return nil, fmt.Errorf("task %s: invalid due date %q", task.UUID, task.Due)
+		}
+		event := Event{ID: task.UUID, Name: task.Description, Time: due.Unix()}
+		if task.Project != "" {
+			event.Tags = append(event.Tags, task.Project)
+		}
+		event.Tags = append(event.Tags, task.Tags...)
+		notes := make([]string, len(task.Annotations))
+		for i, a := range task.Annotations {
+			notes[i] = a.Description
+		}
+		event.Notes = strings.Join(notes, "\n")
+		events = append(events, event)
+	}
+	return events, nil
+}
+
+var (
+	todoDue      = regexp.MustCompile(`(?m)(?:^|\s)due:\d{4}-\d{2}-\d{2}(?:\s|$)`)
+	todoPriority = regexp.MustCompile(`^\([A-Z]\)\s+`)
+	todoDate     = regexp.MustCompile(`^\d{4}-\d{2}-\d{2}\s+`)
+	// todoKeyValue matches todo.txt extensions such as t:2026-10-01 or
+	// rec:1w, whose keys start with a letter, but not URLs or times of
+	// day such as 10:30.
+	todoKeyValue = regexp.MustCompile(`^[A-Za-z][A-Za-z0-9_-]*:[^\s/]\S*$`)
+)
+
+// looksLikeTodoTxt reports whether data has todo.txt due: tags.
+func looksLikeTodoTxt(data []byte) bool {
+	return todoDue.Match(data)
+}
+
+// parseTodoTxt reads the open tasks with a due:YYYY-MM-DD tag from a
+// todo.txt file. Priorities, creation dates and other key:value tags are
+// dropped from the name, and +projects and @contexts become tags instead.
+// Tasks are due at midnight in the import zone.
+func parseTodoTxt(data []byte, opts importOptions) ([]Event, error) {
+	loc := opts.zone
+	if loc == nil {
+		loc = time.Local
+	}
+	var events []Event
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	for n := 1; scanner.Scan(); n++ {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "x ") {
+			continue
+		}
+		line = todoDate.ReplaceAllString(todoPriority.ReplaceAllString(line, ""), "")
+		var event Event
+		var words []string
+		due := ""
+		for _, word := range strings.Fields(line) {
+			switch {
+			case strings.HasPrefix(word, "due:"):
+				due = strings.TrimPrefix(word, "due:")
+			case len(word) > 1 && (word[0] == '+' || word[0] == '@'):
+				event.Tags = append(event.Tags, word[1:])
+			case todoKeyValue.MatchString(word):
+				// Another extension, such as a threshold date.
+			default:
+				words = append(words, word)
+			}
+		}
+		if due == "" {
+			continue
+		}
+		t, err := time.ParseInLocation("2006-01-02", due, loc)
+		if err != nil {
+			return nil, fmt.Errorf("line %d: invalid due date %q", n, due)
+		}
+		event.Name = strings.Join(words, " ")
+		event.Time = t.Unix()
+		events = append(events, event)
+	}
+	return events, scanner.Err()
+}
@@ -0,0 +1,139 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+)
+
+const testTaskExport = `[
+{"id":1,"uuid":"6f1c","description":"Ship release","status":"pending","due":"20261102T170000Z","project":"work","tags":["release","q4"],"annotations":[{"entry":"20261001T090000Z","description":"tag it first"}]},
+{"id":0,"uuid":"9a2b","description":"Old deadline","status":"completed","due":"20261001T170000Z"},
+{"id":2,"uuid":"c3d4","description":"Someday","status":"pending"}
+]`
+
+func TestParseTaskwarrior(t *testing.T) {
+	want := []Event{{
+		ID:    "6f1c",
+		Name:  "Ship release",
+		Time:  time.Date(2026, 11, 2, 17, 0, 0, 0, time.UTC).Unix(),
+		Tags:  []string{"work", "release", "q4"},
+		Notes: "tag it first",
+	}}
+	got, err := parseTaskwarrior([]byte(testTaskExport), importOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !equalEvents(got, want) {
+		t.Errorf("parseTaskwarrior() = %v, want %v", got, want)
+	}
+
+	// Older versions print one task per line.
+	lines := `{"uuid":"6f1c","description":"Ship release","status":"pending","due":"20261102T170000Z"}
+{"uuid":"e5f6","description":"Renew domain","status":"waiting","due":"20261201T000000Z"}`
+	got, err = parseTaskwarrior([]byte(lines), importOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(got) != 2 || got[1].Name != "Renew domain" {
+		t.Errorf("parseTaskwarrior(lines) = %v", got)
+	}
+
+	if _, err := parseTaskwarrior([]byte(`[{"uuid":"x","description":"Bad","due":"tomorrow"}]`), importOptions{}); err == nil {
+		t.Error("Expected an error for an invalid due date")
+	}
+}
+
+func TestParseTodoTxt(t *testing.T) {
+	data := `(A) 2026-10-01 Submit report +work @office due:2026-10-30 t:2026-10-20
+x 2026-10-02 2026-09-01 Pay rent due:2026-10-01
+Call dentist
+Read https://example.com/notes due:2026-11-05
+Call at 10:30 rec:1w due:2026-11-06
+`
+	loc := time.FixedZone("test", 2*3600)
+	got, err := parseTodoTxt([]byte(data), importOptions{zone: loc})
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []Event{
+		{Name: "Submit report", Time: time.Date(2026, 10, 30, 0, 0, 0, 0, loc).Unix(), Tags: []string{"work", "office"}},
+		{Name: "Read https://example.com/notes", Time: time.Date(2026, 11, 5, 0, 0, 0, 0, loc).Unix()},
+		{Name: "Call at 10:30", Time: time.Date(2026, 11, 6, 0, 0, 0, 0, loc).Unix()},
+	}
+	if !equalEvents(got, want) {
+		t.Errorf("parseTodoTxt() = %v, want %v", got, want)
+	}
+
+	if _, err := parseTodoTxt([]byte("ok due:2026-10-30\nbad due:2026-13-01\n"), importOptions{}); err == nil || err.Error() != `line 2: invalid due date "2026-13-01"` {
+		t.Errorf("Expected a line-numbered error, got %v", err)
+	}
+}
+
+func TestFindTaskFormats(t *testing.T) {
+	tests := []struct {
+		path, data, want string
+	}{
+		{"tasks.json", testTaskExport, "taskwarrior"},
+		{"events.json", `[{"name":"A","ts":1}]`, "json"},
+		{"events.json", `[]`, "json"},
+		{"todo.txt", "Submit report due:2026-10-30\n", "todotxt"},
+	}
+	for _, tt := range tests {
+		f, err := findImportFormat("", tt.path, []byte(tt.data))
+		if err != nil || f.name != tt.want {
+			t.Errorf("findImportFormat(%q) = %q, %v, want %q", tt.path, f.name, err, tt.want)
+		}
+	}
+}
+
+func TestTaskSourceRefreshesOnLoad(t *testing.T) {
+	th := newTestHelper(t)
+	todo := filepath.Join(th.testConfigDir, "todo.txt")
+	configFile, err := getConfigFilePath()
+	if err != nil {
+		t.Fatal(err)
+	}
+	writeJSON(t, configFile, config{Sources: []eventSource{{Name: "todo", Path: todo, Format: "todotxt"}}})
+
+	// load returns the events from the todo.txt source.
+	load := func() []Event {
+		t.Helper()
+		events, err := loadProfileEvents(defaultProfile)
+		if err != nil {
+			t.Fatalf("loadProfileEvents() failed: %v", err)
+		}
+		var tasks []Event
+		for _, e := range events {
+			if e.Source == "todo" {
+				tasks = append(tasks, e)
+			}
+		}
+		return tasks
+	}
+	if err := os.WriteFile(todo, []byte("Submit report due:2099-10-30\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	events := load()
+	if len(events) != 1 || events[0].Name != "Submit report" || !events[0].ReadOnly() {
+		t.Fatalf("Expected the todo.txt task as a read-only event, got %v", events)
+	}
+
+	if err := os.WriteFile(todo, []byte("x Submit report due:2099-10-30\nFile taxes due:2099-04-15\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	names := []string{}
+	for _, e := range load() {
+		names = append(names, e.Name)
+	}
+	if !reflect.DeepEqual(names, []string{"File taxes"}) {
+		t.Errorf("Expected the source to be reread, got %v", names)
+	}
+
+	writeJSON(t, configFile, config{Sources: []eventSource{{Name: "todo", Path: todo, Format: "todo"}}})
+	if _, err := loadProfileEvents(defaultProfile); err == nil {
+		t.Error("Expected an error for an unknown source format")
+	}
+}
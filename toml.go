@@ -0,0 +1,401 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+// countdown edit --all writes events as TOML, one [[event]] table each,
+// and reads back the small part of TOML it writes: comments, bare keys,
+// strings (basic, literal and multi-line), arrays of strings and
+// date-times.
+
+const tomlEventTable = "[[event]]"
+
+var (
+	tomlKey       = regexp.MustCompile(`^[A-Za-z0-9_-]+$`)
+	tomlTimeOfDay = regexp.MustCompile(`^ \d{2}:\d{2}\S*`)
+)
+
+// tomlQuote writes s as a TOML basic string.
+func tomlQuote(s string) string {
+	var b strings.Builder
+	b.WriteByte('"')
+	for _, r := range s {
+		switch r {
+		case '"':
+			b.WriteString(`\"`)
+		case '\\':
+			b.WriteString(`\\`)
+		case '\n':
+			b.WriteString(`\n`)
+		case '\t':
+			b.WriteString(`\t`)
+		case '\r':
+			b.WriteString(`\r`)
+		default:
+			if r < 0x20 || r == 0x7f {
+				fmt.Fprintf(&b, `\u%04X`, r)
+			} else {
+				b.WriteRune(r)
+			}
+		}
+	}
+	b.WriteByte('"')
+	return b.String()
+}
+
+// writeEventsTOML writes events as [[event]] tables. Times are written in
+// the local zone.
+func writeEventsTOML(w io.Writer, events []Event) error {
+	for i, e := range events {
+		if i > 0 {
+			fmt.Fprintln(w)
+		}
+		fmt.Fprintln(w, tomlEventTable)
+		if e.ID != "" {
+			fmt.Fprintf(w, "id = %s\n", tomlQuote(e.ID))
+		}
+		fmt.Fprintf(w, "name = %s\n", tomlQuote(e.Name))
+		fmt.Fprintf(w, "time = %s\n", time.Unix(e.Time, 0).Format(time.RFC3339))
+		if e.Yearly != "" {
+			fmt.Fprintf(w, "yearly = %s\n", tomlQuote(e.Yearly))
+		}
+		if len(e.Tags) > 0 {
+			tags := make([]string, len(e.Tags))
+			for i, tag := range e.Tags {
+				tags[i] = tomlQuote(tag)
+			}
+			fmt.Fprintf(w, "tags = [%s]\n", strings.Join(tags, ", "))
+		}
+		switch {
+		case e.Notes == "":
+		case strings.Contains(e.Notes, "\n") && !strings.Contains(e.Notes, "'''") && !strings.ContainsRune(e.Notes, '\r'):
+			fmt.Fprintf(w, "notes = '''\n%s'''\n", e.Notes)
+		default:
+			fmt.Fprintf(w, "notes = %s\n", tomlQuote(e.Notes))
+		}
+	}
+	return nil
+}
+
+// tomlReader reads a document line by line, for multi-line strings.
+type tomlReader struct {
+	lines []string
+	// line is the 1-based number of the last line read.
+	line int
+	// loc is the zone of date-times without an offset.
+	loc *time.Location
+}
+
+func (r *tomlReader) next() (string, bool) {
+	if r.line >= len(r.lines) {
+		return "", false
+	}
+	r.line++
+	return r.lines[r.line-1], true
+}
+
+// parseEventsTOML reads what writeEventsTOML writes, reporting every
+// problem with its line number. Times without an offset are in loc.
+func parseEventsTOML(data []byte, loc *time.Location) ([]Event, error) {
+	r := &tomlReader{lines: strings.Split(strings.ReplaceAll(string(data), "\r\n", "\n"), "\n"), loc: loc}
+	var events []Event
+	var starts []int
+	var errs []error
+	fail := func(line int, format string, args ...any) {
+		errs = append(errs, fmt.Errorf("line %d: %s", line, fmt.Sprintf(format, args...)))
+	}
+	// keys are the keys set in the current table.
+	var keys map[string]bool
+	for {
+		line, ok := r.next()
+		if !ok {
+			break
+		}
+		n := r.line
+		line = strings.TrimSpace(line)
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		if strings.HasPrefix(line, "[") {
+			if table, _, _ := strings.Cut(line, "#"); strings.TrimSpace(table) != tomlEventTable {
+				fail(n, "unknown table %s, only %s is allowed", strings.TrimSpace(table), tomlEventTable)
+				continue
+			}
+			events = append(events, Event{})
+			starts = append(starts, n)
+			keys = make(map[string]bool)
+			continue
+		}
+		key, value, ok := strings.Cut(line, "=")
+		key = strings.TrimSpace(key)
+		if !ok || !tomlKey.MatchString(key) {
+			fail(n, "expected key = value")
+			continue
+		}
+		v, err := r.value(strings.TrimSpace(value))
+		if err != nil {
+			fail(n, "%v", err)
+			continue
+		}
+		if len(events) == 0 {
+			fail(n, "%s before the first %s", key, tomlEventTable)
+			continue
+		}
+		if keys[key] {
+			fail(n, "%s is set twice", key)
+			continue
+		}
+		keys[key] = true
+		if err := setEventField(&events[len(events)-1], key, v, loc); err != nil {
+			fail(n, "%v", err)
+		}
+	}
+	// ids maps each id to the line of the first table using it. A copied
+	// table must not keep the id, or both copies would be the same event.
+	ids := make(map[string]int)
+	for i, e := range events {
+		switch {
+		case strings.TrimSpace(e.Name) == "":
+			fail(starts[i], "event has no name")
+		case e.Time == 0 && e.Yearly == "":
+			fail(starts[i], "%q has no time", e.Name)
+		}
+		if e.ID == "" {
+			continue
+		}
+		if first, ok := ids[e.ID]; ok {
+			fail(starts[i], "id %q is already used on line %d, remove it from a copied event", e.ID, first)
+			continue
+		}
+		ids[e.ID] = starts[i]
+	}
+	return events, errors.Join(errs...)
+}
+
+// setEventField sets the field of e named by a TOML key.
+func setEventField(e *Event, key string, v any, loc *time.Location) error {
+	s, isString := v.(string)
+	switch key {
+	case "id", "name", "notes", "yearly":
+		if !isString {
+			return fmt.Errorf("%s must be a string", key)
+		}
+		switch key {
+		case "id":
+			e.ID = s
+		case "name":
+			e.Name = s
+		case "notes":
+			e.Notes = s
+		case "yearly":
+			if _, err := parseYearly(s); err != nil {
+				return err
+			}
+			e.Yearly = s
+		}
+	case "time":
+		if isString {
+			t, err := parseTOMLTime(s, loc)
+			if err != nil {
+				return err
+			}
+			v = t
+		}
+		t, ok := v.(time.Time)
+		if !ok {
+			return fmt.Errorf("time must be a date-time such as %s", time.Date(2026, 11, 10, 9, 0, 0, 0, loc).Format(time.RFC3339))
+		}
+		e.Time = t.Unix()
+	case "tags":
+		tags, ok := v.([]string)
+		if !ok {
+			return errors.New(`tags must be an array of strings, e.g. ["release", "freeze"]`)
+		}
+		e.Tags = tags
+	default:
+		return fmt.Errorf("unknown key %q, use id, name, time, yearly, tags or notes", key)
+	}
+	return nil
+}
+
+// parseTOMLTime reads an offset or local date-time, or a date, which is
+// midnight.
+func parseTOMLTime(s string, loc *time.Location) (time.Time, error) {
+	s = strings.Replace(s, " ", "T", 1)
+	if t, err := time.Parse(time.RFC3339Nano, s); err == nil {
+		return t, nil
+	}
+	for _, layout := range []string{"2006-01-02T15:04:05", "2006-01-02T15:04", "2006-01-02"} {
+		if t, err := time.ParseInLocation(layout, s, loc); err == nil {
+			return t, nil
+		}
+	}
+	return time.Time{}, fmt.Errorf("invalid date-time %q", s)
+}
+
+// value parses the value at the start of s, which may continue on the
+// following lines for multi-line strings. Anything after it must be a
+// comment.
+func (r *tomlReader) value(s string) (any, error) {
+	var v any
+	var rest string
+	var err error
+	switch {
+	case strings.HasPrefix(s, `"""`), strings.HasPrefix(s, "'''"):
+		v, rest, err = r.multiLineString(s)
+	case strings.HasPrefix(s, `"`), strings.HasPrefix(s, "'"):
+		v, rest, err = tomlString(s)
+	case strings.HasPrefix(s, "["):
+		v, rest, err = tomlArray(s)
+	case s == "" || strings.HasPrefix(s, "#"):
+		return nil, errors.New("missing value")
+	default:
+		token := s
+		if i := strings.IndexAny(s, " \t#"); i >= 0 {
+			token, rest = s[:i], s[i:]
+			// A space may separate the date and time.
+			if m := tomlTimeOfDay.FindString(rest); m != "" && len(token) == len("2006-01-02") {
+				token, rest = token+m, rest[len(m):]
+			}
+		}
+		v, err = parseTOMLTime(token, r.loc)
+		if err != nil {
+			return nil, fmt.Errorf("invalid value %q, strings need quotes", token)
+		}
+	}
+	if err != nil {
+		return nil, err
+	}
+	if rest = strings.TrimSpace(rest); rest != "" && !strings.HasPrefix(rest, "#") {
+		return nil, fmt.Errorf("unexpected %q after the value", rest)
+	}
+	return v, nil
+}
+
+// tomlString reads a single-line basic or literal string at the start of
+// s and returns it with what follows.
+func tomlString(s string) (string, string, error) {
+	quote := s[0]
+	for i := 1; i < len(s); i++ {
+		switch {
+		case s[i] == '\\' && quote == '"':
+			i++
+		case s[i] == quote:
+			if quote == '\'' {
+				return s[1:i], s[i+1:], nil
+			}
+			str, err := tomlUnescape(s[1:i])
+			return str, s[i+1:], err
+		}
+	}
+	return "", "", errors.New("unterminated string")
+}
+
+// multiLineString reads a """ or ”' string, which may span lines. A
+// newline right after the opening quotes is dropped.
+func (r *tomlReader) multiLineString(s string) (string, string, error) {
+	delim := s[:3]
+	var b strings.Builder
+	text, first := s[3:], true
+	for {
+		if i := strings.Index(text, delim); i >= 0 {
+			b.WriteString(text[:i])
+			str := b.String()
+			if delim == `"""` {
+				var err error
+				if str, err = tomlUnescape(str); err != nil {
+					return "", "", err
+				}
+			}
+			return str, text[i+3:], nil
+		}
+		if !first || text != "" {
+			b.WriteString(text)
+			b.WriteByte('\n')
+		}
+		first = false
+		var ok bool
+		if text, ok = r.next(); !ok {
+			return "", "", errors.New("unterminated multi-line string")
+		}
+	}
+}
+
+// tomlArray reads a single-line array of strings.
+func tomlArray(s string) ([]string, string, error) {
+	items := []string{}
+	s = strings.TrimSpace(s[1:])
+	for {
+		if strings.HasPrefix(s, "]") {
+			return items, s[1:], nil
+		}
+		if s == "" || s[0] != '"' && s[0] != '\'' {
+			return nil, "", errors.New(`expected a string or ] in the array`)
+		}
+		item, rest, err := tomlString(s)
+		if err != nil {
+			return nil, "", err
+		}
+		items = append(items, item)
+		s = strings.TrimSpace(rest)
+		if strings.HasPrefix(s, ",") {
+			s = strings.TrimSpace(s[1:])
+		} else if !strings.HasPrefix(s, "]") {
+			return nil, "", errors.New("expected , or ] in the array")
+		}
+	}
+}
+
+// tomlUnescape resolves the escapes of a basic string.
+func tomlUnescape(s string) (string, error) {
+	var b strings.Builder
+	for i := 0; i < len(s); i++ {
+		if s[i] != '\\' {
+			b.WriteByte(s[i])
+			continue
+		}
+		i++
+		if i == len(s) {
+			return "", errors.New("string ends with a backslash")
+		}
+		switch c := s[i]; c {
+		case '"', '\\':
+			b.WriteByte(c)
+		case 'n':
+			b.WriteByte('\n')
+		case 't':
+			b.WriteByte('\t')
+		case 'r':
+			b.WriteByte('\r')
+		case 'b':
+			b.WriteByte('\b')
+		case 'f':
+			b.WriteByte('\f')
+		case 'u', 'U':
+			size := 4
+			if c == 'U' {
+				size = 8
+			}
+			if i+size >= len(s) {
+				return "", fmt.Errorf(`short \%c escape`, c)
+			}
+			code, err := strconv.ParseUint(s[i+1:i+1+size], 16, 32)
+			if err != nil || !utf8.ValidRune(rune(code)) {
+				return "", fmt.Errorf(`invalid \%c escape`, c)
+			}
+			b.WriteRune(rune(code))
+			i += size
+		default:
+			return "", fmt.Errorf(`invalid escape \%c`, c)
+		}
+	}
+	return b.String(), nil
+}
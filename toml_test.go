@@ -0,0 +1,91 @@
+package main
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestEventsTOMLRoundTrip(t *testing.T) {
+	events := []Event{
+		{ID: "a1", Name: `Say "hi"`, Time: 1900000000, Tags: []string{"release", "x\\y"}},
+		{ID: "b2", Name: "Ada's birthday", Time: 1901000000, Yearly: "1990-03-01", Notes: "line one\nline two"},
+		{ID: "c3", Name: "Tab\there", Time: 1902000000, Notes: "has ''' quotes\nand lines"},
+	}
+	var buf bytes.Buffer
+	if err := writeEventsTOML(&buf, events); err != nil {
+		t.Fatal(err)
+	}
+	got, err := parseEventsTOML(buf.Bytes(), time.UTC)
+	if err != nil {
+		t.Fatalf("parseEventsTOML() failed: %v\n%s", err, buf.String())
+	}
+	if !equalEvents(got, events) {
+		t.Errorf("Expected %v, got %v\n%s", events, got, buf.String())
+	}
+}
+
+func TestParseEventsTOML(t *testing.T) {
+	doc := `# comment
+[[event]]  # the first
+name = 'Launch'   # literal
+time = 2030-01-15 09:30:00
+tags = []
+
+[[event]]
+name = "Review"
+time = "2030-02-01"
+notes = """
+Bring éclairs"""
+`
+	events, err := parseEventsTOML([]byte(doc), time.UTC)
+	if err != nil {
+		t.Fatalf("parseEventsTOML() failed: %v", err)
+	}
+	want := []Event{
+		{Name: "Launch", Time: time.Date(2030, 1, 15, 9, 30, 0, 0, time.UTC).Unix(), Tags: []string{}},
+		{Name: "Review", Time: time.Date(2030, 2, 1, 0, 0, 0, 0, time.UTC).Unix(), Notes: "Bring éclairs"},
+	}
+	if !equalEvents(events, want) {
+		t.Errorf("Expected %v, got %v", want, events)
+	}
+}
+
+func TestParseEventsTOMLErrors(t *testing.T) {
+	doc := `name = "orphan"
+[[event]]
+name = "Launch"
+time = tomorrow
+[event]
+[[event]]
+id = "a"
+name = "One"
+time = 2030-01-01T00:00:00Z
+colour = "red"
+[[event]]
+id = "a"
+time = 2030-01-01T00:00:00Z
+time = 2030-01-02T00:00:00Z
+tags = ["x" "y"]
+`
+	_, err := parseEventsTOML([]byte(doc), time.UTC)
+	if err == nil {
+		t.Fatal("Expected errors")
+	}
+	for _, want := range []string{
+		`line 1: name before the first [[event]]`,
+		`line 4: invalid value "tomorrow", strings need quotes`,
+		`line 5: unknown table [event]`,
+		`line 10: unknown key "colour"`,
+		`line 14: time is set twice`,
+		`line 15: expected , or ] in the array`,
+		`line 2: "Launch" has no time`,
+		`line 11: event has no name`,
+		`line 11: id "a" is already used on line 6`,
+	} {
+		if !strings.Contains(err.Error(), want) {
+			t.Errorf("Expected %q in:\n%v", want, err)
+		}
+	}
+}
@@ -0,0 +1,132 @@
+package main
+
+import (
+	"bytes"
+	"cmp"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// vcardDate matches the dates vCard 3 and 4 use for BDAY and ANNIVERSARY:
+// 19850412, 1985-04-12 and, without a year, --0412 or --04-12. A time of
+// day after the date is ignored.
+var vcardDate = regexp.MustCompile(`^(\d{4}|-)-?(\d{2})-?(\d{2})`)
+
+// vcardDates are the properties imported as yearly events and the tag each
+// gets. X-ANNIVERSARY is what vCard 3 address books use.
+var vcardDates = []struct{ property, tag string }{
+	{"BDAY", "birthday"},
+	{"ANNIVERSARY", "anniversary"},
+	{"X-ANNIVERSARY", "anniversary"},
+}
+
+// looksLikeVCard reports whether data is a vCard file.
+func looksLikeVCard(data []byte) bool {
+	return bytes.HasPrefix(bytes.ToUpper(bytes.TrimSpace(data)), []byte("BEGIN:VCARD"))
+}
+
+// parseVCardDate returns the date of a BDAY or ANNIVERSARY property. ok is
+// false for free-text values such as "circa 1800".
+func parseVCardDate(p icsProperty) (d yearlyDate, ok bool, err error) {
+	if strings.EqualFold(p.Params["VALUE"], "text") {
+		return yearlyDate{}, false, nil
+	}
+	m := vcardDate.FindStringSubmatch(p.Value)
+	if m == nil {
+		return yearlyDate{}, false, nil
+	}
+	value := "--" + m[2] + "-" + m[3]
+	// Apple Contacts stores dates without a year in the year given by
+	// X-APPLE-OMIT-YEAR.
+	if m[1] != "-" && m[1] != p.Params["X-APPLE-OMIT-YEAR"] {
+		value = m[1] + "-" + m[2] + "-" + m[3]
+	}
+	d, err = parseYearly(value)
+	return d, err == nil, err
+}
+
+// vcardName returns a contact's display name from FN, or else from the
+// given and family names in N.
+func vcardName(card icsComponent) string {
+	if fn := strings.TrimSpace(card["FN"].text()); fn != "" {
+		return fn
+	}
+	parts := strings.Split(card["N"].Value, ";")
+	if len(parts) < 2 {
+		return strings.TrimSpace(icsTextUnescaper.Replace(parts[0]))
+	}
+	return strings.TrimSpace(icsTextUnescaper.Replace(parts[1] + " " + parts[0]))
+}
+
+// parseVCard reads the birthdays and anniversaries of the contacts in a
+// vCard file as yearly events at midnight in the import zone, named e.g.
+// "Ada Lovelace's birthday". Contacts without either are skipped.
+func parseVCard(data []byte, opts importOptions) ([]Event, error) {
+	loc := opts.zone
+	if loc == nil {
+		loc = time.Local
+	}
+	now := time.Now().In(loc)
+	var cards []icsComponent
+	var card icsComponent
+	for n, line := range unfoldICS(data) {
+		if line == "" {
+			continue
+		}
+		p, ok := parseICSLine(line)
+		if !ok {
+			return nil, fmt.Errorf("line %d: malformed content line %q", n+1, line)
+		}
+		// Drop the group of properties such as item1.BDAY.
+		if _, name, ok := strings.Cut(p.Name, "."); ok {
+			p.Name = name
+		}
+		switch {
+		case p.Name == "BEGIN" && strings.EqualFold(p.Value, "VCARD"):
+			card = icsComponent{}
+		case p.Name == "END" && strings.EqualFold(p.Value, "VCARD"):
+			if card != nil {
+				cards = append(cards, card)
+			}
+			card = nil
+		case card != nil:
+			if _, seen := card[p.Name]; !seen {
+				card[p.Name] = p
+			}
+		}
+	}
+
+	var events []Event
+	for i, card := range cards {
+		name := vcardName(card)
+		seen := make(map[string]bool)
+		for _, date := range vcardDates {
+			p, ok := card[date.property]
+			if !ok || seen[date.tag] {
+				continue
+			}
+			d, ok, err := parseVCardDate(p)
+			if err != nil {
+				return nil, fmt.Errorf("contact %s: %w", cmp.Or(name, strconv.Itoa(i+1)), err)
+			}
+			if !ok {
+				continue
+			}
+			seen[date.tag] = true
+			event := Event{
+				Name:   name + "'s " + date.tag,
+				Time:   d.next(now).Unix(),
+				Tags:   []string{date.tag},
+				Yearly: d.String(),
+			}
+			if uid := card["UID"].Value; uid != "" {
+				event.ID = uid + "-" + date.tag
+			}
+			events = append(events, event)
+		}
+	}
+	return events, nil
+}
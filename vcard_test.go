@@ -0,0 +1,74 @@
+package main
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+const testVCard = `BEGIN:VCARD
+VERSION:3.0
+UID:ada
+FN:Ada Lovelace
+BDAY:1815-12-10
+X-ANNIVERSARY:1835-07-08
+END:VCARD
+BEGIN:VCARD
+VERSION:4.0
+N:Hopper;Grace;;;
+BDAY:--1209
+ANNIVERSARY;VALUE=text:sometime in spring
+END:VCARD
+BEGIN:VCARD
+VERSION:3.0
+FN:Leap Year
+item1.BDAY;X-APPLE-OMIT-YEAR=1604:1604-02-29
+END:VCARD
+BEGIN:VCARD
+VERSION:3.0
+FN:No Dates
+END:VCARD
+`
+
+func TestParseVCard(t *testing.T) {
+	events, err := parseVCard([]byte(strings.ReplaceAll(testVCard, "\n", "\r\n")), importOptions{zone: time.UTC})
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []struct{ id, name, yearly string }{
+		{"ada-birthday", "Ada Lovelace's birthday", "1815-12-10"},
+		{"ada-anniversary", "Ada Lovelace's anniversary", "1835-07-08"},
+		{"", "Grace Hopper's birthday", "--12-09"},
+		{"", "Leap Year's birthday", "--02-29"},
+	}
+	if len(events) != len(want) {
+		t.Fatalf("Expected %d events, got %v", len(want), events)
+	}
+	now := time.Now().In(time.UTC)
+	for i, w := range want {
+		e := events[i]
+		if e.ID != w.id || e.Name != w.name || e.Yearly != w.yearly {
+			t.Errorf("events[%d] = %+v, want %+v", i, e, w)
+		}
+		d, _ := parseYearly(e.Yearly)
+		if e.Time != d.next(now).Unix() {
+			t.Errorf("events[%d] is at %v, want its next occurrence", i, time.Unix(e.Time, 0))
+		}
+	}
+	if tags := events[1].Tags; len(tags) != 1 || tags[0] != "anniversary" {
+		t.Errorf("Expected the anniversary tag, got %v", tags)
+	}
+
+	if _, err := parseVCard([]byte("BEGIN:VCARD\nFN:Bad\nBDAY:1815-13-40\nEND:VCARD\n"), importOptions{}); err == nil {
+		t.Error("Expected an error for an invalid birthday")
+	}
+}
+
+func TestFindVCardFormat(t *testing.T) {
+	for _, path := range []string{"contacts.vcf", "export"} {
+		f, err := findImportFormat("", path, []byte(testVCard))
+		if err != nil || f.name != "vcard" {
+			t.Errorf("findImportFormat(%q) = %q, %v, want vcard", path, f.name, err)
+		}
+	}
+}
@@ -0,0 +1,139 @@
+package main
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// yearlyDate is the date a yearly event recurs on. year is 0 when it
+// isn't known, as for birthdays given without one.
+type yearlyDate struct {
+	year  int
+	month time.Month
+	day   int
+}
+
+// parseYearly parses an Event's Yearly field: YYYY-MM-DD, or --MM-DD
+// without a year.
+func parseYearly(s string) (yearlyDate, error) {
+	value := s
+	if rest, ok := strings.CutPrefix(s, "--"); ok {
+		// Parse in a leap year so Feb 29 is valid.
+		value = "2000-" + rest
+	}
+	t, err := time.Parse("2006-01-02", value)
+	if err != nil {
+		return yearlyDate{}, fmt.Errorf("invalid yearly date %q, use YYYY-MM-DD or --MM-DD", s)
+	}
+	d := yearlyDate{month: t.Month(), day: t.Day()}
+	if !strings.HasPrefix(s, "--") {
+		d.year = t.Year()
+	}
+	return d, nil
+}
+
+func (d yearlyDate) String() string {
+	if d.year == 0 {
+		return fmt.Sprintf("--%02d-%02d", d.month, d.day)
+	}
+	return fmt.Sprintf("%04d-%02d-%02d", d.year, d.month, d.day)
+}
+
+// in returns the start of the date in the given year. Feb 29 falls on
+// Feb 28 in common years, so it stays in February.
+func (d yearlyDate) in(year int, loc *time.Location) time.Time {
+	day := d.day
+	if d.month == time.February && day == 29 && !isLeapYear(year) {
+		day = 28
+	}
+	return time.Date(year, d.month, day, 0, 0, 0, 0, loc)
+}
+
+// next returns the first occurrence that hasn't ended by now. An event
+// stays on today's date until midnight.
+func (d yearlyDate) next(now time.Time) time.Time {
+	today := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, now.Location())
+	t := d.in(now.Year(), now.Location())
+	if t.Before(today) {
+		t = d.in(now.Year()+1, now.Location())
+	}
+	return t
+}
+
+func isLeapYear(year int) bool {
+	return year%4 == 0 && (year%100 != 0 || year%400 == 0)
+}
+
+// ordinal returns n with its English suffix, e.g. "21st".
+func ordinal(n int) string {
+	suffix := "th"
+	if n%100 < 11 || n%100 > 13 {
+		switch n % 10 {
+		case 1:
+			suffix = "st"
+		case 2:
+			suffix = "nd"
+		case 3:
+			suffix = "rd"
+		}
+	}
+	return strconv.Itoa(n) + suffix
+}
+
+// onItsDay reports whether e is a yearly event whose date is today. It
+// starts at midnight, but stays on today's date, and isn't over, until the
+// day ends.
+func (e Event) onItsDay(now time.Time) bool {
+	if e.Yearly == "" {
+		return false
+	}
+	start := time.Unix(e.Time, 0).In(now.Location())
+	return !now.Before(start) && now.Before(start.AddDate(0, 0, 1))
+}
+
+// timeLeft returns the time until e, which is zero rather than negative
+// all through a yearly event's day.
+func (e Event) timeLeft(now time.Time) time.Duration {
+	if e.onItsDay(now) {
+		return 0
+	}
+	return time.Unix(e.Time, 0).Sub(now)
+}
+
+// occurrence moves a yearly event to its next occurrence as of now. Other
+// events, and yearly ones with an invalid date, are returned unchanged.
+func (e Event) occurrence(now time.Time) Event {
+	if e.Yearly == "" {
+		return e
+	}
+	d, err := parseYearly(e.Yearly)
+	if err != nil {
+		return e
+	}
+	e.Time = d.next(now).Unix()
+	return e
+}
+
+// yearlySummary describes a yearly event for the detail panel, e.g. "every
+// Apr 12, turns 40" for birthdays or "every Jun 3, 10th anniversary".
+func (e Event) yearlySummary() string {
+	d, err := parseYearly(e.Yearly)
+	if err != nil {
+		return e.Yearly
+	}
+	summary := "every " + d.in(2000, time.UTC).Format("Jan 2")
+	if d.year == 0 {
+		return summary
+	}
+	n := time.Unix(e.Time, 0).Year() - d.year
+	switch {
+	case n <= 0:
+		return summary
+	case e.hasTag("birthday"):
+		return fmt.Sprintf("%s, turns %d", summary, n)
+	default:
+		return fmt.Sprintf("%s, %s anniversary", summary, ordinal(n))
+	}
+}
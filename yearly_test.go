@@ -0,0 +1,178 @@
+package main
+
+import (
+	"testing"
+	"time"
+
+	tea "charm.land/bubbletea/v2"
+)
+
+func TestParseYearly(t *testing.T) {
+	tests := []struct {
+		in      string
+		want    yearlyDate
+		wantErr bool
+	}{
+		{in: "1985-04-12", want: yearlyDate{1985, time.April, 12}},
+		{in: "--04-12", want: yearlyDate{0, time.April, 12}},
+		{in: "--02-29", want: yearlyDate{0, time.February, 29}},
+		{in: "1985-02-29", wantErr: true},
+		{in: "April 12", wantErr: true},
+	}
+	for _, tt := range tests {
+		got, err := parseYearly(tt.in)
+		if (err != nil) != tt.wantErr || got != tt.want {
+			t.Errorf("parseYearly(%q) = %v, %v, want %v", tt.in, got, err, tt.want)
+			continue
+		}
+		if err == nil && got.String() != tt.in {
+			t.Errorf("String() = %q, want %q", got.String(), tt.in)
+		}
+	}
+}
+
+func TestYearlyNext(t *testing.T) {
+	loc := time.UTC
+	leapling := yearlyDate{2004, time.February, 29}
+	tests := []struct {
+		date yearlyDate
+		now  time.Time
+		want time.Time
+	}{
+		{yearlyDate{0, time.April, 12}, time.Date(2026, 3, 1, 12, 0, 0, 0, loc), time.Date(2026, 4, 12, 0, 0, 0, 0, loc)},
+		{yearlyDate{0, time.April, 12}, time.Date(2026, 4, 12, 23, 0, 0, 0, loc), time.Date(2026, 4, 12, 0, 0, 0, 0, loc)},
+		{yearlyDate{0, time.April, 12}, time.Date(2026, 4, 13, 0, 0, 0, 0, loc), time.Date(2027, 4, 12, 0, 0, 0, 0, loc)},
+		{leapling, time.Date(2026, 10, 18, 0, 0, 0, 0, loc), time.Date(2027, 2, 28, 0, 0, 0, 0, loc)},
+		{leapling, time.Date(2027, 3, 1, 0, 0, 0, 0, loc), time.Date(2028, 2, 29, 0, 0, 0, 0, loc)},
+	}
+	for _, tt := range tests {
+		if got := tt.date.next(tt.now); !got.Equal(tt.want) {
+			t.Errorf("%v.next(%v) = %v, want %v", tt.date, tt.now, got, tt.want)
+		}
+	}
+}
+
+func TestYearlyEventOnItsDay(t *testing.T) {
+	loc := time.Local
+	day := time.Date(2026, 4, 12, 0, 0, 0, 0, loc)
+	birthday := Event{Name: "Ada's birthday", Yearly: "--04-12"}.occurrence(day.Add(13 * time.Hour))
+	later := Event{Name: "Review", Time: day.AddDate(0, 0, 2).Unix()}
+	events := []Event{birthday, later}
+
+	noon := day.Add(12 * time.Hour)
+	if !birthday.onItsDay(noon) || birthday.timeLeft(noon) != 0 {
+		t.Fatalf("Expected the birthday to be under way at noon, %v left", birthday.timeLeft(noon))
+	}
+	defer func(now func() time.Time) { clock = now }(clock)
+	clock = func() time.Time { return noon }
+	if got := birthday.Description(); got != todayText {
+		t.Errorf("Expected %q in the list, got %q", todayText, got)
+	}
+	if next, ok := nextEvent(events, noon); !ok || next.Name != birthday.Name {
+		t.Errorf("Expected the birthday to stay the next event all day, got %v", next)
+	}
+	if fields := newStatusFields(birthday, noon); fields.Short != "today" || fields.Left != 0 {
+		t.Errorf("Expected status to say today, got %q with %v left", fields.Short, fields.Left)
+	}
+
+	// At midnight it is over and moves on to next year.
+	midnight := day.AddDate(0, 0, 1)
+	if birthday.onItsDay(midnight) || birthday.occurrence(midnight).Time != day.AddDate(1, 0, 0).Unix() {
+		t.Errorf("Expected the birthday to move on at midnight")
+	}
+	if next, _ := nextEvent(events, midnight); next.Name != later.Name {
+		t.Errorf("Expected the next event after the day, got %v", next)
+	}
+	if (Event{Name: "Launch", Time: day.Unix()}).onItsDay(noon) {
+		t.Error("Expected a one-off event to be over once its time passes")
+	}
+}
+
+func TestEditYearlyEventOnItsDay(t *testing.T) {
+	newTestHelper(t)
+	day := time.Date(2026, 4, 12, 0, 0, 0, 0, time.Local)
+	noon := day.Add(12 * time.Hour)
+	defer func(now func() time.Time) { clock = now }(clock)
+	clock = func() time.Time { return noon }
+	birthday := Event{ID: "ada", Name: "Ada's birthday", Yearly: "--04-12"}.occurrence(noon)
+	if err := saveProfileEvents(defaultProfile, []Event{birthday}); err != nil {
+		t.Fatal(err)
+	}
+	m, err := NewMainModel(defaultProfile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	updated, _ := m.Update(tea.KeyPressMsg{Code: 'e', Text: "e"})
+	m = updated.(MainModel)
+	m.inputs[inputNameField].SetValue("Ada Lovelace's birthday")
+	m.focus = int(inputSubmitButton)
+	updated, _ = m.Update(tea.KeyPressMsg{Code: tea.KeyEnter})
+	m = updated.(MainModel)
+
+	if m.state != showEvents {
+		t.Fatalf("Expected the edit to be saved on the event's day, got %q", m.inputStatus)
+	}
+	events, err := readProfileEvents(defaultProfile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(events) != 1 || events[0].Name != "Ada Lovelace's birthday" || events[0].Time != day.Unix() || events[0].Yearly != "--04-12" {
+		t.Errorf("Expected the renamed birthday still today, got %v", events)
+	}
+
+	// Moving it to a date already past this year moves it to next year.
+	updated, _ = m.Update(tea.KeyPressMsg{Code: 'e', Text: "e"})
+	m = updated.(MainModel)
+	m.inputs[inputTimeField].SetValue("2026-03-01")
+	m.focus = int(inputSubmitButton)
+	updated, _ = m.Update(tea.KeyPressMsg{Code: tea.KeyEnter})
+	m = updated.(MainModel)
+	events, err = readProfileEvents(defaultProfile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := time.Date(2027, 3, 1, 0, 0, 0, 0, time.Local).Unix()
+	if len(events) != 1 || events[0].Time != want || events[0].Yearly != "--03-01" {
+		t.Errorf("Expected the birthday on Mar 1 next year, got %v", events)
+	}
+}
+
+func TestYearlySummary(t *testing.T) {
+	at := func(year int, month time.Month, day int) int64 {
+		return time.Date(year, month, day, 0, 0, 0, 0, time.Local).Unix()
+	}
+	tests := []struct {
+		event Event
+		want  string
+	}{
+		{Event{Yearly: "1986-04-12", Time: at(2026, 4, 12), Tags: []string{"birthday"}}, "every Apr 12, turns 40"},
+		{Event{Yearly: "--04-12", Time: at(2026, 4, 12), Tags: []string{"birthday"}}, "every Apr 12"},
+		{Event{Yearly: "2004-02-29", Time: at(2027, 2, 28), Tags: []string{"Birthday"}}, "every Feb 29, turns 23"},
+		{Event{Yearly: "2015-06-03", Time: at(2026, 6, 3)}, "every Jun 3, 11th anniversary"},
+		{Event{Yearly: "2024-06-03", Time: at(2026, 6, 3)}, "every Jun 3, 2nd anniversary"},
+	}
+	for _, tt := range tests {
+		if got := tt.event.yearlySummary(); got != tt.want {
+			t.Errorf("yearlySummary(%v) = %q, want %q", tt.event, got, tt.want)
+		}
+	}
+}
+
+func TestLoadProfileEventsMovesYearlyEvents(t *testing.T) {
+	newTestHelper(t)
+	past := time.Now().AddDate(-3, 0, -1)
+	yearly := yearlyDate{past.Year(), past.Month(), past.Day()}
+	if err := saveProfileEvents(defaultProfile, []Event{{Name: "Anniversary", Time: past.Unix(), Yearly: yearly.String()}}); err != nil {
+		t.Fatal(err)
+	}
+	events, err := loadProfileEvents(defaultProfile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(events) != 1 {
+		t.Fatalf("Expected 1 event, got %v", events)
+	}
+	if want := yearly.next(time.Now()).Unix(); events[0].Time != want {
+		t.Errorf("Expected the next occurrence %v, got %v", time.Unix(want, 0), time.Unix(events[0].Time, 0))
+	}
+}